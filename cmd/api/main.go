@@ -1,11 +1,13 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"log"
 
 	"banking-ledger/internal/api"
 	"banking-ledger/internal/config"
+	"banking-ledger/internal/outbox"
 	"banking-ledger/internal/queue"
 	"banking-ledger/internal/repository/mongodb"
 	"banking-ledger/internal/repository/postgres"
@@ -45,17 +47,55 @@ func main() {
 	}
 	defer producer.Close()
 
+	// Outbox relay: publishes the messages stored with pending transactions
+	outboxRepo := mongodb.NewOutboxRepository(mongoDB)
+	relay := outbox.NewRelay(outboxRepo, producer, cfg.OutboxPollInterval)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go relay.Run(ctx)
+
 	accountRepo := postgres.NewAccountRepository(postgresDB)
+	journalRepo := postgres.NewJournalRepository(postgresDB)
+	idempotencyRepo := postgres.NewIdempotencyRepository(postgresDB)
+	fxRateRepo := postgres.NewFXRateRepository(postgresDB)
+	holdRepo := postgres.NewHoldRepository(postgresDB)
+	limitRepo := postgres.NewLimitRepository(postgresDB)
+	feeRepo := postgres.NewFeeRepository(postgresDB)
+	interestRepo := postgres.NewInterestRepository(postgresDB)
+	scheduledRepo := postgres.NewScheduledTransactionRepository(postgresDB)
 	transactionRepo := mongodb.NewTransactionRepository(mongoDB)
+	unitOfWork := postgres.NewUnitOfWork(postgresDB)
 
-	accountService := service.NewAccountService(accountRepo)
+	accountService := service.NewAccountService(accountRepo, journalRepo, unitOfWork)
 	transactionService := service.NewTransactionService(
 		transactionRepo,
 		accountRepo,
-		producer,
+		fxRateRepo,
+		holdRepo,
+		limitRepo,
 	)
 
-	handler := api.NewHandler(accountService, transactionService)
+	// Releases holds that were neither captured nor voided in time
+	holdService := service.NewHoldService(accountRepo, holdRepo, unitOfWork)
+	go holdService.RunExpiry(ctx, cfg.HoldExpiryInterval)
+
+	idempotencyService := service.NewIdempotencyService(idempotencyRepo)
+	limitService := service.NewLimitService(limitRepo, accountRepo)
+	feeService := service.NewFeeService(feeRepo, accountRepo)
+	interestService := service.NewInterestService(interestRepo, accountRepo, journalRepo, transactionRepo)
+	scheduledService := service.NewScheduledTransactionService(scheduledRepo, accountRepo, transactionRepo, transactionService)
+	statementService := service.NewStatementService(accountRepo, journalRepo, transactionRepo)
+
+	fxService := service.NewFXService(fxRateRepo)
+	if cfg.FXRatesFile != "" {
+		loaded, err := fxService.LoadRatesFile(cfg.FXRatesFile)
+		if err != nil {
+			log.Fatalf("Failed to load FX rates: %v", err)
+		}
+		log.Printf("Loaded %d FX rates from %s", loaded, cfg.FXRatesFile)
+	}
+
+	handler := api.NewHandler(accountService, transactionService, idempotencyService, fxService, holdService, limitService, feeService, interestService, scheduledService, statementService)
 
 	router := handler.CreateRouter()
 
@@ -3,6 +3,7 @@ package main
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"log"
 	"os"
 	"os/signal"
@@ -14,6 +15,7 @@ import (
 	"banking-ledger/internal/queue"
 	"banking-ledger/internal/repository/mongodb"
 	"banking-ledger/internal/repository/postgres"
+	"banking-ledger/internal/service"
 )
 
 func main() {
@@ -38,8 +40,8 @@ func main() {
 	defer mongoDB.Disconnect()
 
 	// Create repositories
-	accountRepo := postgres.NewAccountRepository(postgresDB)
 	transactionRepo := mongodb.NewTransactionRepository(mongoDB)
+	unitOfWork := postgres.NewUnitOfWork(postgresDB)
 
 	// Create consumer
 	consumer, err := queue.NewRabbitMQConsumer(cfg.RabbitMQURL, cfg.TransactionQueue)
@@ -52,8 +54,25 @@ func main() {
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
+	// Optionally reconcile balances against the transaction log in the background
+	if cfg.ReconcileInterval > 0 {
+		reconciliationService := service.NewReconciliationService(
+			postgres.NewAccountRepository(postgresDB),
+			transactionRepo,
+			postgres.NewInboxRepository(postgresDB),
+			postgres.NewFeeRepository(postgresDB),
+			postgres.NewUnitOfWork(postgresDB),
+		)
+		go reconciliationService.Run(ctx, cfg.ReconcileInterval, service.DefaultStuckPendingAge)
+	}
+
 	// Process transactions
-	processor := processor.NewTransactionProcessor(accountRepo, transactionRepo)
+	instanceID := cfg.InstanceID
+	if instanceID == "" {
+		hostname, _ := os.Hostname()
+		instanceID = fmt.Sprintf("%s-%d", hostname, os.Getpid())
+	}
+	processor := processor.NewTransactionProcessor(transactionRepo, unitOfWork, instanceID)
 	err = consumer.Consume(ctx, func(data []byte) error {
 		var msg models.TransactionMessage
 		if err := json.Unmarshal(data, &msg); err != nil {
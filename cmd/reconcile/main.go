@@ -0,0 +1,116 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"io"
+	"log"
+	"os"
+	"strconv"
+
+	"banking-ledger/internal/config"
+	"banking-ledger/internal/domain"
+	"banking-ledger/internal/repository/mongodb"
+	"banking-ledger/internal/repository/postgres"
+	"banking-ledger/internal/service"
+)
+
+// Checks every account's balance and every transaction's status against the
+// transaction log and writes the discrepancies found. Exits with status 2 if
+// any remain unrepaired.
+func main() {
+	format := flag.String("format", "json", "json or csv")
+	out := flag.String("out", "", "file to write the report to, defaults to stdout")
+	repair := flag.Bool("repair", false, "correct transaction statuses that disagree with the processor's decision")
+	pendingAge := flag.Duration("pending-age", service.DefaultStuckPendingAge, "report pending transactions older than this as stuck, and compare balances as of this long ago")
+	flag.Parse()
+
+	if *format != "json" && *format != "csv" {
+		log.Fatalf("Invalid format %q: expected json or csv", *format)
+	}
+
+	// Load configuration
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatalf("Failed to load configuration: %v", err)
+	}
+
+	// Connect to PostgreSQL
+	postgresDB, err := postgres.NewConnection(cfg.PostgresURL)
+	if err != nil {
+		log.Fatalf("Failed to connect to PostgreSQL: %v", err)
+	}
+	defer postgres.Close(postgresDB)
+
+	// Connect to MongoDB
+	mongoDB, err := mongodb.NewConnection(cfg.MongoURL, cfg.MongoDB)
+	if err != nil {
+		log.Fatalf("Failed to connect to MongoDB: %v", err)
+	}
+	defer mongoDB.Disconnect()
+
+	reconciliationService := service.NewReconciliationService(
+		postgres.NewAccountRepository(postgresDB),
+		mongodb.NewTransactionRepository(mongoDB),
+		postgres.NewInboxRepository(postgresDB),
+		postgres.NewFeeRepository(postgresDB),
+		postgres.NewUnitOfWork(postgresDB),
+	)
+
+	report, err := reconciliationService.Reconcile(*pendingAge, *repair)
+	if err != nil {
+		log.Fatalf("Failed to reconcile: %v", err)
+	}
+
+	var w io.Writer = os.Stdout
+	if *out != "" {
+		file, err := os.Create(*out)
+		if err != nil {
+			log.Fatalf("Failed to create %s: %v", *out, err)
+		}
+		defer file.Close()
+		w = file
+	}
+	if *format == "csv" {
+		err = writeCSV(w, report)
+	} else {
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		err = encoder.Encode(report)
+	}
+	if err != nil {
+		log.Fatalf("Failed to write report: %v", err)
+	}
+
+	unrepaired := 0
+	for _, d := range report.Discrepancies {
+		if !d.Repaired {
+			unrepaired++
+		}
+	}
+	log.Printf("Reconciled %d accounts and %d transactions: %d discrepancies, %d unrepaired",
+		report.AccountsChecked, report.TransactionsChecked, len(report.Discrepancies), unrepaired)
+	if unrepaired > 0 {
+		os.Exit(2)
+	}
+}
+
+// One row per discrepancy, under a header row
+func writeCSV(w io.Writer, report *domain.ReconciliationReport) error {
+	writer := csv.NewWriter(w)
+	writer.Write([]string{"kind", "account_id", "transaction_id", "expected", "actual", "detail", "repaired"})
+	for _, d := range report.Discrepancies {
+		writer.Write([]string{
+			string(d.Kind),
+			d.AccountID,
+			d.TransactionID,
+			d.Expected,
+			d.Actual,
+			d.Detail,
+			strconv.FormatBool(d.Repaired),
+		})
+	}
+	writer.Flush()
+	return writer.Error()
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"io"
+	"log"
+	"os"
+
+	"banking-ledger/internal/config"
+	"banking-ledger/internal/repository/postgres"
+	"banking-ledger/internal/service"
+)
+
+// Rebuilds every account from its event history and compares it with the
+// accounts table, writing a JSON report of the accounts that disagree. Exits
+// with status 2 if any are left unrebuilt.
+func main() {
+	fromScratch := flag.Bool("from-scratch", false, "fold every event instead of starting from the latest snapshot, and check the snapshot too")
+	rebuild := flag.Bool("rebuild", false, "overwrite account rows that disagree with their events")
+	snapshotEvery := flag.Int("snapshot-every", service.DefaultSnapshotEvery, "save a snapshot once this many events follow the latest one; 0 saves none")
+	out := flag.String("out", "", "file to write the report to, defaults to stdout")
+	flag.Parse()
+
+	if *snapshotEvery < 0 {
+		log.Fatalf("Invalid -snapshot-every %d: must not be negative", *snapshotEvery)
+	}
+
+	// Load configuration
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatalf("Failed to load configuration: %v", err)
+	}
+
+	// Connect to PostgreSQL
+	postgresDB, err := postgres.NewConnection(cfg.PostgresURL)
+	if err != nil {
+		log.Fatalf("Failed to connect to PostgreSQL: %v", err)
+	}
+	defer postgres.Close(postgresDB)
+
+	replayService := service.NewAccountReplayService(
+		postgres.NewAccountRepository(postgresDB),
+		postgres.NewAccountEventStore(postgresDB),
+		postgres.NewUnitOfWork(postgresDB),
+	)
+
+	report, err := replayService.Replay(*fromScratch, *rebuild, *snapshotEvery)
+	if err != nil {
+		log.Fatalf("Failed to replay account events: %v", err)
+	}
+
+	var w io.Writer = os.Stdout
+	if *out != "" {
+		file, err := os.Create(*out)
+		if err != nil {
+			log.Fatalf("Failed to create %s: %v", *out, err)
+		}
+		defer file.Close()
+		w = file
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(report); err != nil {
+		log.Fatalf("Failed to write report: %v", err)
+	}
+
+	unrebuilt := 0
+	for _, account := range report.Mismatched {
+		if !account.Rebuilt {
+			unrebuilt++
+		}
+	}
+	log.Printf("Replayed %d events for %d accounts: %d mismatched, %d not rebuilt, %d snapshots taken",
+		report.EventsReplayed, report.AccountsReplayed, len(report.Mismatched), unrebuilt, report.SnapshotsTaken)
+	if unrebuilt > 0 {
+		os.Exit(2)
+	}
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"banking-ledger/internal/config"
+	"banking-ledger/internal/repository/mongodb"
+	"banking-ledger/internal/repository/postgres"
+	"banking-ledger/internal/service"
+)
+
+// Without flags the scheduler accrues the previous day's interest every
+// INTEREST_RUN_INTERVAL, queues due scheduled transactions every
+// SCHEDULED_TRANSACTION_INTERVAL and sweeps stuck pending transactions every
+// PENDING_SWEEP_INTERVAL. -date runs one day's interest and exits; -from and
+// -to run every day in the range, inclusive. Any day can safely be run again.
+// -sweep runs the pending sweep once and prints its report.
+func main() {
+	date := flag.String("date", "", "run a single day (YYYY-MM-DD) and exit")
+	from := flag.String("from", "", "first day of a range to run (YYYY-MM-DD)")
+	to := flag.String("to", "", "last day of a range to run (YYYY-MM-DD), defaults to yesterday")
+	sweep := flag.Bool("sweep", false, "sweep pending transactions once, print the report as JSON and exit")
+	flag.Parse()
+
+	// Load configuration
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatalf("Failed to load configuration: %v", err)
+	}
+
+	// Connect to PostgreSQL
+	postgresDB, err := postgres.NewConnection(cfg.PostgresURL)
+	if err != nil {
+		log.Fatalf("Failed to connect to PostgreSQL: %v", err)
+	}
+	defer postgres.Close(postgresDB)
+
+	// Connect to MongoDB
+	mongoDB, err := mongodb.NewConnection(cfg.MongoURL, cfg.MongoDB)
+	if err != nil {
+		log.Fatalf("Failed to connect to MongoDB: %v", err)
+	}
+	defer mongoDB.Disconnect()
+
+	accountRepo := postgres.NewAccountRepository(postgresDB)
+	transactionRepo := mongodb.NewTransactionRepository(mongoDB)
+
+	// Transactions are stored with their queue message; the API's outbox
+	// relay publishes them to the processor
+	interestService := service.NewInterestService(
+		postgres.NewInterestRepository(postgresDB),
+		accountRepo,
+		postgres.NewJournalRepository(postgresDB),
+		transactionRepo,
+	)
+	transactionService := service.NewTransactionService(
+		transactionRepo,
+		accountRepo,
+		postgres.NewFXRateRepository(postgresDB),
+		postgres.NewHoldRepository(postgresDB),
+		postgres.NewLimitRepository(postgresDB),
+	)
+	scheduledService := service.NewScheduledTransactionService(
+		postgres.NewScheduledTransactionRepository(postgresDB),
+		accountRepo,
+		transactionRepo,
+		transactionService,
+	)
+
+	sweepService := service.NewPendingSweepService(
+		transactionRepo,
+		mongodb.NewOutboxRepository(mongoDB),
+		postgres.NewInboxRepository(postgresDB),
+		postgres.NewFeeRepository(postgresDB),
+		postgres.NewUnitOfWork(postgresDB),
+	)
+
+	if *sweep {
+		report, err := sweepService.Sweep(cfg.PendingSweepAge, cfg.PendingSweepMaxRequeues)
+		if err != nil {
+			log.Fatalf("Failed to sweep pending transactions: %v", err)
+		}
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(report); err != nil {
+			log.Fatalf("Failed to write report: %v", err)
+		}
+		return
+	}
+
+	if *date != "" {
+		*from, *to = *date, *date
+	}
+	if *from != "" {
+		first := parseDay(*from)
+		last := time.Now().AddDate(0, 0, -1)
+		if *to != "" {
+			last = parseDay(*to)
+		}
+		for day := first; !day.After(last); day = day.AddDate(0, 0, 1) {
+			if err := interestService.RunForDate(day); err != nil {
+				log.Fatalf("Failed to run interest for %s: %v", day.Format("2006-01-02"), err)
+			}
+		}
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go interestService.Run(ctx, cfg.InterestRunInterval)
+	go scheduledService.Run(ctx, cfg.ScheduledTransactionInterval)
+	go sweepService.Run(ctx, cfg.PendingSweepInterval, cfg.PendingSweepAge, cfg.PendingSweepMaxRequeues)
+
+	// Graceful shutdown
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+	<-quit
+
+	log.Println("Shutting down scheduler...")
+	cancel()
+}
+
+func parseDay(value string) time.Time {
+	day, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		log.Fatalf("Invalid date %q: expected YYYY-MM-DD", value)
+	}
+	return day
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"banking-ledger/internal/config"
+	"banking-ledger/internal/domain"
+	"banking-ledger/internal/repository/mongodb"
+	"banking-ledger/internal/repository/postgres"
+	"banking-ledger/internal/service"
+	"banking-ledger/internal/statement"
+)
+
+// Writes a statement for every account for one month, as
+// <out>/<month>/<account id>.html and/or .json
+func main() {
+	lastMonth := time.Now().UTC().AddDate(0, -1, 0).Format("2006-01")
+	month := flag.String("month", lastMonth, "month to cover (YYYY-MM)")
+	out := flag.String("out", "statements", "directory to write statements to")
+	format := flag.String("format", "html", "html, json or both")
+	flag.Parse()
+
+	from, err := time.Parse("2006-01", *month)
+	if err != nil {
+		log.Fatalf("Invalid month %q: expected YYYY-MM", *month)
+	}
+	to := from.AddDate(0, 1, 0)
+	if *format != "html" && *format != "json" && *format != "both" {
+		log.Fatalf("Invalid format %q: expected html, json or both", *format)
+	}
+
+	// Load configuration
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatalf("Failed to load configuration: %v", err)
+	}
+
+	// Connect to PostgreSQL
+	postgresDB, err := postgres.NewConnection(cfg.PostgresURL)
+	if err != nil {
+		log.Fatalf("Failed to connect to PostgreSQL: %v", err)
+	}
+	defer postgres.Close(postgresDB)
+
+	// Connect to MongoDB
+	mongoDB, err := mongodb.NewConnection(cfg.MongoURL, cfg.MongoDB)
+	if err != nil {
+		log.Fatalf("Failed to connect to MongoDB: %v", err)
+	}
+	defer mongoDB.Disconnect()
+
+	statementService := service.NewStatementService(
+		postgres.NewAccountRepository(postgresDB),
+		postgres.NewJournalRepository(postgresDB),
+		mongodb.NewTransactionRepository(mongoDB),
+	)
+
+	dir := filepath.Join(*out, *month)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		log.Fatalf("Failed to create %s: %v", dir, err)
+	}
+
+	accountIDs, err := statementService.ListAccountIDs()
+	if err != nil {
+		log.Fatalf("Failed to list accounts: %v", err)
+	}
+
+	failed := 0
+	for _, accountID := range accountIDs {
+		result, err := statementService.Generate(accountID, from, to)
+		if err == nil {
+			err = write(dir, *format, result)
+		}
+		if err != nil {
+			log.Printf("Failed to generate statement for account %s: %v", accountID, err)
+			failed++
+		}
+	}
+
+	log.Printf("Wrote %d statements for %s to %s", len(accountIDs)-failed, *month, dir)
+	if failed > 0 {
+		log.Fatalf("%d statements failed", failed)
+	}
+}
+
+func write(dir, format string, result *domain.Statement) error {
+	if format == "html" || format == "both" {
+		file, err := os.Create(filepath.Join(dir, result.AccountID+".html"))
+		if err != nil {
+			return err
+		}
+		if err := statement.RenderHTML(file, result); err != nil {
+			file.Close()
+			return err
+		}
+		if err := file.Close(); err != nil {
+			return err
+		}
+	}
+	if format == "json" || format == "both" {
+		data, err := json.MarshalIndent(result, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to encode statement: %v", err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, result.AccountID+".json"), data, 0o644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
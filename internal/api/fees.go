@@ -0,0 +1,204 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"banking-ledger/internal/domain"
+	"banking-ledger/internal/models"
+)
+
+// Creates a fee schedule
+func (h *Handler) CreateFeeScheduleHandler(c *gin.Context) {
+	schedule, ok := bindFeeSchedule(c)
+	if !ok {
+		return
+	}
+
+	schedule, err := h.feeService.CreateSchedule(schedule)
+	if err != nil {
+		writeFeeScheduleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"data":    schedule,
+	})
+}
+
+// Lists every fee schedule
+func (h *Handler) ListFeeSchedulesHandler(c *gin.Context) {
+	schedules, err := h.feeService.ListSchedules()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to retrieve fee schedules",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    schedules,
+	})
+}
+
+// Retrieves a fee schedule by ID
+func (h *Handler) GetFeeScheduleHandler(c *gin.Context) {
+	schedule, err := h.feeService.GetSchedule(c.Param("id"))
+	if err != nil {
+		writeFeeScheduleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    schedule,
+	})
+}
+
+// Replaces a fee schedule
+func (h *Handler) UpdateFeeScheduleHandler(c *gin.Context) {
+	schedule, ok := bindFeeSchedule(c)
+	if !ok {
+		return
+	}
+
+	schedule, err := h.feeService.UpdateSchedule(c.Param("id"), schedule)
+	if err != nil {
+		writeFeeScheduleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    schedule,
+	})
+}
+
+// Deletes a fee schedule
+func (h *Handler) DeleteFeeScheduleHandler(c *gin.Context) {
+	if err := h.feeService.DeleteSchedule(c.Param("id")); err != nil {
+		writeFeeScheduleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+	})
+}
+
+// Waives an account's fees or charges them again
+func (h *Handler) SetFeeWaiverHandler(c *gin.Context) {
+	var req models.FeeWaiverRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	account, err := h.feeService.SetFeesWaived(c.Param("id"), *req.Waived)
+	if errors.Is(err, domain.ErrAccountNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"error":   "Account not found",
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to update fee waiver",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    account,
+	})
+}
+
+// Decodes a schedule from the request body, writing a 400 when it cannot
+func bindFeeSchedule(c *gin.Context) (*domain.FeeSchedule, bool) {
+	var req models.FeeScheduleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return nil, false
+	}
+
+	schedule, err := feeScheduleFromRequest(req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return nil, false
+	}
+	return schedule, true
+}
+
+func feeScheduleFromRequest(req models.FeeScheduleRequest) (*domain.FeeSchedule, error) {
+	currency, err := req.ScheduleCurrency()
+	if err != nil {
+		return nil, err
+	}
+
+	schedule := &domain.FeeSchedule{
+		Name:            req.Name,
+		TransactionType: req.TransactionType,
+		AccountType:     domain.AccountType(req.AccountType),
+		Currency:        currency,
+		Kind:            domain.FeeKind(req.Kind),
+		Rate:            req.Rate.String(),
+		Enabled:         req.Enabled == nil || *req.Enabled,
+	}
+	if schedule.Flat, err = models.OptionalAmount(req.Flat, currency); err != nil {
+		return nil, err
+	}
+	if schedule.Min, err = models.OptionalAmount(req.Min, currency); err != nil {
+		return nil, err
+	}
+	if schedule.Max, err = models.OptionalAmount(req.Max, currency); err != nil {
+		return nil, err
+	}
+	for _, tierReq := range req.Tiers {
+		tier := domain.FeeTier{Rate: tierReq.Rate.String()}
+		if tier.UpTo, err = models.OptionalAmount(tierReq.UpTo, currency); err != nil {
+			return nil, err
+		}
+		if tier.Flat, err = models.OptionalAmount(tierReq.Flat, currency); err != nil {
+			return nil, err
+		}
+		schedule.Tiers = append(schedule.Tiers, tier)
+	}
+	return schedule, nil
+}
+
+func writeFeeScheduleError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, domain.ErrFeeScheduleNotFound):
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"error":   "Fee schedule not found",
+		})
+	case errors.Is(err, domain.ErrInvalidFeeSchedule):
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to save fee schedule",
+		})
+	}
+}
@@ -1,26 +1,54 @@
 package api
 
 import (
+	"errors"
 	"net/http"
+	"time"
 
 	"github.com/gin-gonic/gin"
 
+	"banking-ledger/internal/domain"
 	"banking-ledger/internal/models"
+	"banking-ledger/internal/money"
 	"banking-ledger/internal/service"
 )
 
 type Handler struct {
 	accountService     *service.AccountService
 	transactionService *service.TransactionService
+	idempotencyService *service.IdempotencyService
+	fxService          *service.FXService
+	holdService        *service.HoldService
+	limitService       *service.LimitService
+	feeService         *service.FeeService
+	interestService    *service.InterestService
+	scheduledService   *service.ScheduledTransactionService
+	statementService   *service.StatementService
 }
 
 func NewHandler(
 	accountService *service.AccountService,
 	transactionService *service.TransactionService,
+	idempotencyService *service.IdempotencyService,
+	fxService *service.FXService,
+	holdService *service.HoldService,
+	limitService *service.LimitService,
+	feeService *service.FeeService,
+	interestService *service.InterestService,
+	scheduledService *service.ScheduledTransactionService,
+	statementService *service.StatementService,
 ) *Handler {
 	return &Handler{
 		accountService:     accountService,
 		transactionService: transactionService,
+		idempotencyService: idempotencyService,
+		fxService:          fxService,
+		holdService:        holdService,
+		limitService:       limitService,
+		feeService:         feeService,
+		interestService:    interestService,
+		scheduledService:   scheduledService,
+		statementService:   statementService,
 	}
 }
 
@@ -35,7 +63,25 @@ func (h *Handler) CreateAccountHandler(c *gin.Context) {
 		return
 	}
 
-	account, err := h.accountService.CreateAccount(req.Name, req.InitialAmount)
+	initialBalance, err := req.InitialBalance()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	overdraftLimit, err := req.Limit(initialBalance.Currency)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	account, err := h.accountService.CreateAccount(req.Name, domain.AccountType(req.Type), initialBalance, overdraftLimit)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
 			"success": false,
@@ -54,6 +100,19 @@ func (h *Handler) CreateAccountHandler(c *gin.Context) {
 func (h *Handler) GetAccountHandler(c *gin.Context) {
 	accountID := c.Param("id")
 
+	asOf, err := parseTimeParam(c, "as_of")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+	if asOf != nil {
+		h.getBalanceAsOf(c, accountID, *asOf)
+		return
+	}
+
 	account, err := h.accountService.GetAccount(accountID)
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{
@@ -69,9 +128,236 @@ func (h *Handler) GetAccountHandler(c *gin.Context) {
 	})
 }
 
-// Lists all accounts
+// Answers an as_of query with the ledger balance at that moment
+func (h *Handler) getBalanceAsOf(c *gin.Context, accountID string, asOf time.Time) {
+	balance, err := h.accountService.GetBalanceAsOf(accountID, asOf)
+	if errors.Is(err, domain.ErrAccountNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"error":   "Account not found",
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to retrieve balance",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    balance,
+	})
+}
+
+// Lists the ledger balance after each change, newest first
+func (h *Handler) GetBalanceHistoryHandler(c *gin.Context) {
+	filter, err := parseBalanceHistoryFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	page, err := h.accountService.ListBalanceHistory(c.Param("id"), filter)
+	switch {
+	case errors.Is(err, domain.ErrAccountNotFound):
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"error":   "Account not found",
+		})
+		return
+	case errors.Is(err, domain.ErrInvalidCursor):
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to retrieve balance history",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    page,
+	})
+}
+
+// Looks up an account, writing a 404 or 500 response when that fails
+func (h *Handler) findAccount(c *gin.Context, accountID string) (*domain.Account, bool) {
+	account, err := h.accountService.GetAccount(accountID)
+	if errors.Is(err, domain.ErrAccountNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"error":   "Account not found",
+		})
+		return nil, false
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to retrieve account",
+		})
+		return nil, false
+	}
+	return account, true
+}
+
+// Freezes, blocks, reactivates or closes an account
+func (h *Handler) ChangeAccountStatusHandler(c *gin.Context) {
+	var req models.ChangeAccountStatusRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	account, err := h.accountService.ChangeStatus(c.Param("id"), domain.AccountStatus(req.Status), req.Reason, req.Actor)
+	switch {
+	case errors.Is(err, domain.ErrAccountNotFound):
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"error":   "Account not found",
+		})
+		return
+	case errors.Is(err, domain.ErrInvalidStatusTransition), errors.Is(err, domain.ErrAccountNotEmpty),
+		errors.Is(err, domain.ErrAccountHasHolds):
+		c.JSON(http.StatusConflict, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	case err != nil:
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    account,
+	})
+}
+
+// Changes an account's overdraft or credit limit
+func (h *Handler) ChangeOverdraftLimitHandler(c *gin.Context) {
+	var req models.ChangeOverdraftLimitRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	account, ok := h.findAccount(c, c.Param("id"))
+	if !ok {
+		return
+	}
+
+	limit, err := money.Parse(req.Limit.String(), account.Currency)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	account, err = h.accountService.ChangeOverdraftLimit(account.ID, limit, req.Reason, req.Actor)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    account,
+	})
+}
+
+// Lists an account's overdraft limit changes
+func (h *Handler) GetOverdraftLimitHistoryHandler(c *gin.Context) {
+	changes, err := h.accountService.GetOverdraftLimitHistory(c.Param("id"))
+	if errors.Is(err, domain.ErrAccountNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"error":   "Account not found",
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to retrieve overdraft limit history",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    changes,
+	})
+}
+
+// Lists an account's status changes
+func (h *Handler) GetAccountStatusHistoryHandler(c *gin.Context) {
+	changes, err := h.accountService.GetStatusHistory(c.Param("id"))
+	if errors.Is(err, domain.ErrAccountNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"error":   "Account not found",
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to retrieve status history",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    changes,
+	})
+}
+
+// Lists accounts with filters, sorting and cursor pagination
 func (h *Handler) ListAccountsHandler(c *gin.Context) {
-	accounts, err := h.accountService.ListAccounts()
+	filter, err := parseAccountFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	page, err := h.accountService.ListAccounts(filter)
+	if errors.Is(err, domain.ErrInvalidCursor) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"success": false,
@@ -82,7 +368,7 @@ func (h *Handler) ListAccountsHandler(c *gin.Context) {
 
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
-		"data":    accounts,
+		"data":    page,
 	})
 }
 
@@ -99,7 +385,12 @@ func (h *Handler) DepositHandler(c *gin.Context) {
 		return
 	}
 
-	transaction, err := h.transactionService.CreateDeposit(accountID, req.Amount, req.Description)
+	account, ok := h.findAccount(c, accountID)
+	if !ok {
+		return
+	}
+
+	amount, err := req.Money(account.Currency)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
 			"success": false,
@@ -108,6 +399,12 @@ func (h *Handler) DepositHandler(c *gin.Context) {
 		return
 	}
 
+	transaction, err := h.transactionService.CreateDeposit(accountID, amount, req.Description)
+	if err != nil {
+		writeTransactionError(c, err)
+		return
+	}
+
 	c.JSON(http.StatusAccepted, gin.H{
 		"success": true,
 		"data":    transaction,
@@ -127,7 +424,12 @@ func (h *Handler) WithdrawHandler(c *gin.Context) {
 		return
 	}
 
-	transaction, err := h.transactionService.CreateWithdrawal(accountID, req.Amount, req.Description)
+	account, ok := h.findAccount(c, accountID)
+	if !ok {
+		return
+	}
+
+	amount, err := req.Money(account.Currency)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
 			"success": false,
@@ -136,8 +438,220 @@ func (h *Handler) WithdrawHandler(c *gin.Context) {
 		return
 	}
 
+	transaction, err := h.transactionService.CreateWithdrawal(accountID, amount, req.Description)
+	if err != nil {
+		writeTransactionError(c, err)
+		return
+	}
+
 	c.JSON(http.StatusAccepted, gin.H{
 		"success": true,
 		"data":    transaction,
 	})
 }
+
+// Transfers between two accounts
+func (h *Handler) TransferHandler(c *gin.Context) {
+	var req models.TransferRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	from, ok := h.findAccount(c, req.FromAccountID)
+	if !ok {
+		return
+	}
+
+	amount, err := req.Money(from.Currency)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	transaction, err := h.transactionService.CreateTransfer(req.FromAccountID, req.ToAccountID, amount, req.Description)
+	if err != nil {
+		writeTransactionError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"success": true,
+		"data":    transaction,
+	})
+}
+
+// Compares an account's cached balance with the one derived from its postings
+func (h *Handler) VerifyBalanceHandler(c *gin.Context) {
+	accountID := c.Param("id")
+
+	account, err := h.accountService.GetAccount(accountID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"error":   "Account not found",
+		})
+		return
+	}
+
+	posted, err := h.accountService.GetPostedBalance(accountID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to compute posted balance",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": gin.H{
+			"account_id":     account.ID,
+			"cached_balance": account.Balance,
+			"posted_balance": posted,
+			"balances_match": posted == account.Balance,
+		},
+	})
+}
+
+// Reports debit and credit totals per currency across the journal
+func (h *Handler) TrialBalanceHandler(c *gin.Context) {
+	lines, err := h.accountService.TrialBalance()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to compute trial balance",
+		})
+		return
+	}
+
+	balanced := true
+	for _, line := range lines {
+		balanced = balanced && line.Balanced
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": gin.H{
+			"balanced":   balanced,
+			"currencies": lines,
+		},
+	})
+}
+
+// Lists the exchange rates used for cross-currency transfers
+func (h *Handler) ListFXRatesHandler(c *gin.Context) {
+	rates, err := h.fxService.ListRates()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to retrieve exchange rates",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    rates,
+	})
+}
+
+// Sets the exchange rate for a currency pair
+func (h *Handler) SetFXRateHandler(c *gin.Context) {
+	var req models.SetFXRateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	rate, err := h.fxService.SetRate(req.From, req.To, req.Rate.String())
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    rate,
+	})
+}
+
+// Retrieves a transaction by ID
+func (h *Handler) GetTransactionHandler(c *gin.Context) {
+	transaction, err := h.transactionService.GetTransaction(c.Param("id"))
+	if errors.Is(err, domain.ErrTransactionNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"error":   "Transaction not found",
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to retrieve transaction",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    transaction,
+	})
+}
+
+// Lists an account's transactions, newest first, with filters and cursor pagination
+func (h *Handler) ListAccountTransactionsHandler(c *gin.Context) {
+	account, ok := h.findAccount(c, c.Param("id"))
+	if !ok {
+		return
+	}
+
+	filter, err := parseTransactionFilter(c, account.Currency)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	page, err := h.transactionService.ListTransactionsByAccount(account.ID, filter)
+	switch {
+	case errors.Is(err, domain.ErrAccountNotFound):
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"error":   "Account not found",
+		})
+		return
+	case errors.Is(err, domain.ErrInvalidCursor):
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to retrieve transactions",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    page,
+	})
+}
@@ -0,0 +1,195 @@
+package api
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"banking-ledger/internal/domain"
+	"banking-ledger/internal/models"
+	"banking-ledger/internal/money"
+	"banking-ledger/internal/service"
+)
+
+// Places an authorization hold on an account
+func (h *Handler) CreateHoldHandler(c *gin.Context) {
+	var req models.CreateHoldRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	account, ok := h.findAccount(c, c.Param("id"))
+	if !ok {
+		return
+	}
+
+	amount, err := req.Money(account.Currency)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	expiresAt := time.Now().Add(service.DefaultHoldTTL)
+	if req.ExpiresAt != nil {
+		expiresAt = *req.ExpiresAt
+	}
+
+	hold, err := h.holdService.CreateHold(account.ID, amount, req.Description, expiresAt)
+	if err != nil {
+		writeTransactionError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"data":    hold,
+	})
+}
+
+// Lists an account's holds
+func (h *Handler) ListHoldsHandler(c *gin.Context) {
+	holds, err := h.holdService.ListHolds(c.Param("id"))
+	if errors.Is(err, domain.ErrAccountNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"error":   "Account not found",
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to retrieve holds",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    holds,
+	})
+}
+
+// Retrieves a hold by ID
+func (h *Handler) GetHoldHandler(c *gin.Context) {
+	hold, err := h.holdService.GetHold(c.Param("id"))
+	if errors.Is(err, domain.ErrHoldNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"error":   "Hold not found",
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to retrieve hold",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    hold,
+	})
+}
+
+// Captures all or part of a hold; the capture is applied by the processor
+func (h *Handler) CaptureHoldHandler(c *gin.Context) {
+	// The body is optional: an empty one captures the whole hold
+	var req models.CaptureHoldRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	hold, err := h.holdService.GetHold(c.Param("id"))
+	if errors.Is(err, domain.ErrHoldNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"error":   "Hold not found",
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to retrieve hold",
+		})
+		return
+	}
+
+	var amount *money.Money
+	if req.Amount != "" {
+		parsed, err := money.Parse(req.Amount.String(), hold.Amount.Currency)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"error":   err.Error(),
+			})
+			return
+		}
+		amount = &parsed
+	}
+
+	transaction, err := h.transactionService.CreateCapture(hold.ID, amount, req.Description)
+	if errors.Is(err, domain.ErrHoldNotActive) {
+		c.JSON(http.StatusConflict, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+	if err != nil {
+		writeTransactionError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"success": true,
+		"data":    transaction,
+	})
+}
+
+// Voids an active hold, releasing its funds
+func (h *Handler) VoidHoldHandler(c *gin.Context) {
+	hold, err := h.holdService.VoidHold(c.Param("id"))
+	switch {
+	case errors.Is(err, domain.ErrHoldNotFound):
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"error":   "Hold not found",
+		})
+		return
+	case errors.Is(err, domain.ErrHoldNotActive):
+		c.JSON(http.StatusConflict, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to void hold",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    hold,
+	})
+}
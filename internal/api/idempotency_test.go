@@ -0,0 +1,223 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"banking-ledger/internal/domain"
+	"banking-ledger/internal/service"
+)
+
+type memoryIdempotencyRepo struct {
+	mu      sync.Mutex
+	records map[string]*domain.IdempotencyRecord
+}
+
+func (r *memoryIdempotencyRepo) Reserve(record *domain.IdempotencyRecord) (*domain.IdempotencyRecord, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if existing, ok := r.records[record.Key]; ok {
+		copied := *existing
+		return &copied, nil
+	}
+	stored := *record
+	r.records[record.Key] = &stored
+	return nil, nil
+}
+
+func (r *memoryIdempotencyRepo) Complete(key string, statusCode int, body []byte) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := time.Now()
+	r.records[key].StatusCode = statusCode
+	r.records[key].ResponseBody = append([]byte(nil), body...)
+	r.records[key].CompletedAt = &now
+	return nil
+}
+
+func (r *memoryIdempotencyRepo) Release(key string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.records, key)
+	return nil
+}
+
+func (r *memoryIdempotencyRepo) TakeOver(record *domain.IdempotencyRecord, claimedAt time.Time) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	existing, ok := r.records[record.Key]
+	if !ok || existing.CompletedAt != nil || !existing.CreatedAt.Equal(claimedAt) {
+		return false, nil
+	}
+	existing.CreatedAt = record.CreatedAt
+	return true, nil
+}
+
+func (r *memoryIdempotencyRepo) DeleteOlderThan(cutoff time.Time) error {
+	return nil
+}
+
+func TestIdempotent(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	h := &Handler{idempotencyService: service.NewIdempotencyService(&memoryIdempotencyRepo{
+		records: make(map[string]*domain.IdempotencyRecord),
+	})}
+
+	calls := 0
+	router := gin.New()
+	router.POST("/accounts/:id/deposit", h.Idempotent(), func(c *gin.Context) {
+		calls++
+		c.JSON(http.StatusAccepted, gin.H{"success": true, "call": calls})
+	})
+
+	send := func(key, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/accounts/a1/deposit", strings.NewReader(body))
+		req.Header.Set(idempotencyKeyHeader, key)
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	first := send("key-1", `{"amount": 10, "description": "x"}`)
+	if first.Code != http.StatusAccepted {
+		t.Fatalf("first request status = %d", first.Code)
+	}
+
+	replay := send("key-1", `{"description":"x","amount":10}`)
+	if replay.Code != http.StatusAccepted || replay.Body.String() != first.Body.String() {
+		t.Errorf("replay = %d %s, want %d %s", replay.Code, replay.Body, first.Code, first.Body)
+	}
+	if replay.Header().Get(idempotentReplayedHeader) != "true" {
+		t.Errorf("replay is missing the %s header", idempotentReplayedHeader)
+	}
+	if calls != 1 {
+		t.Errorf("handler ran %d times, want 1", calls)
+	}
+
+	conflict := send("key-1", `{"amount": 11}`)
+	if conflict.Code != http.StatusUnprocessableEntity {
+		t.Errorf("conflicting payload status = %d, want %d", conflict.Code, http.StatusUnprocessableEntity)
+	}
+
+	send("key-2", `{"amount": 10}`)
+	if calls != 2 {
+		t.Errorf("handler ran %d times, want 2", calls)
+	}
+}
+
+func TestIdempotentRetriesServerErrorsAndStaleClaims(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	repo := &memoryIdempotencyRepo{records: make(map[string]*domain.IdempotencyRecord)}
+	h := &Handler{idempotencyService: service.NewIdempotencyService(repo)}
+
+	var failWith error
+	calls := 0
+	router := gin.New()
+	router.POST("/transfers", h.Idempotent(), func(c *gin.Context) {
+		calls++
+		if failWith != nil {
+			writeTransactionError(c, failWith)
+			return
+		}
+		c.JSON(http.StatusAccepted, gin.H{"success": true})
+	})
+
+	send := func(key string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/transfers", strings.NewReader(`{"amount": 10}`))
+		req.Header.Set(idempotencyKeyHeader, key)
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	// An outage is not the final answer for the key
+	failWith = errors.New("connection refused")
+	if rec := send("outage"); rec.Code != http.StatusInternalServerError {
+		t.Fatalf("outage status = %d, want %d", rec.Code, http.StatusInternalServerError)
+	}
+	failWith = nil
+	if rec := send("outage"); rec.Code != http.StatusAccepted || calls != 2 {
+		t.Errorf("retry after outage = %d after %d calls, want %d after 2", rec.Code, calls, http.StatusAccepted)
+	}
+
+	// A rejection is
+	failWith = fmt.Errorf("%w: transfer amount must be positive", domain.ErrInvalidTransaction)
+	if rec := send("rejected"); rec.Code != http.StatusBadRequest {
+		t.Fatalf("rejection status = %d, want %d", rec.Code, http.StatusBadRequest)
+	}
+	failWith = nil
+	if rec := send("rejected"); rec.Code != http.StatusBadRequest || calls != 3 {
+		t.Errorf("retry after rejection = %d after %d calls, want a replayed %d", rec.Code, calls, http.StatusBadRequest)
+	}
+
+	// A claim left behind by a request that crashed is taken over once its
+	// lease runs out, but not before
+	claim := func(key string, age time.Duration) {
+		fingerprintReq := httptest.NewRequest(http.MethodPost, "/transfers", nil)
+		repo.records[key] = &domain.IdempotencyRecord{
+			Key:         key,
+			Fingerprint: fingerprint(fingerprintReq, []byte(`{"amount": 10}`)),
+			CreatedAt:   time.Now().Add(-age),
+		}
+	}
+	claim("running", time.Second)
+	if rec := send("running"); rec.Code != http.StatusConflict {
+		t.Errorf("request with a live claim = %d, want %d", rec.Code, http.StatusConflict)
+	}
+	claim("crashed", time.Hour)
+	if rec := send("crashed"); rec.Code != http.StatusAccepted || calls != 4 {
+		t.Errorf("request with a stale claim = %d after %d calls, want %d after 4", rec.Code, calls, http.StatusAccepted)
+	}
+}
+
+func TestRouterReplaysIdempotentRoutes(t *testing.T) {
+	repo := &memoryIdempotencyRepo{records: make(map[string]*domain.IdempotencyRecord)}
+	router := (&Handler{idempotencyService: service.NewIdempotencyService(repo)}).CreateRouter()
+
+	routes := []string{
+		"/accounts",
+		"/accounts/a1/deposit",
+		"/accounts/a1/withdraw",
+		"/transfers",
+		"/transactions/t1/reverse",
+		"/transactions/t1/refund",
+		"/accounts/a1/holds",
+		"/holds/h1/capture",
+		"/holds/h1/void",
+		"/accounts/a1/scheduled-transactions",
+	}
+	for _, path := range routes {
+		t.Run(path, func(t *testing.T) {
+			// A completed request with this key is replayed without reaching
+			// the handler, which has no services to run against here
+			body := `{"amount": 10}`
+			now := time.Now()
+			repo.records[path] = &domain.IdempotencyRecord{
+				Key:          path,
+				Fingerprint:  fingerprint(httptest.NewRequest(http.MethodPost, path, nil), []byte(body)),
+				StatusCode:   http.StatusCreated,
+				ResponseBody: []byte(`{"success":true}`),
+				CreatedAt:    now,
+				CompletedAt:  &now,
+			}
+
+			req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+			req.Header.Set(idempotencyKeyHeader, path)
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+
+			if rec.Code != http.StatusCreated || rec.Header().Get(idempotentReplayedHeader) != "true" {
+				t.Errorf("POST %s = %d, replayed %q; want the stored %d replayed", path,
+					rec.Code, rec.Header().Get(idempotentReplayedHeader), http.StatusCreated)
+			}
+		})
+	}
+}
@@ -0,0 +1,207 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"banking-ledger/internal/domain"
+	"banking-ledger/internal/models"
+)
+
+// Creates an interest plan
+func (h *Handler) CreateInterestPlanHandler(c *gin.Context) {
+	plan, ok := bindInterestPlan(c)
+	if !ok {
+		return
+	}
+
+	plan, err := h.interestService.CreatePlan(plan)
+	if err != nil {
+		writeInterestPlanError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"data":    plan,
+	})
+}
+
+// Lists every interest plan
+func (h *Handler) ListInterestPlansHandler(c *gin.Context) {
+	plans, err := h.interestService.ListPlans()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to retrieve interest plans",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    plans,
+	})
+}
+
+// Retrieves an interest plan by ID
+func (h *Handler) GetInterestPlanHandler(c *gin.Context) {
+	plan, err := h.interestService.GetPlan(c.Param("id"))
+	if err != nil {
+		writeInterestPlanError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    plan,
+	})
+}
+
+// Replaces an interest plan
+func (h *Handler) UpdateInterestPlanHandler(c *gin.Context) {
+	plan, ok := bindInterestPlan(c)
+	if !ok {
+		return
+	}
+
+	plan, err := h.interestService.UpdatePlan(c.Param("id"), plan)
+	if err != nil {
+		writeInterestPlanError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    plan,
+	})
+}
+
+// Puts an account on an interest plan, or takes it off
+func (h *Handler) AssignInterestPlanHandler(c *gin.Context) {
+	var req models.AssignInterestPlanRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	account, err := h.interestService.AssignPlan(c.Param("id"), req.PlanID)
+	if errors.Is(err, domain.ErrAccountNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"error":   "Account not found",
+		})
+		return
+	}
+	if err != nil {
+		writeInterestPlanError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    account,
+	})
+}
+
+// Lists an account's daily interest accruals. Query parameters: from and to
+// (YYYY-MM-DD, to exclusive), defaulting to the current month.
+func (h *Handler) ListInterestAccrualsHandler(c *gin.Context) {
+	today := domain.Day(time.Now())
+	from, err := parseDateParam(c, "from", today.AddDate(0, 0, 1-today.Day()))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+	to, err := parseDateParam(c, "to", from.AddDate(0, 1, 0))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	accruals, err := h.interestService.ListAccruals(c.Param("id"), from, to)
+	if errors.Is(err, domain.ErrAccountNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"error":   "Account not found",
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to retrieve interest accruals",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    accruals,
+	})
+}
+
+func parseDateParam(c *gin.Context, name string, fallback time.Time) (time.Time, error) {
+	value := c.Query(name)
+	if value == "" {
+		return fallback, nil
+	}
+	t, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid %s: expected a YYYY-MM-DD date", name)
+	}
+	return t, nil
+}
+
+// Decodes a plan from the request body, writing a 400 when it cannot
+func bindInterestPlan(c *gin.Context) (*domain.InterestPlan, bool) {
+	var req models.InterestPlanRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return nil, false
+	}
+
+	return &domain.InterestPlan{
+		Name:       req.Name,
+		AnnualRate: req.AnnualRate.String(),
+		Method:     domain.InterestMethod(req.Method),
+		DayCount:   domain.DayCount(req.DayCount),
+		Enabled:    req.Enabled == nil || *req.Enabled,
+	}, true
+}
+
+func writeInterestPlanError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, domain.ErrInterestPlanNotFound):
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"error":   "Interest plan not found",
+		})
+	case errors.Is(err, domain.ErrInvalidInterestPlan):
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to save interest plan",
+		})
+	}
+}
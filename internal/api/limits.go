@@ -0,0 +1,192 @@
+package api
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"banking-ledger/internal/domain"
+	"banking-ledger/internal/models"
+	"banking-ledger/internal/money"
+)
+
+// Creates a limit rule
+func (h *Handler) CreateLimitRuleHandler(c *gin.Context) {
+	rule, ok := h.bindLimitRule(c)
+	if !ok {
+		return
+	}
+
+	rule, err := h.limitService.CreateRule(rule)
+	if err != nil {
+		writeLimitRuleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"data":    rule,
+	})
+}
+
+// Lists every limit rule
+func (h *Handler) ListLimitRulesHandler(c *gin.Context) {
+	rules, err := h.limitService.ListRules()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to retrieve limit rules",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    rules,
+	})
+}
+
+// Retrieves a limit rule by ID
+func (h *Handler) GetLimitRuleHandler(c *gin.Context) {
+	rule, err := h.limitService.GetRule(c.Param("id"))
+	if err != nil {
+		writeLimitRuleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    rule,
+	})
+}
+
+// Replaces a limit rule
+func (h *Handler) UpdateLimitRuleHandler(c *gin.Context) {
+	rule, ok := h.bindLimitRule(c)
+	if !ok {
+		return
+	}
+
+	rule, err := h.limitService.UpdateRule(c.Param("id"), rule)
+	if err != nil {
+		writeLimitRuleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    rule,
+	})
+}
+
+// Deletes a limit rule
+func (h *Handler) DeleteLimitRuleHandler(c *gin.Context) {
+	if err := h.limitService.DeleteRule(c.Param("id")); err != nil {
+		writeLimitRuleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+	})
+}
+
+// Decodes a rule from the request body, writing a 400 when it cannot
+func (h *Handler) bindLimitRule(c *gin.Context) (*domain.LimitRule, bool) {
+	var req models.LimitRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return nil, false
+	}
+
+	currency := money.DefaultCurrency
+	if req.AccountID != "" {
+		account, ok := h.findAccount(c, req.AccountID)
+		if !ok {
+			return nil, false
+		}
+		currency = account.Currency
+	}
+
+	maxAmount, err := req.Limit(currency)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return nil, false
+	}
+
+	enabled := true
+	if req.Enabled != nil {
+		enabled = *req.Enabled
+	}
+
+	return &domain.LimitRule{
+		Name:            req.Name,
+		AccountID:       req.AccountID,
+		AccountType:     domain.AccountType(req.AccountType),
+		TransactionType: req.TransactionType,
+		Kind:            domain.LimitKind(req.Kind),
+		MaxAmount:       maxAmount,
+		MaxCount:        req.MaxCount,
+		WindowSeconds:   req.WindowSeconds,
+		Enabled:         enabled,
+	}, true
+}
+
+func writeLimitRuleError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, domain.ErrLimitRuleNotFound):
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"error":   "Limit rule not found",
+		})
+	case errors.Is(err, domain.ErrAccountNotFound):
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"error":   "Account not found",
+		})
+	case errors.Is(err, domain.ErrInvalidLimitRule), errors.Is(err, money.ErrCurrencyMismatch):
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to save limit rule",
+		})
+	}
+}
+
+// Writes the error for a transaction the service did not accept. Broken
+// limits get 422 with the rule that was hit and other rejections a plain 400.
+// Anything else is a 500, so Idempotent lets the client retry it.
+func writeTransactionError(c *gin.Context, err error) {
+	var exceeded *domain.LimitExceededError
+	switch {
+	case errors.As(err, &exceeded):
+		c.JSON(http.StatusUnprocessableEntity, gin.H{
+			"success": false,
+			"error":   err.Error(),
+			"limit":   exceeded,
+		})
+	case domain.IsRejection(err):
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+	default:
+		log.Printf("Failed to create transaction: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to create transaction",
+		})
+	}
+}
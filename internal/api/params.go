@@ -0,0 +1,190 @@
+package api
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"banking-ledger/internal/constants"
+	"banking-ledger/internal/domain"
+	"banking-ledger/internal/money"
+)
+
+// Reads the transaction listing query parameters:
+// type, status (comma separated), min_amount, max_amount, from, to (RFC 3339),
+// q (description text), cursor and limit. Amounts are read in the account's currency.
+func parseTransactionFilter(c *gin.Context, currency money.Currency) (domain.TransactionFilter, error) {
+	var filter domain.TransactionFilter
+	var err error
+
+	for _, value := range splitList(c.Query("type")) {
+		filter.Types = append(filter.Types, constants.TransactionType(value))
+	}
+	for _, value := range splitList(c.Query("status")) {
+		filter.Statuses = append(filter.Statuses, constants.TransactionStatus(value))
+	}
+	if filter.MinAmount, err = parseAmountParam(c, "min_amount", currency); err != nil {
+		return filter, err
+	}
+	if filter.MaxAmount, err = parseAmountParam(c, "max_amount", currency); err != nil {
+		return filter, err
+	}
+	if filter.CreatedFrom, err = parseTimeParam(c, "from"); err != nil {
+		return filter, err
+	}
+	if filter.CreatedTo, err = parseTimeParam(c, "to"); err != nil {
+		return filter, err
+	}
+	if filter.Limit, err = parseLimitParam(c); err != nil {
+		return filter, err
+	}
+	filter.Description = c.Query("q")
+	filter.Cursor = c.Query("cursor")
+	return filter, nil
+}
+
+// Reads the account listing query parameters:
+// name_prefix, currency, status, type, overdrawn, min_balance, max_balance (in that currency, USD by
+// default), from, to (RFC 3339), sort
+// (created_at, name or balance), order (asc or desc), cursor, limit and
+// include_total
+func parseAccountFilter(c *gin.Context) (domain.AccountFilter, error) {
+	var filter domain.AccountFilter
+	var err error
+
+	filter.NamePrefix = c.Query("name_prefix")
+	if value := c.Query("status"); value != "" {
+		filter.Status = domain.AccountStatus(value)
+		if !filter.Status.Valid() {
+			return filter, fmt.Errorf("invalid status: %q", value)
+		}
+	}
+	if value := c.Query("type"); value != "" {
+		filter.Type = domain.AccountType(value)
+		if !filter.Type.Valid() {
+			return filter, fmt.Errorf("invalid type: %q", value)
+		}
+	}
+	if value := c.Query("overdrawn"); value != "" {
+		if filter.Overdrawn, err = strconv.ParseBool(value); err != nil {
+			return filter, fmt.Errorf("invalid overdrawn: must be true or false")
+		}
+	}
+	balanceCurrency := money.DefaultCurrency
+	if value := c.Query("currency"); value != "" {
+		if filter.Currency, err = money.ParseCurrency(value); err != nil {
+			return filter, err
+		}
+		balanceCurrency = filter.Currency
+	}
+	if filter.MinBalance, err = parseAmountParam(c, "min_balance", balanceCurrency); err != nil {
+		return filter, err
+	}
+	if filter.MaxBalance, err = parseAmountParam(c, "max_balance", balanceCurrency); err != nil {
+		return filter, err
+	}
+	if filter.CreatedFrom, err = parseTimeParam(c, "from"); err != nil {
+		return filter, err
+	}
+	if filter.CreatedTo, err = parseTimeParam(c, "to"); err != nil {
+		return filter, err
+	}
+	if filter.Limit, err = parseLimitParam(c); err != nil {
+		return filter, err
+	}
+
+	filter.SortBy = domain.AccountSortField(c.DefaultQuery("sort", string(domain.AccountSortCreatedAt)))
+	switch filter.SortBy {
+	case domain.AccountSortCreatedAt, domain.AccountSortName, domain.AccountSortBalance:
+	default:
+		return filter, fmt.Errorf("invalid sort: must be created_at, name or balance")
+	}
+
+	// Newest first by default, alphabetical or smallest first otherwise
+	order := c.Query("order")
+	switch order {
+	case "":
+		filter.Descending = filter.SortBy == domain.AccountSortCreatedAt
+	case "asc", "desc":
+		filter.Descending = order == "desc"
+	default:
+		return filter, fmt.Errorf("invalid order: must be asc or desc")
+	}
+
+	if value := c.Query("include_total"); value != "" {
+		if filter.IncludeTotal, err = strconv.ParseBool(value); err != nil {
+			return filter, fmt.Errorf("invalid include_total: must be true or false")
+		}
+	}
+
+	filter.Cursor = c.Query("cursor")
+	return filter, nil
+}
+
+// Reads the balance history query parameters: from, to (RFC 3339, both
+// inclusive), cursor and limit
+func parseBalanceHistoryFilter(c *gin.Context) (domain.BalanceHistoryFilter, error) {
+	var filter domain.BalanceHistoryFilter
+	var err error
+
+	if filter.From, err = parseTimeParam(c, "from"); err != nil {
+		return filter, err
+	}
+	if filter.To, err = parseTimeParam(c, "to"); err != nil {
+		return filter, err
+	}
+	if filter.Limit, err = parseLimitParam(c); err != nil {
+		return filter, err
+	}
+	filter.Cursor = c.Query("cursor")
+	return filter, nil
+}
+
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+func parseAmountParam(c *gin.Context, name string, currency money.Currency) (*money.Money, error) {
+	value := c.Query(name)
+	if value == "" {
+		return nil, nil
+	}
+	amount, err := money.Parse(value, currency)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s: %v", name, err)
+	}
+	return &amount, nil
+}
+
+func parseTimeParam(c *gin.Context, name string) (*time.Time, error) {
+	value := c.Query(name)
+	if value == "" {
+		return nil, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s: expected an RFC 3339 timestamp", name)
+	}
+	return &t, nil
+}
+
+func parseLimitParam(c *gin.Context) (int, error) {
+	value := c.Query("limit")
+	if value == "" {
+		return 0, nil
+	}
+	limit, err := strconv.Atoi(value)
+	if err != nil || limit < 1 {
+		return 0, fmt.Errorf("invalid limit: must be a positive integer")
+	}
+	return limit, nil
+}
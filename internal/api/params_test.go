@@ -0,0 +1,111 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"banking-ledger/internal/domain"
+	"banking-ledger/internal/money"
+)
+
+func TestParseAccountFilter(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name    string
+		query   string
+		wantErr bool
+		check   func(t *testing.T, filter domain.AccountFilter)
+	}{
+		{
+			name:  "defaults to newest first",
+			query: "",
+			check: func(t *testing.T, filter domain.AccountFilter) {
+				if filter.SortBy != domain.AccountSortCreatedAt || !filter.Descending {
+					t.Errorf("sort = %s descending %v, want created_at descending", filter.SortBy, filter.Descending)
+				}
+			},
+		},
+		{
+			name:  "other sorts default to ascending",
+			query: "sort=name",
+			check: func(t *testing.T, filter domain.AccountFilter) {
+				if filter.SortBy != domain.AccountSortName || filter.Descending {
+					t.Errorf("sort = %s descending %v, want name ascending", filter.SortBy, filter.Descending)
+				}
+			},
+		},
+		{
+			name:  "explicit order",
+			query: "sort=balance&order=desc",
+			check: func(t *testing.T, filter domain.AccountFilter) {
+				if filter.SortBy != domain.AccountSortBalance || !filter.Descending {
+					t.Errorf("sort = %s descending %v, want balance descending", filter.SortBy, filter.Descending)
+				}
+			},
+		},
+		{
+			name:  "balances are read in the currency filtered on",
+			query: "currency=EUR&min_balance=10&max_balance=20.5",
+			check: func(t *testing.T, filter domain.AccountFilter) {
+				if filter.Currency != money.EUR {
+					t.Errorf("currency = %s, want EUR", filter.Currency)
+				}
+				if filter.MinBalance == nil || filter.MinBalance.Currency != money.EUR || filter.MinBalance.String() != "10.00" {
+					t.Errorf("min balance = %v, want 10.00 EUR", filter.MinBalance)
+				}
+				if filter.MaxBalance == nil || filter.MaxBalance.Currency != money.EUR || filter.MaxBalance.String() != "20.50" {
+					t.Errorf("max balance = %v, want 20.50 EUR", filter.MaxBalance)
+				}
+			},
+		},
+		{
+			name:  "balances default to USD",
+			query: "min_balance=10",
+			check: func(t *testing.T, filter domain.AccountFilter) {
+				if filter.MinBalance == nil || filter.MinBalance.Currency != money.DefaultCurrency {
+					t.Errorf("min balance = %v, want one in %s", filter.MinBalance, money.DefaultCurrency)
+				}
+			},
+		},
+		{
+			name:  "name prefix is passed through unescaped",
+			query: "name_prefix=50%25_off&overdrawn=true&include_total=1",
+			check: func(t *testing.T, filter domain.AccountFilter) {
+				if filter.NamePrefix != "50%_off" || !filter.Overdrawn || !filter.IncludeTotal {
+					t.Errorf("filter = %+v", filter)
+				}
+			},
+		},
+		{name: "unknown sort", query: "sort=owner", wantErr: true},
+		{name: "unknown order", query: "order=up", wantErr: true},
+		{name: "unknown status", query: "status=open", wantErr: true},
+		{name: "unknown type", query: "type=brokerage", wantErr: true},
+		{name: "unknown currency", query: "currency=XYZ", wantErr: true},
+		{name: "bad balance", query: "min_balance=ten", wantErr: true},
+		{name: "bad overdrawn", query: "overdrawn=maybe", wantErr: true},
+		{name: "bad timestamp", query: "from=2024-03-01", wantErr: true},
+		{name: "bad limit", query: "limit=0", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, _ := gin.CreateTestContext(httptest.NewRecorder())
+			c.Request = httptest.NewRequest(http.MethodGet, "/accounts?"+tt.query, nil)
+
+			filter, err := parseAccountFilter(c)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("parseAccountFilter(%q) succeeded, want an error", tt.query)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseAccountFilter(%q) returned an error: %v", tt.query, err)
+			}
+			tt.check(t, filter)
+		})
+	}
+}
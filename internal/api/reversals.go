@@ -0,0 +1,81 @@
+package api
+
+import (
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"banking-ledger/internal/domain"
+	"banking-ledger/internal/models"
+	"banking-ledger/internal/money"
+)
+
+// Reverses a completed transaction in full
+func (h *Handler) ReverseTransactionHandler(c *gin.Context) {
+	// The body is optional
+	var req models.ReverseTransactionRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	transaction, err := h.transactionService.ReverseTransaction(c.Param("id"), req.Description)
+	writeReversalResponse(c, transaction, err)
+}
+
+// Refunds part of a completed transaction
+func (h *Handler) RefundTransactionHandler(c *gin.Context) {
+	var req models.RefundTransactionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	original, err := h.transactionService.GetTransaction(c.Param("id"))
+	if err != nil {
+		writeReversalResponse(c, nil, err)
+		return
+	}
+
+	amount, err := money.Parse(req.Amount.String(), original.Amount.Currency)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	transaction, err := h.transactionService.RefundTransaction(original.ID, amount, req.Description)
+	writeReversalResponse(c, transaction, err)
+}
+
+func writeReversalResponse(c *gin.Context, transaction *domain.Transaction, err error) {
+	switch {
+	case errors.Is(err, domain.ErrTransactionNotFound):
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"error":   "Transaction not found",
+		})
+	case errors.Is(err, domain.ErrNotReversible), errors.Is(err, domain.ErrReversalExceedsOriginal):
+		c.JSON(http.StatusConflict, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+	case err != nil:
+		writeTransactionError(c, err)
+	default:
+		c.JSON(http.StatusAccepted, gin.H{
+			"success": true,
+			"data":    transaction,
+		})
+	}
+}
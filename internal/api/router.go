@@ -21,20 +21,78 @@ func (h *Handler) CreateRouter() *gin.Engine {
 	r.Use(cors.New(cors.Config{
 		AllowOrigins:     []string{"*"},
 		AllowMethods:     []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowHeaders:     []string{"Origin", "Content-Type", "Accept", "Authorization"},
-		ExposeHeaders:    []string{"Content-Length"},
+		AllowHeaders:     []string{"Origin", "Content-Type", "Accept", "Authorization", idempotencyKeyHeader},
+		ExposeHeaders:    []string{"Content-Length", idempotentReplayedHeader},
 		AllowCredentials: true,
 		MaxAge:           12 * time.Hour,
 	}))
 
-	// Account routes
-	r.POST("/accounts", h.CreateAccountHandler)
+	// Account routes; an initial_amount moves money, so creation is idempotent too
+	r.POST("/accounts", h.Idempotent(), h.CreateAccountHandler)
 	r.GET("/accounts", h.ListAccountsHandler)
 	r.GET("/accounts/:id", h.GetAccountHandler)
+	r.GET("/accounts/:id/balance/verify", h.VerifyBalanceHandler)
+	r.GET("/accounts/:id/balance-history", h.GetBalanceHistoryHandler)
+	r.POST("/accounts/:id/status", h.ChangeAccountStatusHandler)
+	r.GET("/accounts/:id/status/history", h.GetAccountStatusHistoryHandler)
+	r.PUT("/accounts/:id/overdraft-limit", h.ChangeOverdraftLimitHandler)
+	r.GET("/accounts/:id/overdraft-limit/history", h.GetOverdraftLimitHistoryHandler)
+	r.PUT("/accounts/:id/fee-waiver", h.SetFeeWaiverHandler)
+	r.PUT("/accounts/:id/interest-plan", h.AssignInterestPlanHandler)
+	r.GET("/accounts/:id/interest/accruals", h.ListInterestAccrualsHandler)
+	r.GET("/accounts/:id/statements", h.GetStatementHandler)
+	r.POST("/accounts/:id/scheduled-transactions", h.Idempotent(), h.CreateScheduledTransactionHandler)
+	r.GET("/accounts/:id/scheduled-transactions", h.ListScheduledTransactionsHandler)
 
-	// Transaction routes
-	r.POST("/accounts/:id/deposit", h.DepositHandler)
-	r.POST("/accounts/:id/withdraw", h.WithdrawHandler)
+	// Standing order routes
+	r.GET("/scheduled-transactions/:id", h.GetScheduledTransactionHandler)
+	r.POST("/scheduled-transactions/:id/pause", h.PauseScheduledTransactionHandler)
+	r.POST("/scheduled-transactions/:id/resume", h.ResumeScheduledTransactionHandler)
+	r.POST("/scheduled-transactions/:id/cancel", h.CancelScheduledTransactionHandler)
+	r.GET("/scheduled-transactions/:id/executions", h.ListScheduledExecutionsHandler)
+
+	// Transaction routes; anything that moves money must go through Idempotent
+	r.POST("/accounts/:id/deposit", h.Idempotent(), h.DepositHandler)
+	r.POST("/accounts/:id/withdraw", h.Idempotent(), h.WithdrawHandler)
+	r.POST("/transfers", h.Idempotent(), h.TransferHandler)
+	r.GET("/transactions/:id", h.GetTransactionHandler)
+	r.POST("/transactions/:id/reverse", h.Idempotent(), h.ReverseTransactionHandler)
+	r.POST("/transactions/:id/refund", h.Idempotent(), h.RefundTransactionHandler)
+	r.GET("/accounts/:id/transactions", h.ListAccountTransactionsHandler)
+
+	// Authorization hold routes
+	r.POST("/accounts/:id/holds", h.Idempotent(), h.CreateHoldHandler)
+	r.GET("/accounts/:id/holds", h.ListHoldsHandler)
+	r.GET("/holds/:id", h.GetHoldHandler)
+	r.POST("/holds/:id/capture", h.Idempotent(), h.CaptureHoldHandler)
+	r.POST("/holds/:id/void", h.Idempotent(), h.VoidHoldHandler)
+
+	// Limit rule admin routes
+	r.GET("/limits/rules", h.ListLimitRulesHandler)
+	r.POST("/limits/rules", h.CreateLimitRuleHandler)
+	r.GET("/limits/rules/:id", h.GetLimitRuleHandler)
+	r.PUT("/limits/rules/:id", h.UpdateLimitRuleHandler)
+	r.DELETE("/limits/rules/:id", h.DeleteLimitRuleHandler)
+
+	// Fee schedule admin routes
+	r.GET("/fees/schedules", h.ListFeeSchedulesHandler)
+	r.POST("/fees/schedules", h.CreateFeeScheduleHandler)
+	r.GET("/fees/schedules/:id", h.GetFeeScheduleHandler)
+	r.PUT("/fees/schedules/:id", h.UpdateFeeScheduleHandler)
+	r.DELETE("/fees/schedules/:id", h.DeleteFeeScheduleHandler)
+
+	// Interest plan admin routes
+	r.GET("/interest/plans", h.ListInterestPlansHandler)
+	r.POST("/interest/plans", h.CreateInterestPlanHandler)
+	r.GET("/interest/plans/:id", h.GetInterestPlanHandler)
+	r.PUT("/interest/plans/:id", h.UpdateInterestPlanHandler)
+
+	// Ledger routes
+	r.GET("/ledger/trial-balance", h.TrialBalanceHandler)
+
+	// FX rate routes
+	r.GET("/fx/rates", h.ListFXRatesHandler)
+	r.PUT("/fx/rates", h.SetFXRateHandler)
 
 	// Health check
 	r.GET("/health", func(c *gin.Context) {
@@ -0,0 +1,166 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"banking-ledger/internal/domain"
+	"banking-ledger/internal/models"
+	"banking-ledger/internal/money"
+)
+
+// Schedules a one-off or recurring transaction from the account
+func (h *Handler) CreateScheduledTransactionHandler(c *gin.Context) {
+	var req models.ScheduledTransactionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	account, ok := h.findAccount(c, c.Param("id"))
+	if !ok {
+		return
+	}
+
+	amount, err := req.Money(account.Currency)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	schedule, err := h.scheduledService.Create(&domain.ScheduledTransaction{
+		AccountID:   account.ID,
+		ToAccountID: req.ToAccountID,
+		Type:        req.Type,
+		Amount:      amount,
+		Description: req.Description,
+		RunAt:       req.RunAt,
+		Cron:        req.Cron,
+	})
+	if err != nil {
+		writeScheduledTransactionError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"data":    schedule,
+	})
+}
+
+// Lists the scheduled transactions paying into or out of the account
+func (h *Handler) ListScheduledTransactionsHandler(c *gin.Context) {
+	schedules, err := h.scheduledService.ListByAccount(c.Param("id"))
+	if err != nil {
+		writeScheduledTransactionError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    schedules,
+	})
+}
+
+// Retrieves a scheduled transaction by ID
+func (h *Handler) GetScheduledTransactionHandler(c *gin.Context) {
+	schedule, err := h.scheduledService.Get(c.Param("id"))
+	if err != nil {
+		writeScheduledTransactionError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    schedule,
+	})
+}
+
+// Pauses a scheduled transaction
+func (h *Handler) PauseScheduledTransactionHandler(c *gin.Context) {
+	schedule, err := h.scheduledService.Pause(c.Param("id"))
+	if err != nil {
+		writeScheduledTransactionError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    schedule,
+	})
+}
+
+// Resumes a paused scheduled transaction
+func (h *Handler) ResumeScheduledTransactionHandler(c *gin.Context) {
+	schedule, err := h.scheduledService.Resume(c.Param("id"))
+	if err != nil {
+		writeScheduledTransactionError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    schedule,
+	})
+}
+
+// Cancels a scheduled transaction
+func (h *Handler) CancelScheduledTransactionHandler(c *gin.Context) {
+	schedule, err := h.scheduledService.Cancel(c.Param("id"))
+	if err != nil {
+		writeScheduledTransactionError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    schedule,
+	})
+}
+
+// Lists every run of a scheduled transaction and its outcome
+func (h *Handler) ListScheduledExecutionsHandler(c *gin.Context) {
+	executions, err := h.scheduledService.ListExecutions(c.Param("id"))
+	if err != nil {
+		writeScheduledTransactionError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    executions,
+	})
+}
+
+func writeScheduledTransactionError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, domain.ErrScheduledTransactionNotFound):
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"error":   "Scheduled transaction not found",
+		})
+	case errors.Is(err, domain.ErrAccountNotFound):
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"error":   "Account not found",
+		})
+	case errors.Is(err, domain.ErrInvalidScheduledTransaction), errors.Is(err, money.ErrCurrencyMismatch):
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to process scheduled transaction",
+		})
+	}
+}
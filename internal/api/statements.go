@@ -0,0 +1,84 @@
+package api
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"banking-ledger/internal/domain"
+	"banking-ledger/internal/statement"
+)
+
+// Builds an account statement. Query parameters: from and to (YYYY-MM-DD,
+// to exclusive) defaulting to the current month, and format, json (the
+// default) or html.
+func (h *Handler) GetStatementHandler(c *gin.Context) {
+	today := domain.Day(time.Now())
+	from, err := parseDateParam(c, "from", today.AddDate(0, 0, 1-today.Day()))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+	to, err := parseDateParam(c, "to", from.AddDate(0, 1, 0))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+	format := c.DefaultQuery("format", "json")
+	if format != "json" && format != "html" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "invalid format: must be json or html",
+		})
+		return
+	}
+
+	result, err := h.statementService.Generate(c.Param("id"), from, to)
+	switch {
+	case errors.Is(err, domain.ErrAccountNotFound):
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"error":   "Account not found",
+		})
+		return
+	case errors.Is(err, domain.ErrInvalidStatementPeriod):
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to generate statement",
+		})
+		return
+	}
+
+	if format == "html" {
+		var page bytes.Buffer
+		if err := statement.RenderHTML(&page, result); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"success": false,
+				"error":   "Failed to render statement",
+			})
+			return
+		}
+		c.Data(http.StatusOK, "text/html; charset=utf-8", page.Bytes())
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    result,
+	})
+}
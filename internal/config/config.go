@@ -3,15 +3,35 @@ package config
 import (
 	"fmt"
 	"os"
+	"strconv"
+	"time"
 )
 
 type Config struct {
-	PostgresURL      string
-	MongoURL         string
-	MongoDB          string
-	RabbitMQURL      string
-	TransactionQueue string
-	Port             string
+	PostgresURL        string
+	MongoURL           string
+	MongoDB            string
+	RabbitMQURL        string
+	TransactionQueue   string
+	Port               string
+	OutboxPollInterval time.Duration
+	FXRatesFile        string // optional JSON file of exchange rates loaded at startup
+	HoldExpiryInterval time.Duration
+	// How often the scheduler accrues the previous day's interest
+	InterestRunInterval time.Duration
+	// How often the scheduler queues due scheduled transactions
+	ScheduledTransactionInterval time.Duration
+	// How often the processor reconciles balances against the transaction
+	// log; zero turns it off
+	ReconcileInterval time.Duration
+	// How often the scheduler sweeps pending transactions, how old they
+	// must be and how many times one is requeued before it is failed
+	PendingSweepInterval    time.Duration
+	PendingSweepAge         time.Duration
+	PendingSweepMaxRequeues int
+	// Names this processor in transaction status histories; defaults to
+	// the host name and process ID
+	InstanceID string
 }
 
 // Load configuration from environment variables
@@ -44,18 +64,104 @@ func Load() (*Config, error) {
 		port = "8080"
 	}
 
+	outboxPollInterval, err := durationEnv("OUTBOX_POLL_INTERVAL", 500*time.Millisecond)
+	if err != nil {
+		return nil, err
+	}
+
+	fxRatesFile := os.Getenv("FX_RATES_FILE")
+
+	holdExpiryInterval, err := durationEnv("HOLD_EXPIRY_INTERVAL", time.Minute)
+	if err != nil {
+		return nil, err
+	}
+
+	interestRunInterval, err := durationEnv("INTEREST_RUN_INTERVAL", time.Hour)
+	if err != nil {
+		return nil, err
+	}
+
+	scheduledTransactionInterval, err := durationEnv("SCHEDULED_TRANSACTION_INTERVAL", time.Minute)
+	if err != nil {
+		return nil, err
+	}
+
+	reconcileInterval, err := optionalDurationEnv("RECONCILE_INTERVAL")
+	if err != nil {
+		return nil, err
+	}
+
+	pendingSweepInterval, err := durationEnv("PENDING_SWEEP_INTERVAL", 5*time.Minute)
+	if err != nil {
+		return nil, err
+	}
+
+	pendingSweepAge, err := durationEnv("PENDING_SWEEP_AGE", 15*time.Minute)
+	if err != nil {
+		return nil, err
+	}
+
+	pendingSweepMaxRequeues := 3
+	if value := os.Getenv("PENDING_SWEEP_MAX_REQUEUES"); value != "" {
+		requeues, err := strconv.Atoi(value)
+		if err != nil || requeues < 0 {
+			return nil, fmt.Errorf("invalid PENDING_SWEEP_MAX_REQUEUES: %q", value)
+		}
+		pendingSweepMaxRequeues = requeues
+	}
+
+	instanceID := os.Getenv("INSTANCE_ID")
+
 	cfg := &Config{
-		PostgresURL:      postgresURL,
-		MongoURL:         mongoURL,
-		MongoDB:          mongoDB,
-		RabbitMQURL:      rabbitMQURL,
-		TransactionQueue: transactionQueue,
-		Port:             port,
+		PostgresURL:                  postgresURL,
+		MongoURL:                     mongoURL,
+		MongoDB:                      mongoDB,
+		RabbitMQURL:                  rabbitMQURL,
+		TransactionQueue:             transactionQueue,
+		Port:                         port,
+		OutboxPollInterval:           outboxPollInterval,
+		FXRatesFile:                  fxRatesFile,
+		HoldExpiryInterval:           holdExpiryInterval,
+		InterestRunInterval:          interestRunInterval,
+		ScheduledTransactionInterval: scheduledTransactionInterval,
+		ReconcileInterval:            reconcileInterval,
+		PendingSweepInterval:         pendingSweepInterval,
+		PendingSweepAge:              pendingSweepAge,
+		PendingSweepMaxRequeues:      pendingSweepMaxRequeues,
+		InstanceID:                   instanceID,
 	}
 
 	return cfg, nil
 }
 
+// Reads a positive duration such as "30s" from the environment variable
+// name, or returns def if it is unset
+func durationEnv(name string, def time.Duration) (time.Duration, error) {
+	value := os.Getenv(name)
+	if value == "" {
+		return def, nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("invalid %s: %q", name, value)
+	}
+	return d, nil
+}
+
+// Like durationEnv, but zero is allowed and is the default, for settings
+// where zero turns the feature off
+func optionalDurationEnv(name string) (time.Duration, error) {
+	value := os.Getenv(name)
+	if value == "" {
+		return 0, nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("invalid %s: %q", name, value)
+	}
+	return d, nil
+}
+
 // Configuration Validation
 func (c *Config) Validate() error {
 	if c.PostgresURL == "" {
@@ -5,12 +5,19 @@ type TransactionType string
 const (
 	TransactionTypeDeposit    TransactionType = "deposit"
 	TransactionTypeWithdrawal TransactionType = "withdrawal"
+	TransactionTypeTransfer   TransactionType = "transfer"
+	TransactionTypeCapture    TransactionType = "capture"  // settles an authorization hold
+	TransactionTypeReversal   TransactionType = "reversal" // undoes a whole transaction
+	TransactionTypeRefund     TransactionType = "refund"   // undoes part of a transaction
+	TransactionTypeFee        TransactionType = "fee"      // charged for another transaction
+	TransactionTypeInterest   TransactionType = "interest" // monthly interest paid to the account
 )
 
 type TransactionStatus string
 
 const (
-	TransactionStatusPending   TransactionStatus = "pending"
-	TransactionStatusCompleted TransactionStatus = "completed"
-	TransactionStatusFailed    TransactionStatus = "failed"
+	TransactionStatusPending    TransactionStatus = "pending"
+	TransactionStatusProcessing TransactionStatus = "processing" // picked up by a processor
+	TransactionStatusCompleted  TransactionStatus = "completed"
+	TransactionStatusFailed     TransactionStatus = "failed"
 )
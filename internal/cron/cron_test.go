@@ -0,0 +1,60 @@
+package cron
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestNext(t *testing.T) {
+	start := time.Date(2024, time.January, 15, 10, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		expr string
+		from time.Time
+		want time.Time
+	}{
+		{"* * * * *", start, time.Date(2024, 1, 15, 10, 31, 0, 0, time.UTC)},
+		{"0 9 1 * *", start, time.Date(2024, 2, 1, 9, 0, 0, 0, time.UTC)},
+		{"*/15 * * * *", start, time.Date(2024, 1, 15, 10, 45, 0, 0, time.UTC)},
+		{"0 0 * * 1-5", time.Date(2024, 1, 19, 12, 0, 0, 0, time.UTC), time.Date(2024, 1, 22, 0, 0, 0, 0, time.UTC)},
+		{"0 0 * * 7", start, time.Date(2024, 1, 21, 0, 0, 0, 0, time.UTC)},
+		{"0 0 29 2 *", start, time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC)},
+		{"0 0 31 * *", time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC), time.Date(2024, 3, 31, 0, 0, 0, 0, time.UTC)},
+		// Either day field matches when both are restricted
+		{"0 0 1 * 1", start, time.Date(2024, 1, 22, 0, 0, 0, 0, time.UTC)},
+		{"30 10 15 1 *", start, time.Date(2025, 1, 15, 10, 30, 0, 0, time.UTC)},
+		{"0 0 30 2 *", start, time.Time{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			schedule, err := Parse(tt.expr)
+			if err != nil {
+				t.Fatalf("Parse returned an error: %v", err)
+			}
+			if got := schedule.Next(tt.from); !got.Equal(tt.want) {
+				t.Errorf("Next(%s) = %s, want %s", tt.from, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseRejectsInvalidExpressions(t *testing.T) {
+	for _, expr := range []string{
+		"",
+		"* * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"*/0 * * * *",
+		"5-1 * * * *",
+		"a * * * *",
+	} {
+		if _, err := Parse(expr); !errors.Is(err, ErrInvalidExpression) {
+			t.Errorf("Parse(%q) = %v, want ErrInvalidExpression", expr, err)
+		}
+	}
+}
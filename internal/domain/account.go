@@ -1,20 +1,103 @@
 package domain
 
 import (
+	"encoding/json"
 	"time"
+
+	"banking-ledger/internal/money"
 )
 
+// Balance is the ledger balance, a cache of the account's journal postings;
+// it is only ever changed by posting a JournalEntry. Held is the total of the
+// account's active holds, which the ledger does not see. OverdraftLimit is
+// how far below zero the available balance may go. Version is the number of
+// the last AccountEvent applied to it.
 type Account struct {
-	ID        string    `json:"id"`
-	Name      string    `json:"name"`
-	Balance   float64   `json:"balance"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	ID             string         `json:"id"`
+	Name           string         `json:"name"`
+	Type           AccountType    `json:"type"`
+	Currency       money.Currency `json:"currency"`
+	Balance        money.Money    `json:"ledger_balance"`
+	Held           money.Money    `json:"held_balance"`
+	OverdraftLimit money.Money    `json:"overdraft_limit"`
+	Status         AccountStatus  `json:"status"`
+	FeesWaived     bool           `json:"fees_waived"`
+	InterestPlanID string         `json:"interest_plan_id,omitempty"`
+	CreatedAt      time.Time      `json:"created_at"`
+	UpdatedAt      time.Time      `json:"updated_at"`
+	Version        int64          `json:"-"`
+}
+
+// AvailableBalance is the ledger balance less the amount reserved by holds
+func (a *Account) AvailableBalance() (money.Money, error) {
+	// Accounts built without a held balance have nothing reserved
+	if a.Held.Currency == "" {
+		return a.Balance, nil
+	}
+	return a.Balance.Sub(a.Held)
+}
+
+// MarshalJSON adds the derived available_balance to the stored fields
+func (a Account) MarshalJSON() ([]byte, error) {
+	type account Account
+	available, err := a.AvailableBalance()
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(struct {
+		account
+		AvailableBalance money.Money `json:"available_balance"`
+	}{account(a), available})
+}
+
+type AccountSortField string
+
+const (
+	AccountSortCreatedAt AccountSortField = "created_at"
+	AccountSortName      AccountSortField = "name"
+	AccountSortBalance   AccountSortField = "balance"
+)
+
+// Criteria for listing accounts; zero values are ignored
+type AccountFilter struct {
+	NamePrefix   string // case-insensitive
+	Currency     money.Currency
+	Status       AccountStatus
+	Type         AccountType
+	Overdrawn    bool         // only accounts with a negative ledger balance
+	MinBalance   *money.Money // balance filters only match accounts in their currency
+	MaxBalance   *money.Money
+	CreatedFrom  *time.Time
+	CreatedTo    *time.Time // exclusive
+	SortBy       AccountSortField
+	Descending   bool
+	Cursor       string // NextCursor of the previous page
+	Limit        int
+	IncludeTotal bool
+}
+
+// Total and Totals cover every account matching the filter, across all
+// pages, and are only set when requested. Totals sums balances per currency.
+type AccountPage struct {
+	Accounts   []*Account    `json:"accounts"`
+	NextCursor string        `json:"next_cursor,omitempty"`
+	Total      *int64        `json:"total,omitempty"`
+	Totals     []money.Money `json:"totals,omitempty"`
 }
 
 type AccountRepository interface {
 	Create(account *Account) error
 	GetByID(id string) (*Account, error)
-	UpdateBalance(id string, newBalance float64) error
-	List() ([]*Account, error)
+	// LockForUpdate reads the accounts and holds row locks on them until the
+	// surrounding UnitOfWork ends; only meaningful inside UnitOfWork.Do
+	LockForUpdate(ids ...string) (map[string]*Account, error)
+	List(filter AccountFilter) (*AccountPage, error)
+	// UpdateStatus stores the account's new status together with the audit
+	// record of the change
+	UpdateStatus(change *AccountStatusChange) error
+	ListStatusChanges(accountID string) ([]*AccountStatusChange, error)
+	// UpdateOverdraftLimit stores the new limit with its audit record
+	UpdateOverdraftLimit(change *OverdraftLimitChange) error
+	ListOverdraftLimitChanges(accountID string) ([]*OverdraftLimitChange, error)
+	SetFeesWaived(id string, waived bool) error
 }
@@ -0,0 +1,254 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"banking-ledger/internal/money"
+)
+
+type AccountEventType string
+
+const (
+	AccountEventOpened   AccountEventType = "opened"
+	AccountEventCredited AccountEventType = "credited"
+	AccountEventDebited  AccountEventType = "debited"
+	AccountEventFrozen   AccountEventType = "frozen"
+	AccountEventClosed   AccountEventType = "closed"
+	// Any other status change, such as unfreezing or blocking debits
+	AccountEventStatusChanged         AccountEventType = "status_changed"
+	AccountEventOverdraftLimitChanged AccountEventType = "overdraft_limit_changed"
+)
+
+var ErrEventOutOfOrder = errors.New("account event out of order")
+
+// AccountEvent is one immutable change to an account. An account's events
+// are numbered by Version from 1 without gaps; folding them in order gives
+// its ledger state. Only the fields of the event's type are set.
+type AccountEvent struct {
+	AccountID      string           `json:"account_id"`
+	Version        int64            `json:"version"`
+	Type           AccountEventType `json:"type"`
+	Name           string           `json:"name,omitempty"`            // opened
+	AccountType    AccountType      `json:"account_type,omitempty"`    // opened
+	Currency       money.Currency   `json:"currency,omitempty"`        // opened
+	OverdraftLimit *money.Money     `json:"overdraft_limit,omitempty"` // opened, overdraft_limit_changed
+	Amount         *money.Money     `json:"amount,omitempty"`          // credited, debited; always positive
+	EntryID        string           `json:"entry_id,omitempty"`        // journal entry that moved the balance
+	Status         AccountStatus    `json:"status,omitempty"`          // frozen, closed, status_changed
+	OccurredAt     time.Time        `json:"occurred_at"`
+}
+
+// AccountSnapshot is an account's state after the event numbered
+// Account.Version, so a replay only has to fold the events after it
+type AccountSnapshot struct {
+	Account Account   `json:"account"`
+	TakenAt time.Time `json:"taken_at"`
+}
+
+// Replay of one account by cmd/replay. Mismatches lists the fields where the
+// stored projection, or the latest snapshot when replaying from scratch,
+// disagrees with the events.
+type ReplayedAccount struct {
+	AccountID    string   `json:"account_id"`
+	Version      int64    `json:"version"`
+	FromSnapshot int64    `json:"from_snapshot,omitempty"` // version replay started after
+	Events       int      `json:"events"`
+	Mismatches   []string `json:"mismatches,omitempty"`
+	Rebuilt      bool     `json:"rebuilt"`
+}
+
+type ReplayReport struct {
+	StartedAt        time.Time         `json:"started_at"`
+	FinishedAt       time.Time         `json:"finished_at"`
+	AccountsReplayed int               `json:"accounts_replayed"`
+	EventsReplayed   int               `json:"events_replayed"`
+	SnapshotsTaken   int               `json:"snapshots_taken"`
+	Mismatched       []ReplayedAccount `json:"mismatched"`
+}
+
+type AccountEventStore interface {
+	// ListEvents returns the account's events after version afterVersion,
+	// oldest first
+	ListEvents(accountID string, afterVersion int64) ([]*AccountEvent, error)
+	// ListAccountIDs returns every account with at least one event
+	ListAccountIDs() ([]string, error)
+	// LatestSnapshot returns the account's newest snapshot, or nil if none
+	LatestSnapshot(accountID string) (*AccountSnapshot, error)
+	// SaveSnapshot stores a snapshot; saving one for a version that already
+	// has a snapshot changes nothing
+	SaveSnapshot(snapshot *AccountSnapshot) error
+	// ReplaceProjection overwrites the event-derived columns of the account's
+	// row with the given state, recreating the row if it is missing
+	ReplaceProjection(account *Account) error
+}
+
+// NewOpenedEvent records the creation of an account with a zero balance
+func NewOpenedEvent(account *Account) *AccountEvent {
+	limit := account.OverdraftLimit
+	if limit.Currency == "" {
+		limit = money.Zero(account.Currency)
+	}
+	return &AccountEvent{
+		AccountID:      account.ID,
+		Type:           AccountEventOpened,
+		Name:           account.Name,
+		AccountType:    account.Type,
+		Currency:       account.Currency,
+		OverdraftLimit: &limit,
+		Status:         account.Status,
+		OccurredAt:     account.CreatedAt,
+	}
+}
+
+// NewBalanceEvent records a change to the ledger balance: a credit if change
+// is positive, otherwise a debit
+func NewBalanceEvent(accountID, entryID string, change money.Money, at time.Time) *AccountEvent {
+	event := &AccountEvent{
+		AccountID:  accountID,
+		Type:       AccountEventCredited,
+		EntryID:    entryID,
+		OccurredAt: at,
+	}
+	if change.IsNegative() {
+		event.Type = AccountEventDebited
+		change = change.Neg()
+	}
+	event.Amount = &change
+	return event
+}
+
+// NewStatusEvent records a move to status
+func NewStatusEvent(accountID string, status AccountStatus, at time.Time) *AccountEvent {
+	event := &AccountEvent{
+		AccountID:  accountID,
+		Type:       AccountEventStatusChanged,
+		Status:     status,
+		OccurredAt: at,
+	}
+	switch status {
+	case AccountStatusFrozen:
+		event.Type = AccountEventFrozen
+	case AccountStatusClosed:
+		event.Type = AccountEventClosed
+	}
+	return event
+}
+
+func NewOverdraftLimitEvent(accountID string, limit money.Money, at time.Time) *AccountEvent {
+	return &AccountEvent{
+		AccountID:      accountID,
+		Type:           AccountEventOverdraftLimitChanged,
+		OverdraftLimit: &limit,
+		OccurredAt:     at,
+	}
+}
+
+// Apply folds the next event into the account. Events are facts that were
+// already checked when they happened, so only their order and shape are
+// checked here.
+func (a *Account) Apply(event *AccountEvent) error {
+	if event.Version != a.Version+1 {
+		return fmt.Errorf("%w: account %s is at version %d, event is %d", ErrEventOutOfOrder, a.ID, a.Version, event.Version)
+	}
+	if (event.Type == AccountEventOpened) != (a.Version == 0) {
+		return fmt.Errorf("%w: account %s must start with exactly one %s event", ErrEventOutOfOrder, event.AccountID, AccountEventOpened)
+	}
+
+	switch event.Type {
+	case AccountEventOpened:
+		*a = Account{
+			ID:             event.AccountID,
+			Name:           event.Name,
+			Type:           event.AccountType,
+			Currency:       event.Currency,
+			Balance:        money.Zero(event.Currency),
+			Held:           money.Zero(event.Currency),
+			OverdraftLimit: money.Zero(event.Currency),
+			Status:         event.Status,
+			CreatedAt:      event.OccurredAt,
+		}
+		if event.OverdraftLimit != nil {
+			a.OverdraftLimit = *event.OverdraftLimit
+		}
+		if a.Status == "" {
+			a.Status = AccountStatusActive
+		}
+	case AccountEventCredited, AccountEventDebited:
+		if event.Amount == nil {
+			return fmt.Errorf("%s event %d of account %s has no amount", event.Type, event.Version, a.ID)
+		}
+		change := *event.Amount
+		if event.Type == AccountEventDebited {
+			change = change.Neg()
+		}
+		balance, err := a.Balance.Add(change)
+		if err != nil {
+			return err
+		}
+		a.Balance = balance
+	case AccountEventFrozen, AccountEventClosed, AccountEventStatusChanged:
+		if !event.Status.Valid() {
+			return fmt.Errorf("%s event %d of account %s has invalid status %q", event.Type, event.Version, a.ID, event.Status)
+		}
+		a.Status = event.Status
+	case AccountEventOverdraftLimitChanged:
+		if event.OverdraftLimit == nil || event.OverdraftLimit.Currency != a.Currency {
+			return fmt.Errorf("%s event %d of account %s has no limit in %s", event.Type, event.Version, a.ID, a.Currency)
+		}
+		a.OverdraftLimit = *event.OverdraftLimit
+	default:
+		return fmt.Errorf("unknown event type %q in account %s", event.Type, a.ID)
+	}
+
+	a.Version = event.Version
+	a.UpdatedAt = event.OccurredAt
+	return nil
+}
+
+// ReplayAccount rebuilds an account by folding events into snapshot, which
+// may be nil to start from the first event
+func ReplayAccount(snapshot *AccountSnapshot, events []*AccountEvent) (*Account, error) {
+	account := &Account{}
+	if snapshot != nil {
+		*account = snapshot.Account
+	}
+	for _, event := range events {
+		if err := account.Apply(event); err != nil {
+			return nil, err
+		}
+	}
+	return account, nil
+}
+
+// DiffEventState lists the event-derived fields where stored differs from
+// a, as "field: want x, got y"
+func (a *Account) DiffEventState(stored *Account) []string {
+	var diffs []string
+	add := func(field string, want, got interface{}) {
+		diffs = append(diffs, fmt.Sprintf("%s: want %v, got %v", field, want, got))
+	}
+	if a.Name != stored.Name {
+		add("name", a.Name, stored.Name)
+	}
+	if a.Type != stored.Type {
+		add("type", a.Type, stored.Type)
+	}
+	if a.Currency != stored.Currency {
+		add("currency", a.Currency, stored.Currency)
+	}
+	if a.Balance != stored.Balance {
+		add("ledger_balance", a.Balance, stored.Balance)
+	}
+	if a.OverdraftLimit != stored.OverdraftLimit {
+		add("overdraft_limit", a.OverdraftLimit, stored.OverdraftLimit)
+	}
+	if a.Status != stored.Status {
+		add("status", a.Status, stored.Status)
+	}
+	if a.Version != stored.Version {
+		add("version", a.Version, stored.Version)
+	}
+	return diffs
+}
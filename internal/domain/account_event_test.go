@@ -0,0 +1,113 @@
+package domain
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"banking-ledger/internal/money"
+)
+
+func accountEvents() []*AccountEvent {
+	at := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+	events := []*AccountEvent{
+		NewOpenedEvent(&Account{
+			ID:             "acc-1",
+			Name:           "Savings",
+			Type:           AccountTypeSavings,
+			Currency:       money.USD,
+			OverdraftLimit: money.Zero(money.USD),
+			Status:         AccountStatusActive,
+			CreatedAt:      at,
+		}),
+		NewBalanceEvent("acc-1", "entry-1", money.MustParse("100.00", money.USD), at.Add(time.Hour)),
+		NewBalanceEvent("acc-1", "entry-2", money.MustParse("-30.00", money.USD), at.Add(2*time.Hour)),
+		NewStatusEvent("acc-1", AccountStatusFrozen, at.Add(3*time.Hour)),
+		NewStatusEvent("acc-1", AccountStatusActive, at.Add(4*time.Hour)),
+		NewBalanceEvent("acc-1", "entry-3", money.MustParse("-70.00", money.USD), at.Add(5*time.Hour)),
+		NewStatusEvent("acc-1", AccountStatusClosed, at.Add(6*time.Hour)),
+	}
+	for i, event := range events {
+		event.Version = int64(i + 1)
+	}
+	return events
+}
+
+func TestAccountEventConstructors(t *testing.T) {
+	events := accountEvents()
+	want := []AccountEventType{
+		AccountEventOpened, AccountEventCredited, AccountEventDebited, AccountEventFrozen,
+		AccountEventStatusChanged, AccountEventDebited, AccountEventClosed,
+	}
+	for i, event := range events {
+		if event.Type != want[i] {
+			t.Errorf("event %d type = %s, want %s", i+1, event.Type, want[i])
+		}
+	}
+	if events[2].Amount.String() != "30.00" {
+		t.Errorf("debit amount = %s, want 30.00", events[2].Amount)
+	}
+}
+
+func TestReplayAccount(t *testing.T) {
+	events := accountEvents()
+
+	account, err := ReplayAccount(nil, events[:3])
+	if err != nil {
+		t.Fatalf("ReplayAccount() returned an error: %v", err)
+	}
+	if account.Balance.String() != "70.00" || account.Status != AccountStatusActive || account.Version != 3 {
+		t.Errorf("after 3 events = %s %s v%d, want 70.00 active v3", account.Balance, account.Status, account.Version)
+	}
+	if account.Name != "Savings" || account.Type != AccountTypeSavings || account.Currency != money.USD {
+		t.Errorf("opened state lost: %+v", account)
+	}
+
+	full, err := ReplayAccount(nil, events)
+	if err != nil {
+		t.Fatalf("ReplayAccount() returned an error: %v", err)
+	}
+	if !full.Balance.IsZero() || full.Status != AccountStatusClosed || full.Version != 7 {
+		t.Errorf("after all events = %s %s v%d, want 0.00 closed v7", full.Balance, full.Status, full.Version)
+	}
+
+	// A snapshot stands in for the events before it
+	fromSnapshot, err := ReplayAccount(&AccountSnapshot{Account: *account}, events[3:])
+	if err != nil {
+		t.Fatalf("ReplayAccount() from snapshot returned an error: %v", err)
+	}
+	if diffs := full.DiffEventState(fromSnapshot); len(diffs) > 0 {
+		t.Errorf("replay from snapshot differs: %v", diffs)
+	}
+}
+
+func TestReplayAccountRejectsBrokenHistory(t *testing.T) {
+	events := accountEvents()
+
+	tests := map[string][]*AccountEvent{
+		"gap":           {events[0], events[2]},
+		"no opened":     events[1:3],
+		"opened twice":  {events[0], {AccountID: "acc-1", Version: 2, Type: AccountEventOpened}},
+		"missing start": events[4:],
+	}
+	for name, history := range tests {
+		if _, err := ReplayAccount(nil, history); !errors.Is(err, ErrEventOutOfOrder) {
+			t.Errorf("%s: ReplayAccount() = %v, want %v", name, err, ErrEventOutOfOrder)
+		}
+	}
+}
+
+func TestDiffEventState(t *testing.T) {
+	account, err := ReplayAccount(nil, accountEvents()[:3])
+	if err != nil {
+		t.Fatal(err)
+	}
+	stored := *account
+	stored.Balance = money.MustParse("75.00", money.USD)
+	stored.Held = money.MustParse("10.00", money.USD) // not an event, so not compared
+
+	diffs := account.DiffEventState(&stored)
+	if len(diffs) != 1 || diffs[0] != "ledger_balance: want 70.00, got 75.00" {
+		t.Errorf("DiffEventState() = %v, want the ledger balance only", diffs)
+	}
+}
@@ -0,0 +1,66 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+type AccountStatus string
+
+const (
+	AccountStatusActive        AccountStatus = "active"
+	AccountStatusFrozen        AccountStatus = "frozen"         // no money in or out
+	AccountStatusDebitBlocked  AccountStatus = "debit_blocked"  // money may come in but not go out
+	AccountStatusCreditBlocked AccountStatus = "credit_blocked" // money may go out but not come in
+	AccountStatusClosed        AccountStatus = "closed"         // final; only reachable at zero balance with no active holds
+)
+
+var (
+	ErrAccountRestricted       = errors.New("account status does not allow this transaction")
+	ErrInvalidStatusTransition = errors.New("invalid account status transition")
+	ErrAccountNotEmpty         = errors.New("account balance must be zero to close")
+	ErrAccountHasHolds         = errors.New("account holds must be captured or voided before closing")
+)
+
+// Audit record of one status transition
+type AccountStatusChange struct {
+	ID         string        `json:"id"`
+	AccountID  string        `json:"account_id"`
+	FromStatus AccountStatus `json:"from_status"`
+	ToStatus   AccountStatus `json:"to_status"`
+	Reason     string        `json:"reason"`
+	Actor      string        `json:"actor"`
+	CreatedAt  time.Time     `json:"created_at"`
+}
+
+func (s AccountStatus) Valid() bool {
+	switch s {
+	case AccountStatusActive, AccountStatusFrozen, AccountStatusDebitBlocked,
+		AccountStatusCreditBlocked, AccountStatusClosed:
+		return true
+	}
+	return false
+}
+
+// CanTransitionTo reports whether the status may move to next. Every open
+// status can move to any other one; a closed account stays closed.
+func (s AccountStatus) CanTransitionTo(next AccountStatus) bool {
+	return next.Valid() && s != next && s != AccountStatusClosed
+}
+
+// CheckDebit returns ErrAccountRestricted unless money may leave the account
+func (a *Account) CheckDebit() error {
+	if a.Status == AccountStatusActive || a.Status == AccountStatusCreditBlocked {
+		return nil
+	}
+	return fmt.Errorf("%w: account %s is %s", ErrAccountRestricted, a.ID, a.Status)
+}
+
+// CheckCredit returns ErrAccountRestricted unless money may enter the account
+func (a *Account) CheckCredit() error {
+	if a.Status == AccountStatusActive || a.Status == AccountStatusDebitBlocked {
+		return nil
+	}
+	return fmt.Errorf("%w: account %s is %s", ErrAccountRestricted, a.ID, a.Status)
+}
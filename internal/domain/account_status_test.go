@@ -0,0 +1,49 @@
+package domain
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestAccountStatusRestrictions(t *testing.T) {
+	tests := []struct {
+		status              AccountStatus
+		canDebit, canCredit bool
+	}{
+		{AccountStatusActive, true, true},
+		{AccountStatusFrozen, false, false},
+		{AccountStatusDebitBlocked, false, true},
+		{AccountStatusCreditBlocked, true, false},
+		{AccountStatusClosed, false, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.status), func(t *testing.T) {
+			account := &Account{ID: "a", Status: tt.status}
+			if err := account.CheckDebit(); (err == nil) != tt.canDebit || (err != nil && !errors.Is(err, ErrAccountRestricted)) {
+				t.Errorf("CheckDebit() = %v, want allowed %v", err, tt.canDebit)
+			}
+			if err := account.CheckCredit(); (err == nil) != tt.canCredit || (err != nil && !errors.Is(err, ErrAccountRestricted)) {
+				t.Errorf("CheckCredit() = %v, want allowed %v", err, tt.canCredit)
+			}
+		})
+	}
+}
+
+func TestAccountStatusTransitions(t *testing.T) {
+	if !AccountStatusFrozen.CanTransitionTo(AccountStatusActive) {
+		t.Error("frozen account cannot be unfrozen")
+	}
+	if !AccountStatusDebitBlocked.CanTransitionTo(AccountStatusClosed) {
+		t.Error("debit-blocked account cannot be closed")
+	}
+	if AccountStatusClosed.CanTransitionTo(AccountStatusActive) {
+		t.Error("closed account can be reopened")
+	}
+	if AccountStatusActive.CanTransitionTo(AccountStatusActive) {
+		t.Error("transition to the same status is allowed")
+	}
+	if AccountStatusActive.CanTransitionTo("suspended") {
+		t.Error("transition to an unknown status is allowed")
+	}
+}
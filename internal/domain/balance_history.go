@@ -0,0 +1,44 @@
+package domain
+
+import (
+	"time"
+
+	"banking-ledger/internal/money"
+)
+
+// BalanceHistoryEntry is an account's ledger balance right after a journal
+// entry moved it. Sequence orders the entries of one account.
+type BalanceHistoryEntry struct {
+	Sequence      int64       `json:"sequence"`
+	AccountID     string      `json:"account_id"`
+	EntryID       string      `json:"entry_id"`
+	TransactionID string      `json:"transaction_id,omitempty"`
+	Change        money.Money `json:"change"`
+	Balance       money.Money `json:"ledger_balance"`
+	CreatedAt     time.Time   `json:"created_at"`
+}
+
+// Criteria for listing an account's balance history; zero values are ignored
+type BalanceHistoryFilter struct {
+	From   *time.Time
+	To     *time.Time // inclusive
+	Cursor string     // NextCursor of the previous page
+	Limit  int
+}
+
+// BalanceHistoryPage lists history newest first
+type BalanceHistoryPage struct {
+	Entries    []*BalanceHistoryEntry `json:"entries"`
+	NextCursor string                 `json:"next_cursor,omitempty"`
+}
+
+// PointInTimeBalance is an account's ledger balance as it stood at AsOf.
+// EntryID and ChangedAt identify the last change at or before then; both
+// are empty if the balance had not moved yet.
+type PointInTimeBalance struct {
+	AccountID string      `json:"account_id"`
+	AsOf      time.Time   `json:"as_of"`
+	Balance   money.Money `json:"ledger_balance"`
+	EntryID   string      `json:"entry_id,omitempty"`
+	ChangedAt *time.Time  `json:"changed_at,omitempty"`
+}
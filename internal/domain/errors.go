@@ -0,0 +1,13 @@
+package domain
+
+import "errors"
+
+var (
+	ErrAccountNotFound   = errors.New("account not found")
+	ErrInsufficientFunds = errors.New("insufficient funds")
+	// A request that can never succeed as made, such as a non-positive amount
+	ErrInvalidTransaction = errors.New("invalid transaction")
+
+	ErrTransactionNotFound = errors.New("transaction not found")
+	ErrInvalidCursor       = errors.New("invalid cursor")
+)
@@ -0,0 +1,306 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"banking-ledger/internal/constants"
+	"banking-ledger/internal/money"
+)
+
+type FeeKind string
+
+const (
+	FeeKindFlat       FeeKind = "flat"       // the same amount on every transaction
+	FeeKindPercentage FeeKind = "percentage" // a fraction of the amount
+	FeeKindTiered     FeeKind = "tiered"     // flat plus fraction, chosen by amount band
+)
+
+var (
+	ErrFeeScheduleNotFound = errors.New("fee schedule not found")
+	ErrInvalidFeeSchedule  = errors.New("invalid fee schedule")
+)
+
+// FeeTier prices amounts up to and including UpTo at Flat plus Rate of the
+// amount. Tiers are ordered by UpTo; the last one has no UpTo.
+type FeeTier struct {
+	UpTo *money.Money `json:"up_to,omitempty"`
+	Flat *money.Money `json:"flat,omitempty"`
+	Rate string       `json:"rate,omitempty"`
+}
+
+// FeeSchedule prices one transaction type for accounts of one type, or for
+// every account when AccountType is empty. Rates are fractions: "0.015" is
+// 1.5%. Min and Max bound the calculated fee for every kind. A schedule only
+// applies to accounts held in its currency.
+type FeeSchedule struct {
+	ID              string                    `json:"id"`
+	Name            string                    `json:"name"`
+	TransactionType constants.TransactionType `json:"transaction_type"`
+	AccountType     AccountType               `json:"account_type,omitempty"`
+	Currency        money.Currency            `json:"currency"`
+	Kind            FeeKind                   `json:"kind"`
+	Flat            *money.Money              `json:"flat,omitempty"`
+	Rate            string                    `json:"rate,omitempty"`
+	Tiers           []FeeTier                 `json:"tiers,omitempty"`
+	Min             *money.Money              `json:"min,omitempty"`
+	Max             *money.Money              `json:"max,omitempty"`
+	Enabled         bool                      `json:"enabled"`
+	CreatedAt       time.Time                 `json:"created_at"`
+	UpdatedAt       time.Time                 `json:"updated_at"`
+}
+
+// FeeCharge links a fee to the transaction it was charged for. ID is also the
+// ID of the fee transaction that records it.
+type FeeCharge struct {
+	ID            string      `json:"id"`
+	TransactionID string      `json:"transaction_id"`
+	AccountID     string      `json:"account_id"`
+	ScheduleID    string      `json:"schedule_id"`
+	Amount        money.Money `json:"amount"`
+	Description   string      `json:"description"`
+	CreatedAt     time.Time   `json:"created_at"`
+}
+
+type FeeRepository interface {
+	CreateSchedule(schedule *FeeSchedule) error
+	GetSchedule(id string) (*FeeSchedule, error)
+	UpdateSchedule(schedule *FeeSchedule) error
+	DeleteSchedule(id string) error
+	ListSchedules() ([]*FeeSchedule, error)
+	// ListApplicableSchedules returns the enabled schedules for the
+	// transaction type that cover the account's type and currency
+	ListApplicableSchedules(account *Account, transactionType constants.TransactionType) ([]*FeeSchedule, error)
+	RecordCharge(charge *FeeCharge) error
+	// GetCharge returns the fee charged for a transaction, or nil if none was
+	GetCharge(transactionID string) (*FeeCharge, error)
+}
+
+// Fees are charged on money leaving an account at the customer's request
+func IsFeeableType(transactionType constants.TransactionType) bool {
+	return transactionType == constants.TransactionTypeWithdrawal ||
+		transactionType == constants.TransactionTypeTransfer
+}
+
+func (k FeeKind) Valid() bool {
+	switch k {
+	case FeeKindFlat, FeeKindPercentage, FeeKindTiered:
+		return true
+	}
+	return false
+}
+
+// Reads a fee rate: a decimal fraction from 0 up to, but not including, 1
+func parseFeeRate(s string) (*big.Rat, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return new(big.Rat), nil
+	}
+	rate, ok := new(big.Rat).SetString(s)
+	if !ok || strings.ContainsAny(s, "/eE") || rate.Sign() < 0 || rate.Cmp(big.NewRat(1, 1)) >= 0 {
+		return nil, fmt.Errorf("%w: rate %q must be a fraction from 0 to below 1", ErrInvalidFeeSchedule, s)
+	}
+	return rate, nil
+}
+
+// Validate checks the schedule is complete for its kind and that every
+// amount is in its currency
+func (s *FeeSchedule) Validate() error {
+	if s.Name == "" {
+		return fmt.Errorf("%w: name is required", ErrInvalidFeeSchedule)
+	}
+	if !IsFeeableType(s.TransactionType) {
+		return fmt.Errorf("%w: fees cannot be charged on %q transactions", ErrInvalidFeeSchedule, s.TransactionType)
+	}
+	if s.AccountType != "" && !s.AccountType.Valid() {
+		return fmt.Errorf("%w: unsupported account type %q", ErrInvalidFeeSchedule, s.AccountType)
+	}
+	if !s.Currency.Valid() {
+		return fmt.Errorf("%w: unsupported currency %q", ErrInvalidFeeSchedule, s.Currency)
+	}
+	if !s.Kind.Valid() {
+		return fmt.Errorf("%w: unsupported kind %q", ErrInvalidFeeSchedule, s.Kind)
+	}
+
+	amounts := []*money.Money{s.Flat, s.Min, s.Max}
+	switch s.Kind {
+	case FeeKindFlat:
+		if s.Flat == nil || s.Rate != "" || len(s.Tiers) > 0 {
+			return fmt.Errorf("%w: flat fees need flat and nothing else", ErrInvalidFeeSchedule)
+		}
+	case FeeKindPercentage:
+		if s.Rate == "" || s.Flat != nil || len(s.Tiers) > 0 {
+			return fmt.Errorf("%w: percentage fees need rate and nothing else", ErrInvalidFeeSchedule)
+		}
+	case FeeKindTiered:
+		if len(s.Tiers) == 0 || s.Flat != nil || s.Rate != "" {
+			return fmt.Errorf("%w: tiered fees need tiers and nothing else", ErrInvalidFeeSchedule)
+		}
+		for i, tier := range s.Tiers {
+			last := i == len(s.Tiers)-1
+			if (tier.UpTo == nil) != last {
+				return fmt.Errorf("%w: every tier but the last needs up_to", ErrInvalidFeeSchedule)
+			}
+			if i > 0 && !last {
+				if ordered, err := s.Tiers[i-1].UpTo.LessThan(*tier.UpTo); err == nil && !ordered {
+					return fmt.Errorf("%w: tiers must be in increasing up_to order", ErrInvalidFeeSchedule)
+				}
+			}
+			if _, err := parseFeeRate(tier.Rate); err != nil {
+				return err
+			}
+			amounts = append(amounts, tier.UpTo, tier.Flat)
+		}
+	}
+	if _, err := parseFeeRate(s.Rate); err != nil {
+		return err
+	}
+
+	for _, amount := range amounts {
+		if amount == nil {
+			continue
+		}
+		if amount.Currency != s.Currency {
+			return fmt.Errorf("%w: amounts must be in %s", ErrInvalidFeeSchedule, s.Currency)
+		}
+		if amount.IsNegative() {
+			return fmt.Errorf("%w: amounts must not be negative", ErrInvalidFeeSchedule)
+		}
+	}
+	if s.Min != nil && s.Max != nil {
+		if inverted, _ := s.Max.LessThan(*s.Min); inverted {
+			return fmt.Errorf("%w: min is above max", ErrInvalidFeeSchedule)
+		}
+	}
+	return nil
+}
+
+// Applies reports whether the schedule prices transactions of this type on the account
+func (s *FeeSchedule) Applies(account *Account, transactionType constants.TransactionType) bool {
+	return s.Enabled &&
+		s.TransactionType == transactionType &&
+		(s.AccountType == "" || s.AccountType == account.Type) &&
+		s.Currency == account.Currency
+}
+
+// Calculate prices amount, rounding half to even to minor units and then
+// applying Min and Max
+func (s *FeeSchedule) Calculate(amount money.Money) (money.Money, error) {
+	if amount.Currency != s.Currency {
+		return money.Money{}, money.ErrCurrencyMismatch
+	}
+
+	var flat *money.Money
+	rate := s.Rate
+	switch s.Kind {
+	case FeeKindFlat:
+		flat = s.Flat
+	case FeeKindTiered:
+		tier, err := s.tierFor(amount)
+		if err != nil {
+			return money.Money{}, err
+		}
+		flat, rate = tier.Flat, tier.Rate
+	}
+
+	parsed, err := parseFeeRate(rate)
+	if err != nil {
+		return money.Money{}, err
+	}
+	fee, err := amount.Mul(parsed, money.RoundHalfEven)
+	if err != nil {
+		return money.Money{}, err
+	}
+	if flat != nil {
+		if fee, err = fee.Add(*flat); err != nil {
+			return money.Money{}, err
+		}
+	}
+
+	if s.Min != nil {
+		if below, _ := fee.LessThan(*s.Min); below {
+			fee = *s.Min
+		}
+	}
+	if s.Max != nil {
+		if above, _ := s.Max.LessThan(fee); above {
+			fee = *s.Max
+		}
+	}
+	return fee, nil
+}
+
+func (s *FeeSchedule) tierFor(amount money.Money) (*FeeTier, error) {
+	for i := range s.Tiers {
+		tier := &s.Tiers[i]
+		if tier.UpTo == nil {
+			return tier, nil
+		}
+		above, err := tier.UpTo.LessThan(amount)
+		if err != nil {
+			return nil, err
+		}
+		if !above {
+			return tier, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: no tier covers %s", ErrInvalidFeeSchedule, amount)
+}
+
+// NewFeeCharge prices the transaction with the most specific schedule that
+// applies: one for the account's type wins over one for every type. It
+// returns nil when the account's fees are waived or the fee comes to zero.
+func NewFeeCharge(schedules []*FeeSchedule, account *Account, transaction *Transaction) (*FeeCharge, error) {
+	if account.FeesWaived || !IsFeeableType(transaction.Type) {
+		return nil, nil
+	}
+
+	var chosen *FeeSchedule
+	for _, schedule := range schedules {
+		if !schedule.Applies(account, transaction.Type) {
+			continue
+		}
+		if chosen == nil || (chosen.AccountType == "" && schedule.AccountType != "") {
+			chosen = schedule
+		}
+	}
+	if chosen == nil {
+		return nil, nil
+	}
+
+	amount, err := chosen.Calculate(transaction.Amount)
+	if err != nil {
+		return nil, err
+	}
+	if !amount.IsPositive() {
+		return nil, nil
+	}
+	return &FeeCharge{
+		TransactionID: transaction.ID,
+		AccountID:     transaction.AccountID,
+		ScheduleID:    chosen.ID,
+		Amount:        amount,
+		Description:   fmt.Sprintf("%s on %s %s", chosen.Name, transaction.Type, transaction.ID),
+	}, nil
+}
+
+// Transaction is the completed fee transaction that records the charge
+func (c *FeeCharge) Transaction() *Transaction {
+	return &Transaction{
+		ID:          c.ID,
+		AccountID:   c.AccountID,
+		Type:        constants.TransactionTypeFee,
+		Amount:      c.Amount,
+		FeeOf:       c.TransactionID,
+		Status:      constants.TransactionStatusCompleted,
+		Description: c.Description,
+		CreatedAt:   c.CreatedAt,
+		UpdatedAt:   c.CreatedAt,
+		StatusHistory: []StatusChange{
+			{To: constants.TransactionStatusCompleted, At: c.CreatedAt},
+		},
+	}
+}
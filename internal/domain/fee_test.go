@@ -0,0 +1,97 @@
+package domain
+
+import (
+	"testing"
+
+	"banking-ledger/internal/constants"
+	"banking-ledger/internal/money"
+)
+
+func usd(s string) *money.Money {
+	m := money.MustParse(s, money.USD)
+	return &m
+}
+
+func TestFeeScheduleCalculate(t *testing.T) {
+	tests := []struct {
+		name     string
+		schedule FeeSchedule
+		amount   string
+		want     string
+	}{
+		{"flat", FeeSchedule{Kind: FeeKindFlat, Flat: usd("2.50")}, "100.00", "2.50"},
+		{"percentage rounds half to even", FeeSchedule{Kind: FeeKindPercentage, Rate: "0.015"}, "101.00", "1.52"},
+		{"percentage below min", FeeSchedule{Kind: FeeKindPercentage, Rate: "0.01", Min: usd("1.00")}, "20.00", "1.00"},
+		{"percentage above max", FeeSchedule{Kind: FeeKindPercentage, Rate: "0.01", Max: usd("25.00")}, "5000.00", "25.00"},
+		{"first tier", FeeSchedule{Kind: FeeKindTiered, Tiers: []FeeTier{
+			{UpTo: usd("1000.00"), Flat: usd("1.00")},
+			{Flat: usd("0.50"), Rate: "0.001"},
+		}}, "1000.00", "1.00"},
+		{"last tier", FeeSchedule{Kind: FeeKindTiered, Tiers: []FeeTier{
+			{UpTo: usd("1000.00"), Flat: usd("1.00")},
+			{Flat: usd("0.50"), Rate: "0.001"},
+		}}, "2500.00", "3.00"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.schedule.Currency = money.USD
+			got, err := tt.schedule.Calculate(money.MustParse(tt.amount, money.USD))
+			if err != nil {
+				t.Fatalf("Calculate() returned an error: %v", err)
+			}
+			if got.String() != tt.want {
+				t.Errorf("Calculate(%s) = %s, want %s", tt.amount, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewFeeChargePrefersAccountTypeSchedule(t *testing.T) {
+	schedules := []*FeeSchedule{
+		{ID: "any", Name: "Withdrawal fee", TransactionType: constants.TransactionTypeWithdrawal,
+			Currency: money.USD, Kind: FeeKindFlat, Flat: usd("3.00"), Enabled: true},
+		{ID: "savings", Name: "Savings withdrawal fee", TransactionType: constants.TransactionTypeWithdrawal,
+			AccountType: AccountTypeSavings, Currency: money.USD, Kind: FeeKindFlat, Flat: usd("5.00"), Enabled: true},
+	}
+	account := &Account{ID: "acc-1", Type: AccountTypeSavings, Currency: money.USD}
+	withdrawal := &Transaction{ID: "txn-1", AccountID: "acc-1", Type: constants.TransactionTypeWithdrawal, Amount: *usd("50.00")}
+
+	charge, err := NewFeeCharge(schedules, account, withdrawal)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if charge == nil || charge.ScheduleID != "savings" || charge.Amount.String() != "5.00" {
+		t.Errorf("charge = %+v, want 5.00 from the savings schedule", charge)
+	}
+
+	account.FeesWaived = true
+	if charge, _ := NewFeeCharge(schedules, account, withdrawal); charge != nil {
+		t.Errorf("charge = %+v on a waived account, want none", charge)
+	}
+}
+
+func TestFeeScheduleValidate(t *testing.T) {
+	valid := FeeSchedule{Name: "Transfer fee", TransactionType: constants.TransactionTypeTransfer,
+		Currency: money.USD, Kind: FeeKindPercentage, Rate: "0.01", Min: usd("1.00"), Max: usd("10.00")}
+	if err := valid.Validate(); err != nil {
+		t.Errorf("Validate() = %v, want nil", err)
+	}
+
+	invalid := map[string]func(s *FeeSchedule){
+		"deposit":         func(s *FeeSchedule) { s.TransactionType = constants.TransactionTypeDeposit },
+		"rate of one":     func(s *FeeSchedule) { s.Rate = "1" },
+		"min above max":   func(s *FeeSchedule) { s.Min = usd("20.00") },
+		"other currency":  func(s *FeeSchedule) { m := money.MustParse("1.00", money.EUR); s.Min = &m },
+		"flat on percent": func(s *FeeSchedule) { s.Flat = usd("1.00") },
+	}
+	for name, mutate := range invalid {
+		t.Run(name, func(t *testing.T) {
+			schedule := valid
+			mutate(&schedule)
+			if err := schedule.Validate(); err == nil {
+				t.Error("Validate() = nil, want an error")
+			}
+		})
+	}
+}
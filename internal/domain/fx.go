@@ -0,0 +1,73 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"banking-ledger/internal/money"
+)
+
+var ErrFXRateNotFound = errors.New("no exchange rate for currency pair")
+
+// FXRate says that one unit of From buys Rate units of To. Transactions keep
+// a copy of the rate they were converted at, so later rate changes never
+// alter how an existing transaction is posted.
+type FXRate struct {
+	From money.Currency `json:"from" bson:"from"`
+	To   money.Currency `json:"to" bson:"to"`
+	Rate string         `json:"rate" bson:"rate"` // exact decimal, e.g. "1.0842"
+	AsOf time.Time      `json:"as_of" bson:"as_of"`
+}
+
+// FXRateProvider quotes the rate for converting between two currencies
+type FXRateProvider interface {
+	GetRate(from, to money.Currency) (*FXRate, error)
+}
+
+// FXRateRepository is the locally stored rate table
+type FXRateRepository interface {
+	FXRateProvider
+	// SetRate inserts the pair or replaces its current rate
+	SetRate(rate *FXRate) error
+	ListRates() ([]*FXRate, error)
+}
+
+// ParseRate reads a positive decimal exchange rate exactly
+func ParseRate(s string) (*big.Rat, error) {
+	s = strings.TrimSpace(s)
+	if s == "" || strings.ContainsAny(s, "/eE") {
+		return nil, fmt.Errorf("invalid exchange rate: %q", s)
+	}
+	rate, ok := new(big.Rat).SetString(s)
+	if !ok || rate.Sign() <= 0 {
+		return nil, fmt.Errorf("invalid exchange rate: %q", s)
+	}
+	return rate, nil
+}
+
+// Validate checks the pair and the rate
+func (r *FXRate) Validate() error {
+	if !r.From.Valid() || !r.To.Valid() {
+		return fmt.Errorf("unsupported currency pair: %s/%s", r.From, r.To)
+	}
+	if r.From == r.To {
+		return fmt.Errorf("exchange rate needs two different currencies")
+	}
+	_, err := ParseRate(r.Rate)
+	return err
+}
+
+// Convert values amount, which must be in From, in To
+func (r *FXRate) Convert(amount money.Money) (money.Money, error) {
+	if amount.Currency != r.From {
+		return money.Money{}, money.ErrCurrencyMismatch
+	}
+	rate, err := ParseRate(r.Rate)
+	if err != nil {
+		return money.Money{}, err
+	}
+	return amount.Convert(r.To, rate, money.RoundHalfEven)
+}
@@ -0,0 +1,80 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"banking-ledger/internal/money"
+)
+
+type HoldStatus string
+
+const (
+	HoldStatusActive   HoldStatus = "active"
+	HoldStatusCaptured HoldStatus = "captured"
+	HoldStatusVoided   HoldStatus = "voided"
+	HoldStatusExpired  HoldStatus = "expired"
+)
+
+var (
+	ErrHoldNotFound       = errors.New("hold not found")
+	ErrHoldNotActive      = errors.New("hold is no longer active")
+	ErrCaptureExceedsHold = errors.New("capture amount exceeds the held amount")
+)
+
+// Hold reserves part of an account's balance without posting anything to the
+// journal. While active it lowers the available balance; capturing it posts
+// the captured amount and releases the rest, and voiding or expiry releases
+// all of it.
+type Hold struct {
+	ID             string       `json:"id"`
+	AccountID      string       `json:"account_id"`
+	Amount         money.Money  `json:"amount"`
+	CapturedAmount *money.Money `json:"captured_amount,omitempty"`
+	Status         HoldStatus   `json:"status"`
+	Description    string       `json:"description"`
+	TransactionID  string       `json:"transaction_id,omitempty"` // capture transaction
+	ExpiresAt      time.Time    `json:"expires_at"`
+	CreatedAt      time.Time    `json:"created_at"`
+	UpdatedAt      time.Time    `json:"updated_at"`
+}
+
+type HoldRepository interface {
+	// Create stores an active hold and adds it to the account's held balance
+	Create(hold *Hold) error
+	GetByID(id string) (*Hold, error)
+	// LockForUpdate reads the hold and locks its row until the surrounding
+	// UnitOfWork ends
+	LockForUpdate(id string) (*Hold, error)
+	ListByAccountID(accountID string) ([]*Hold, error)
+	// ListExpired returns active holds whose expiry is before now
+	ListExpired(now time.Time, limit int) ([]*Hold, error)
+	// Release moves an active hold to a final status and takes its amount
+	// off the account's held balance
+	Release(id string, status HoldStatus, captured *money.Money, transactionID string) error
+}
+
+// CheckCapture reports whether amount can be captured from the hold at now
+func (h *Hold) CheckCapture(amount money.Money, now time.Time) error {
+	if h.Status != HoldStatusActive || !now.Before(h.ExpiresAt) {
+		return fmt.Errorf("%w: hold %s is %s", ErrHoldNotActive, h.ID, h.displayStatus(now))
+	}
+	exceeds, err := h.Amount.LessThan(amount)
+	if err != nil {
+		return err
+	}
+	if exceeds {
+		return ErrCaptureExceedsHold
+	}
+	return nil
+}
+
+// An active hold past its expiry is reported as expired even before the
+// expiry sweep has released it
+func (h *Hold) displayStatus(now time.Time) HoldStatus {
+	if h.Status == HoldStatusActive && !now.Before(h.ExpiresAt) {
+		return HoldStatusExpired
+	}
+	return h.Status
+}
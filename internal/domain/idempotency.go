@@ -0,0 +1,35 @@
+package domain
+
+import (
+	"errors"
+	"time"
+)
+
+var (
+	ErrIdempotencyKeyReused   = errors.New("idempotency key was already used with a different request")
+	ErrIdempotencyKeyInFlight = errors.New("a request with this idempotency key is still being processed")
+)
+
+// Response stored against a client-supplied Idempotency-Key. A record without
+// CompletedAt is a reservation held by the request currently running.
+type IdempotencyRecord struct {
+	Key          string
+	Fingerprint  string
+	StatusCode   int
+	ResponseBody []byte
+	CreatedAt    time.Time
+	CompletedAt  *time.Time
+}
+
+type IdempotencyRepository interface {
+	// Reserve claims the key for a new request. If the key is already taken
+	// the existing record is returned and nothing is written.
+	Reserve(record *IdempotencyRecord) (existing *IdempotencyRecord, err error)
+	Complete(key string, statusCode int, body []byte) error
+	// Release drops an unfinished reservation so the request can be retried
+	Release(key string) error
+	// TakeOver moves an unfinished reservation still created at claimedAt to
+	// record.CreatedAt, reporting false if another request got there first
+	TakeOver(record *IdempotencyRecord, claimedAt time.Time) (bool, error)
+	DeleteOlderThan(cutoff time.Time) error
+}
@@ -0,0 +1,31 @@
+package domain
+
+import "time"
+
+type InboxOutcome string
+
+const (
+	InboxOutcomeApplied  InboxOutcome = "applied"
+	InboxOutcomeRejected InboxOutcome = "rejected"
+)
+
+// Record of a transaction the processor has already decided. It is written in
+// the same database transaction as the postings, so a redelivered message can
+// tell whether its money has already moved.
+type InboxRecord struct {
+	TransactionID string
+	Outcome       InboxOutcome
+	FailureCode   FailureCode // set when rejected
+	Reason        string
+	ProcessedAt   time.Time
+}
+
+type InboxRepository interface {
+	// Claim records the transaction as being processed. It returns nil if
+	// this is the first time, or the earlier record if it was already decided.
+	// A concurrent claim of the same ID blocks until the first one commits.
+	Claim(transactionID string) (*InboxRecord, error)
+	// SetOutcome records the decision; failure is nil unless it was rejected
+	SetOutcome(transactionID string, outcome InboxOutcome, failure *TransactionFailure) error
+	Get(transactionID string) (*InboxRecord, error)
+}
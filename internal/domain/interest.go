@@ -0,0 +1,218 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"banking-ledger/internal/money"
+)
+
+type InterestMethod string
+
+const (
+	InterestMethodSimple   InterestMethod = "simple"   // earned on the ledger balance only
+	InterestMethodCompound InterestMethod = "compound" // earned on the balance plus interest accrued but not yet posted
+)
+
+// DayCount decides what fraction of the annual rate one day earns
+type DayCount string
+
+const (
+	DayCountActual365 DayCount = "ACT/365"
+	DayCountActual360 DayCount = "ACT/360"
+	DayCount30360     DayCount = "30/360" // every month counts as 30 days, spread evenly over its days
+)
+
+// Accruals are kept to this many decimal places of the account's currency's
+// major unit; only the monthly total is rounded to minor units
+const AccrualPlaces = 10
+
+var (
+	ErrInterestPlanNotFound = errors.New("interest plan not found")
+	ErrInvalidInterestPlan  = errors.New("invalid interest plan")
+)
+
+// InterestPlan is a rate accounts can be put on. AnnualRate is a fraction:
+// "0.035" is 3.5% a year.
+type InterestPlan struct {
+	ID         string         `json:"id"`
+	Name       string         `json:"name"`
+	AnnualRate string         `json:"annual_rate"`
+	Method     InterestMethod `json:"method"`
+	DayCount   DayCount       `json:"day_count"`
+	Enabled    bool           `json:"enabled"`
+	CreatedAt  time.Time      `json:"created_at"`
+	UpdatedAt  time.Time      `json:"updated_at"`
+}
+
+// InterestAccrual is the interest one account earned on one day. Amount is
+// exact to AccrualPlaces decimals. PostingID is set once the accrual has been
+// paid out by a monthly posting.
+type InterestAccrual struct {
+	ID        string         `json:"id"`
+	AccountID string         `json:"account_id"`
+	PlanID    string         `json:"plan_id"`
+	Date      time.Time      `json:"date"`
+	Basis     string         `json:"basis"`
+	Rate      string         `json:"rate"`
+	Amount    string         `json:"amount"`
+	Currency  money.Currency `json:"currency"`
+	PostingID string         `json:"posting_id,omitempty"`
+	CreatedAt time.Time      `json:"created_at"`
+}
+
+// InterestPosting pays out one account's accruals for one month. ID is also
+// the ID of the interest transaction, so re-running a month finds the same one.
+type InterestPosting struct {
+	ID        string      `json:"id"`
+	AccountID string      `json:"account_id"`
+	Period    string      `json:"period"` // YYYY-MM
+	Amount    money.Money `json:"amount"`
+	CreatedAt time.Time   `json:"created_at"`
+}
+
+type InterestRepository interface {
+	CreatePlan(plan *InterestPlan) error
+	GetPlan(id string) (*InterestPlan, error)
+	UpdatePlan(plan *InterestPlan) error
+	ListPlans() ([]*InterestPlan, error)
+	// SetAccountPlan puts the account on a plan, or takes it off when planID is empty
+	SetAccountPlan(accountID, planID string) error
+	// ListPlanAccounts returns every account on a plan
+	ListPlanAccounts() ([]*Account, error)
+	// AddAccrual stores the accrual unless the account already has one for
+	// that date, and reports whether it was stored
+	AddAccrual(accrual *InterestAccrual) (bool, error)
+	// ListAccruals returns the account's accruals dated from from up to, but
+	// not including, to, oldest first
+	ListAccruals(accountID string, from, to time.Time) ([]*InterestAccrual, error)
+	// ListAccruingAccounts returns the accounts with unposted accruals
+	// dated from from up to, but not including, to
+	ListAccruingAccounts(from, to time.Time) ([]string, error)
+	// GetPosting returns the account's posting for the period, or nil if there is none
+	GetPosting(accountID, period string) (*InterestPosting, error)
+	// CreatePosting stores the posting and marks the accruals it pays out
+	CreatePosting(posting *InterestPosting, accrualIDs []string) error
+}
+
+func (m InterestMethod) Valid() bool {
+	return m == InterestMethodSimple || m == InterestMethodCompound
+}
+
+func (d DayCount) Valid() bool {
+	switch d {
+	case DayCountActual365, DayCountActual360, DayCount30360:
+		return true
+	}
+	return false
+}
+
+// Reads an annual rate: a decimal fraction from 0 up to, but not including, 1
+func parseInterestRate(s string) (*big.Rat, error) {
+	s = strings.TrimSpace(s)
+	rate, ok := new(big.Rat).SetString(s)
+	if !ok || strings.ContainsAny(s, "/eE") || rate.Sign() < 0 || rate.Cmp(big.NewRat(1, 1)) >= 0 {
+		return nil, fmt.Errorf("%w: annual_rate %q must be a fraction from 0 to below 1", ErrInvalidInterestPlan, s)
+	}
+	return rate, nil
+}
+
+func (p *InterestPlan) Validate() error {
+	if p.Name == "" {
+		return fmt.Errorf("%w: name is required", ErrInvalidInterestPlan)
+	}
+	if !p.Method.Valid() {
+		return fmt.Errorf("%w: unsupported method %q", ErrInvalidInterestPlan, p.Method)
+	}
+	if !p.DayCount.Valid() {
+		return fmt.Errorf("%w: unsupported day_count %q", ErrInvalidInterestPlan, p.DayCount)
+	}
+	_, err := parseInterestRate(p.AnnualRate)
+	return err
+}
+
+// DayFraction is the part of a year that date counts for
+func (d DayCount) DayFraction(date time.Time) *big.Rat {
+	switch d {
+	case DayCountActual360:
+		return big.NewRat(1, 360)
+	case DayCount30360:
+		return big.NewRat(30, int64(DaysInMonth(date))*360)
+	default:
+		return big.NewRat(1, 365)
+	}
+}
+
+// DailyInterest is the exact interest basis earns on date; nothing is earned
+// on a balance that is zero or below
+func (p *InterestPlan) DailyInterest(basis *big.Rat, date time.Time) (*big.Rat, error) {
+	rate, err := parseInterestRate(p.AnnualRate)
+	if err != nil {
+		return nil, err
+	}
+	if basis.Sign() <= 0 {
+		return new(big.Rat), nil
+	}
+	interest := new(big.Rat).Mul(basis, rate)
+	return interest.Mul(interest, p.DayCount.DayFraction(date)), nil
+}
+
+// AccrualBasis is the balance the plan earns on: the ledger balance, plus
+// the interest accrued since the last posting for compound plans
+func (p *InterestPlan) AccrualBasis(balance money.Money, unposted []*InterestAccrual) (*big.Rat, error) {
+	basis := balance.Rat()
+	if p.Method != InterestMethodCompound {
+		return basis, nil
+	}
+	accrued, err := SumAccruals(unposted)
+	if err != nil {
+		return nil, err
+	}
+	return basis.Add(basis, accrued), nil
+}
+
+// SumAccruals adds up the exact amounts of the accruals
+func SumAccruals(accruals []*InterestAccrual) (*big.Rat, error) {
+	total := new(big.Rat)
+	for _, accrual := range accruals {
+		amount, ok := new(big.Rat).SetString(accrual.Amount)
+		if !ok {
+			return nil, fmt.Errorf("invalid amount %q on interest accrual %s", accrual.Amount, accrual.ID)
+		}
+		total.Add(total, amount)
+	}
+	return total, nil
+}
+
+// RoundInterest rounds an exact amount of major units half to even to the
+// currency's minor units
+func RoundInterest(amount *big.Rat, currency money.Currency) (money.Money, error) {
+	one, err := money.Parse("1", currency)
+	if err != nil {
+		return money.Money{}, err
+	}
+	return one.Mul(amount, money.RoundHalfEven)
+}
+
+// Day truncates t to midnight UTC
+func Day(t time.Time) time.Time {
+	year, month, day := t.UTC().Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+func DaysInMonth(t time.Time) int {
+	return time.Date(t.Year(), t.Month()+1, 0, 0, 0, 0, 0, time.UTC).Day()
+}
+
+// IsMonthEnd reports whether t falls on the last day of its month
+func IsMonthEnd(t time.Time) bool {
+	return t.Day() == DaysInMonth(t)
+}
+
+// InterestPeriod names the month t falls in, e.g. "2024-03"
+func InterestPeriod(t time.Time) string {
+	return t.Format("2006-01")
+}
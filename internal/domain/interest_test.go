@@ -0,0 +1,144 @@
+package domain
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"banking-ledger/internal/money"
+)
+
+func TestInterestPlanDailyInterest(t *testing.T) {
+	march := time.Date(2024, time.March, 15, 0, 0, 0, 0, time.UTC)
+	february := time.Date(2024, time.February, 15, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		rate     string
+		dayCount DayCount
+		basis    string
+		date     time.Time
+		want     string
+	}{
+		{"ACT/365", "0.0365", DayCountActual365, "1000", march, "0.1000000000"},
+		{"ACT/360", "0.036", DayCountActual360, "1000", march, "0.1000000000"},
+		{"30/360 in a 31 day month", "0.036", DayCount30360, "1000", march, "0.0967741935"},
+		{"30/360 in a leap February", "0.036", DayCount30360, "1000", february, "0.1034482759"},
+		{"zero balance", "0.05", DayCountActual365, "0", march, "0.0000000000"},
+		{"overdrawn balance", "0.05", DayCountActual365, "-250", march, "0.0000000000"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plan := InterestPlan{AnnualRate: tt.rate, Method: InterestMethodSimple, DayCount: tt.dayCount}
+			basis, _ := new(big.Rat).SetString(tt.basis)
+			got, err := plan.DailyInterest(basis, tt.date)
+			if err != nil {
+				t.Fatalf("DailyInterest returned an error: %v", err)
+			}
+			if got.FloatString(AccrualPlaces) != tt.want {
+				t.Errorf("DailyInterest = %s, want %s", got.FloatString(AccrualPlaces), tt.want)
+			}
+		})
+	}
+}
+
+func TestThirtyThreeSixtyEarnsAThirtyDayMonth(t *testing.T) {
+	plan := InterestPlan{AnnualRate: "0.036", Method: InterestMethodSimple, DayCount: DayCount30360}
+	basis := big.NewRat(1000, 1)
+
+	for _, month := range []time.Month{time.February, time.April, time.July} {
+		total := new(big.Rat)
+		first := time.Date(2023, month, 1, 0, 0, 0, 0, time.UTC)
+		for day := first; day.Month() == month; day = day.AddDate(0, 0, 1) {
+			interest, err := plan.DailyInterest(basis, day)
+			if err != nil {
+				t.Fatalf("DailyInterest returned an error: %v", err)
+			}
+			total.Add(total, interest)
+		}
+		if total.Cmp(big.NewRat(3, 1)) != 0 {
+			t.Errorf("%s earned %s, want 3", month, total.FloatString(AccrualPlaces))
+		}
+	}
+}
+
+func TestAccrualBasis(t *testing.T) {
+	balance := money.MustParse("1000.00", money.USD)
+	unposted := []*InterestAccrual{{Amount: "0.1369863014"}, {Amount: "0.1369863014"}}
+
+	tests := []struct {
+		method InterestMethod
+		want   string
+	}{
+		{InterestMethodSimple, "1000.0000000000"},
+		{InterestMethodCompound, "1000.2739726028"},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.method), func(t *testing.T) {
+			plan := InterestPlan{AnnualRate: "0.05", Method: tt.method, DayCount: DayCountActual365}
+			basis, err := plan.AccrualBasis(balance, unposted)
+			if err != nil {
+				t.Fatalf("AccrualBasis returned an error: %v", err)
+			}
+			if basis.FloatString(AccrualPlaces) != tt.want {
+				t.Errorf("basis = %s, want %s", basis.FloatString(AccrualPlaces), tt.want)
+			}
+		})
+	}
+}
+
+func TestRoundInterestHalfToEven(t *testing.T) {
+	tests := []struct {
+		amount string
+		want   string
+	}{
+		{"0.125", "0.12"},
+		{"0.135", "0.14"},
+		{"4.1095890410", "4.11"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.amount, func(t *testing.T) {
+			amount, _ := new(big.Rat).SetString(tt.amount)
+			got, err := RoundInterest(amount, money.USD)
+			if err != nil {
+				t.Fatalf("RoundInterest returned an error: %v", err)
+			}
+			if got.String() != tt.want {
+				t.Errorf("RoundInterest = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestInterestPlanValidate(t *testing.T) {
+	valid := InterestPlan{Name: "Savings", AnnualRate: "0.035", Method: InterestMethodCompound, DayCount: DayCountActual365}
+	if err := valid.Validate(); err != nil {
+		t.Fatalf("Validate returned an error for a valid plan: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		modify func(p *InterestPlan)
+	}{
+		{"missing name", func(p *InterestPlan) { p.Name = "" }},
+		{"negative rate", func(p *InterestPlan) { p.AnnualRate = "-0.01" }},
+		{"rate of 100%", func(p *InterestPlan) { p.AnnualRate = "1" }},
+		{"rate as a percentage", func(p *InterestPlan) { p.AnnualRate = "3.5" }},
+		{"unknown method", func(p *InterestPlan) { p.Method = "continuous" }},
+		{"unknown day count", func(p *InterestPlan) { p.DayCount = "ACT/ACT" }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plan := valid
+			tt.modify(&plan)
+			if err := plan.Validate(); !errors.Is(err, ErrInvalidInterestPlan) {
+				t.Errorf("Validate = %v, want ErrInvalidInterestPlan", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,257 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"banking-ledger/internal/constants"
+	"banking-ledger/internal/money"
+)
+
+type PostingDirection string
+
+const (
+	Debit  PostingDirection = "debit"
+	Credit PostingDirection = "credit"
+)
+
+// Ledger accounts that belong to the bank rather than a customer. Customer
+// ledger accounts share the ID of the account they back.
+const (
+	SystemAccountCashIn         = "system:cash_in"
+	SystemAccountCashOut        = "system:cash_out"
+	SystemAccountOpeningBalance = "system:opening_balance"
+	// Credited by captured card holds, owed to the card network
+	SystemAccountCardSettlement = "system:card_settlement"
+	// Credited with every fee charged
+	SystemAccountFeeRevenue = "system:fee_revenue"
+	// Debited with every interest payment
+	SystemAccountInterestExpense = "system:interest_expense"
+	// Holds each currency leg of a cross-currency transfer
+	SystemAccountFXConversion = "system:fx_conversion"
+	// Absorbs the rounding left over when an amount is converted
+	SystemAccountFXGainLoss = "system:fx_gain_loss"
+)
+
+var ErrUnbalancedEntry = errors.New("journal entry does not balance")
+
+func IsSystemAccount(accountID string) bool {
+	return strings.HasPrefix(accountID, "system:")
+}
+
+// Posting moves Amount (always positive) onto one side of a ledger account.
+// Customer accounts are liabilities of the bank: a credit raises their
+// balance and a debit lowers it.
+type Posting struct {
+	ID        string           `json:"id"`
+	EntryID   string           `json:"entry_id"`
+	AccountID string           `json:"account_id"`
+	Direction PostingDirection `json:"direction"`
+	Amount    money.Money      `json:"amount"`
+	CreatedAt time.Time        `json:"created_at"`
+}
+
+type JournalEntry struct {
+	ID            string    `json:"id"`
+	TransactionID string    `json:"transaction_id,omitempty"`
+	Description   string    `json:"description"`
+	Postings      []Posting `json:"postings"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// AccountPosting is a posting to one account along with the entry it
+// belongs to
+type AccountPosting struct {
+	Posting
+	TransactionID    string `json:"transaction_id,omitempty"`
+	EntryDescription string `json:"entry_description"`
+}
+
+// Debit and credit totals for one currency; they must be equal
+type TrialBalanceLine struct {
+	Currency money.Currency `json:"currency"`
+	Debits   money.Money    `json:"debits"`
+	Credits  money.Money    `json:"credits"`
+	Balanced bool           `json:"balanced"`
+}
+
+type JournalRepository interface {
+	// Post stores the entry, applies it to the cached balances of the
+	// customer accounts it touches and records each new balance in their
+	// balance history
+	Post(entry *JournalEntry) error
+	// ComputeBalance derives an account's balance from its postings alone
+	ComputeBalance(accountID string, currency money.Currency) (money.Money, error)
+	// ComputeBalanceAsOf is ComputeBalance over the postings made before asOf
+	ComputeBalanceAsOf(accountID string, currency money.Currency, asOf time.Time) (money.Money, error)
+	// OpeningBalance sums the account's postings from entries not made for a
+	// transaction: the initial amount it was opened with
+	OpeningBalance(accountID string, currency money.Currency) (money.Money, error)
+	// ListAccountPostings returns the account's postings made in [from, to),
+	// oldest first
+	ListAccountPostings(accountID string, from, to time.Time) ([]AccountPosting, error)
+	// BalanceHistoryAt returns the account's last balance history entry at or
+	// before asOf, or nil if its balance had not moved by then
+	BalanceHistoryAt(accountID string, asOf time.Time) (*BalanceHistoryEntry, error)
+	// ListBalanceHistory returns one page of the account's balance history
+	ListBalanceHistory(accountID string, filter BalanceHistoryFilter) (*BalanceHistoryPage, error)
+	// ListStatementEntries returns the account's balance history recorded in
+	// [from, to), in the order it was recorded
+	ListStatementEntries(accountID string, from, to time.Time) ([]StatementEntry, error)
+	// RecomputeBalances overwrites every cached balance with the posted one
+	RecomputeBalances() error
+	TrialBalance() ([]TrialBalanceLine, error)
+}
+
+// NewTransactionEntry builds the journal entry that applies a transaction
+func NewTransactionEntry(transaction *Transaction) (*JournalEntry, error) {
+	var debit, credit string
+	switch transaction.Type {
+	case constants.TransactionTypeDeposit:
+		debit, credit = SystemAccountCashIn, transaction.AccountID
+	case constants.TransactionTypeWithdrawal:
+		debit, credit = transaction.AccountID, SystemAccountCashOut
+	case constants.TransactionTypeCapture:
+		debit, credit = transaction.AccountID, SystemAccountCardSettlement
+	case constants.TransactionTypeFee:
+		debit, credit = transaction.AccountID, SystemAccountFeeRevenue
+	case constants.TransactionTypeInterest:
+		debit, credit = SystemAccountInterestExpense, transaction.AccountID
+	case constants.TransactionTypeTransfer:
+		if transaction.FXRate != nil {
+			return newFXTransferEntry(transaction)
+		}
+		debit, credit = transaction.AccountID, transaction.ToAccountID
+	case constants.TransactionTypeReversal, constants.TransactionTypeRefund:
+		return nil, fmt.Errorf("%s %s needs its original transaction; use NewReversalEntry", transaction.Type, transaction.ID)
+	default:
+		return nil, fmt.Errorf("unknown transaction type: %s", transaction.Type)
+	}
+
+	entry := &JournalEntry{
+		TransactionID: transaction.ID,
+		Description:   transaction.Description,
+		Postings: []Posting{
+			{AccountID: debit, Direction: Debit, Amount: transaction.Amount},
+			{AccountID: credit, Direction: Credit, Amount: transaction.Amount},
+		},
+	}
+	return entry, entry.Validate()
+}
+
+// A cross-currency transfer balances each currency through the FX conversion
+// account. The credited amount was rounded when it was converted, so it is
+// valued back in the source currency and whatever the customer paid on top
+// of (or short of) that value goes to FX gain/loss.
+func newFXTransferEntry(transaction *Transaction) (*JournalEntry, error) {
+	if transaction.ToAmount == nil {
+		return nil, fmt.Errorf("cross-currency transfer %s has no credited amount", transaction.ID)
+	}
+	credited := *transaction.ToAmount
+	if transaction.FXRate.From != transaction.Amount.Currency || transaction.FXRate.To != credited.Currency {
+		return nil, money.ErrCurrencyMismatch
+	}
+	rate, err := ParseRate(transaction.FXRate.Rate)
+	if err != nil {
+		return nil, err
+	}
+	settled, err := credited.Convert(transaction.Amount.Currency, new(big.Rat).Inv(rate), money.RoundHalfEven)
+	if err != nil {
+		return nil, err
+	}
+	remainder, err := transaction.Amount.Sub(settled)
+	if err != nil {
+		return nil, err
+	}
+
+	postings := []Posting{
+		{AccountID: transaction.AccountID, Direction: Debit, Amount: transaction.Amount},
+		{AccountID: SystemAccountFXConversion, Direction: Credit, Amount: settled},
+	}
+	if remainder.IsPositive() {
+		postings = append(postings, Posting{AccountID: SystemAccountFXGainLoss, Direction: Credit, Amount: remainder})
+	} else if remainder.IsNegative() {
+		postings = append(postings, Posting{AccountID: SystemAccountFXGainLoss, Direction: Debit, Amount: remainder.Abs()})
+	}
+	postings = append(postings,
+		Posting{AccountID: SystemAccountFXConversion, Direction: Debit, Amount: credited},
+		Posting{AccountID: transaction.ToAccountID, Direction: Credit, Amount: credited},
+	)
+
+	entry := &JournalEntry{
+		TransactionID: transaction.ID,
+		Description:   transaction.Description,
+		Postings:      postings,
+	}
+	return entry, entry.Validate()
+}
+
+// NewOpeningEntry credits a new account with its initial balance
+func NewOpeningEntry(account *Account) *JournalEntry {
+	return &JournalEntry{
+		Description: "Opening balance",
+		Postings: []Posting{
+			{AccountID: SystemAccountOpeningBalance, Direction: Debit, Amount: account.Balance},
+			{AccountID: account.ID, Direction: Credit, Amount: account.Balance},
+		},
+	}
+}
+
+// Validate checks that every amount is positive and that debits equal
+// credits in each currency
+func (e *JournalEntry) Validate() error {
+	if len(e.Postings) < 2 {
+		return fmt.Errorf("%w: needs at least two postings", ErrUnbalancedEntry)
+	}
+
+	totals := make(map[money.Currency]int64)
+	for _, p := range e.Postings {
+		if !p.Amount.IsPositive() {
+			return fmt.Errorf("posting to %s must have a positive amount", p.AccountID)
+		}
+		switch p.Direction {
+		case Debit:
+			totals[p.Amount.Currency] += p.Amount.Amount
+		case Credit:
+			totals[p.Amount.Currency] -= p.Amount.Amount
+		default:
+			return fmt.Errorf("unknown posting direction: %s", p.Direction)
+		}
+	}
+
+	for currency, total := range totals {
+		if total != 0 {
+			return fmt.Errorf("%w: %s is off by %s", ErrUnbalancedEntry, currency, money.New(total, currency))
+		}
+	}
+	return nil
+}
+
+// BalanceChanges returns the signed effect of the entry on each customer
+// account it touches
+func (e *JournalEntry) BalanceChanges() (map[string]money.Money, error) {
+	changes := make(map[string]money.Money)
+	for _, p := range e.Postings {
+		if IsSystemAccount(p.AccountID) {
+			continue
+		}
+		delta := p.Amount
+		if p.Direction == Debit {
+			delta = delta.Neg()
+		}
+		current, ok := changes[p.AccountID]
+		if !ok {
+			changes[p.AccountID] = delta
+			continue
+		}
+		sum, err := current.Add(delta)
+		if err != nil {
+			return nil, err
+		}
+		changes[p.AccountID] = sum
+	}
+	return changes, nil
+}
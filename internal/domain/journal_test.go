@@ -0,0 +1,147 @@
+package domain
+
+import (
+	"errors"
+	"testing"
+
+	"banking-ledger/internal/constants"
+	"banking-ledger/internal/money"
+)
+
+func TestNewTransactionEntry(t *testing.T) {
+	amount := money.MustParse("25.00", money.USD)
+	tests := []struct {
+		name        string
+		transaction Transaction
+		wantChanges map[string]int64
+	}{
+		{
+			name:        "Deposit credits the account",
+			transaction: Transaction{ID: "t1", AccountID: "a", Type: constants.TransactionTypeDeposit, Amount: amount},
+			wantChanges: map[string]int64{"a": 2500},
+		},
+		{
+			name:        "Withdrawal debits the account",
+			transaction: Transaction{ID: "t2", AccountID: "a", Type: constants.TransactionTypeWithdrawal, Amount: amount},
+			wantChanges: map[string]int64{"a": -2500},
+		},
+		{
+			name:        "Transfer moves between accounts",
+			transaction: Transaction{ID: "t3", AccountID: "a", ToAccountID: "b", Type: constants.TransactionTypeTransfer, Amount: amount},
+			wantChanges: map[string]int64{"a": -2500, "b": 2500},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entry, err := NewTransactionEntry(&tt.transaction)
+			if err != nil {
+				t.Fatalf("NewTransactionEntry() returned an error: %v", err)
+			}
+			changes, err := entry.BalanceChanges()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(changes) != len(tt.wantChanges) {
+				t.Fatalf("BalanceChanges() = %v, want %v", changes, tt.wantChanges)
+			}
+			for accountID, want := range tt.wantChanges {
+				if changes[accountID].Amount != want {
+					t.Errorf("change for %s = %d, want %d", accountID, changes[accountID].Amount, want)
+				}
+			}
+		})
+	}
+}
+
+func TestJournalEntryValidate(t *testing.T) {
+	entry := &JournalEntry{
+		Postings: []Posting{
+			{AccountID: SystemAccountCashIn, Direction: Debit, Amount: money.MustParse("10.00", money.USD)},
+			{AccountID: "a", Direction: Credit, Amount: money.MustParse("9.99", money.USD)},
+		},
+	}
+	if err := entry.Validate(); !errors.Is(err, ErrUnbalancedEntry) {
+		t.Errorf("Validate() error = %v, want %v", err, ErrUnbalancedEntry)
+	}
+}
+
+func TestNewFXTransferEntry(t *testing.T) {
+	rate := &FXRate{From: money.USD, To: money.EUR, Rate: "0.92371"}
+	amount := money.MustParse("1234.57", money.USD)
+	credited, err := rate.Convert(amount)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := credited.String(); got != "1140.38" {
+		t.Fatalf("converted amount = %s, want 1140.38", got)
+	}
+
+	transfer := Transaction{
+		ID:          "t4",
+		AccountID:   "a",
+		ToAccountID: "b",
+		Type:        constants.TransactionTypeTransfer,
+		Amount:      amount,
+		ToAmount:    &credited,
+		FXRate:      rate,
+	}
+	entry, err := NewTransactionEntry(&transfer)
+	if err != nil {
+		t.Fatalf("NewTransactionEntry() returned an error: %v", err)
+	}
+
+	// 1140.38 EUR is worth 1234.56 USD at this rate; the cent left over is the bank's
+	var gain *Posting
+	for i := range entry.Postings {
+		if entry.Postings[i].AccountID == SystemAccountFXGainLoss {
+			gain = &entry.Postings[i]
+		}
+	}
+	if gain == nil || gain.Direction != Credit || gain.Amount.String() != "0.01" {
+		t.Errorf("fx gain/loss posting = %+v, want a 0.01 USD credit", gain)
+	}
+
+	changes, err := entry.BalanceChanges()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if changes["a"] != amount.Neg() || changes["b"] != credited {
+		t.Errorf("BalanceChanges() = %v", changes)
+	}
+}
+
+func TestNewReversalEntry(t *testing.T) {
+	original := &Transaction{
+		ID:          "t5",
+		AccountID:   "a",
+		ToAccountID: "b",
+		Type:        constants.TransactionTypeTransfer,
+		Amount:      money.MustParse("40.00", money.USD),
+		Status:      constants.TransactionStatusCompleted,
+	}
+	refund := &Transaction{
+		ID:         "t6",
+		AccountID:  "a",
+		Type:       constants.TransactionTypeRefund,
+		Amount:     money.MustParse("15.00", money.USD),
+		ReversalOf: original.ID,
+	}
+
+	entry, err := NewReversalEntry(original, refund)
+	if err != nil {
+		t.Fatalf("NewReversalEntry() returned an error: %v", err)
+	}
+	changes, err := entry.BalanceChanges()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if changes["a"].Amount != 1500 || changes["b"].Amount != -1500 {
+		t.Errorf("BalanceChanges() = %v, want a +15.00 and b -15.00", changes)
+	}
+
+	refund.Type = constants.TransactionTypeReversal
+	if err := refund.CheckReversible(); !errors.Is(err, ErrNotReversible) {
+		t.Errorf("CheckReversible() on a pending reversal = %v, want %v", err, ErrNotReversible)
+	}
+}
@@ -0,0 +1,243 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"banking-ledger/internal/constants"
+	"banking-ledger/internal/money"
+)
+
+type LimitKind string
+
+const (
+	// Caps the amount of any one transaction
+	LimitKindSingleAmount LimitKind = "single_amount"
+	// Caps the summed amount of transactions within the window
+	LimitKindTotalAmount LimitKind = "total_amount"
+	// Caps the number of transactions within the window
+	LimitKindCount LimitKind = "count"
+)
+
+var (
+	ErrLimitExceeded     = errors.New("limit exceeded")
+	ErrLimitRuleNotFound = errors.New("limit rule not found")
+	ErrInvalidLimitRule  = errors.New("invalid limit rule")
+)
+
+// LimitRule caps one transaction type, either for a single account or for
+// every account of a type. Amount rules only apply to accounts held in the
+// currency of MaxAmount. Windows are rolling: a rule with a one hour window
+// counts the transactions applied in the hour before now.
+type LimitRule struct {
+	ID              string                    `json:"id"`
+	Name            string                    `json:"name"`
+	AccountID       string                    `json:"account_id,omitempty"`
+	AccountType     AccountType               `json:"account_type,omitempty"`
+	TransactionType constants.TransactionType `json:"transaction_type"`
+	Kind            LimitKind                 `json:"kind"`
+	MaxAmount       *money.Money              `json:"max_amount,omitempty"`
+	MaxCount        int                       `json:"max_count,omitempty"`
+	WindowSeconds   int64                     `json:"window_seconds,omitempty"`
+	Enabled         bool                      `json:"enabled"`
+	CreatedAt       time.Time                 `json:"created_at"`
+	UpdatedAt       time.Time                 `json:"updated_at"`
+}
+
+// LimitExceededError names the rule a transaction broke. It matches
+// ErrLimitExceeded with errors.Is.
+type LimitExceededError struct {
+	RuleID   string      `json:"rule_id"`
+	RuleName string      `json:"rule_name"`
+	Kind     LimitKind   `json:"kind"`
+	Limit    string      `json:"limit"`
+	Used     string      `json:"used"`
+	Window   string      `json:"window,omitempty"`
+	Amount   money.Money `json:"amount"`
+}
+
+func (e *LimitExceededError) Error() string {
+	message := fmt.Sprintf("limit exceeded: rule %q allows %s", e.RuleName, e.Limit)
+	if e.Window != "" {
+		message += " per " + e.Window
+	}
+	if e.Used != "" {
+		message += ", " + e.Used + " already used"
+	}
+	return message
+}
+
+func (e *LimitExceededError) Unwrap() error {
+	return ErrLimitExceeded
+}
+
+// Count and total of the transactions applied to an account within a window
+type LimitUsage struct {
+	Count int
+	Total money.Money
+}
+
+type LimitUsageReader interface {
+	// Usage sums the transactions of one type applied to the account since
+	// the given time; only amounts in currency are totalled
+	Usage(accountID string, transactionType constants.TransactionType, currency money.Currency, since time.Time) (LimitUsage, error)
+}
+
+type LimitRepository interface {
+	LimitUsageReader
+	CreateRule(rule *LimitRule) error
+	GetRule(id string) (*LimitRule, error)
+	UpdateRule(rule *LimitRule) error
+	DeleteRule(id string) error
+	ListRules() ([]*LimitRule, error)
+	// ListApplicableRules returns the enabled rules for the transaction type
+	// that name the account or its type
+	ListApplicableRules(account *Account, transactionType constants.TransactionType) ([]*LimitRule, error)
+	// RecordUsage counts an applied transaction towards its account's limits
+	RecordUsage(transaction *Transaction) error
+}
+
+func (k LimitKind) Valid() bool {
+	switch k {
+	case LimitKindSingleAmount, LimitKindTotalAmount, LimitKindCount:
+		return true
+	}
+	return false
+}
+
+// Only money moved at a customer's request is limited; captures count as
+// their own type, and reversals and refunds are never limited
+func IsLimitedType(transactionType constants.TransactionType) bool {
+	switch transactionType {
+	case constants.TransactionTypeDeposit,
+		constants.TransactionTypeWithdrawal,
+		constants.TransactionTypeTransfer,
+		constants.TransactionTypeCapture:
+		return true
+	}
+	return false
+}
+
+func (r *LimitRule) Window() time.Duration {
+	return time.Duration(r.WindowSeconds) * time.Second
+}
+
+// Validate checks the rule is complete and consistent for its kind
+func (r *LimitRule) Validate() error {
+	if r.Name == "" {
+		return fmt.Errorf("%w: name is required", ErrInvalidLimitRule)
+	}
+	if (r.AccountID == "") == (r.AccountType == "") {
+		return fmt.Errorf("%w: set exactly one of account_id and account_type", ErrInvalidLimitRule)
+	}
+	if r.AccountType != "" && !r.AccountType.Valid() {
+		return fmt.Errorf("%w: unsupported account type %q", ErrInvalidLimitRule, r.AccountType)
+	}
+	if !IsLimitedType(r.TransactionType) {
+		return fmt.Errorf("%w: transactions of type %q cannot be limited", ErrInvalidLimitRule, r.TransactionType)
+	}
+	if !r.Kind.Valid() {
+		return fmt.Errorf("%w: unsupported kind %q", ErrInvalidLimitRule, r.Kind)
+	}
+
+	switch r.Kind {
+	case LimitKindCount:
+		if r.MaxCount <= 0 || r.MaxAmount != nil {
+			return fmt.Errorf("%w: count rules need a positive max_count and no max_amount", ErrInvalidLimitRule)
+		}
+	default:
+		if r.MaxAmount == nil || !r.MaxAmount.IsPositive() || r.MaxCount != 0 {
+			return fmt.Errorf("%w: amount rules need a positive max_amount and no max_count", ErrInvalidLimitRule)
+		}
+	}
+	if r.Kind == LimitKindSingleAmount && r.WindowSeconds != 0 {
+		return fmt.Errorf("%w: single_amount rules take no window_seconds", ErrInvalidLimitRule)
+	}
+	if r.Kind != LimitKindSingleAmount && r.WindowSeconds <= 0 {
+		return fmt.Errorf("%w: %s rules need a positive window_seconds", ErrInvalidLimitRule, r.Kind)
+	}
+	return nil
+}
+
+// Applies reports whether the rule covers transactions of this type on the account
+func (r *LimitRule) Applies(account *Account, transactionType constants.TransactionType) bool {
+	if !r.Enabled || r.TransactionType != transactionType {
+		return false
+	}
+	if r.AccountID != "" && r.AccountID != account.ID {
+		return false
+	}
+	if r.AccountType != "" && r.AccountType != account.Type {
+		return false
+	}
+	return r.MaxAmount == nil || r.MaxAmount.Currency == account.Currency
+}
+
+// CheckLimits evaluates every rule that applies to the transaction against
+// the usage already recorded for its account, returning a
+// *LimitExceededError for the first rule it would break
+func CheckLimits(rules []*LimitRule, usage LimitUsageReader, account *Account, transaction *Transaction, now time.Time) error {
+	for _, rule := range rules {
+		if !rule.Applies(account, transaction.Type) {
+			continue
+		}
+		if err := rule.check(usage, account, transaction, now); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *LimitRule) check(usage LimitUsageReader, account *Account, transaction *Transaction, now time.Time) error {
+	exceeded := &LimitExceededError{
+		RuleID:   r.ID,
+		RuleName: r.Name,
+		Kind:     r.Kind,
+		Amount:   transaction.Amount,
+	}
+	if r.WindowSeconds > 0 {
+		exceeded.Window = r.Window().String()
+	}
+
+	if r.Kind == LimitKindSingleAmount {
+		over, err := r.MaxAmount.LessThan(transaction.Amount)
+		if err != nil {
+			return err
+		}
+		if over {
+			exceeded.Limit = r.MaxAmount.String()
+			return exceeded
+		}
+		return nil
+	}
+
+	used, err := usage.Usage(account.ID, transaction.Type, account.Currency, now.Add(-r.Window()))
+	if err != nil {
+		return err
+	}
+
+	if r.Kind == LimitKindCount {
+		if used.Count+1 > r.MaxCount {
+			exceeded.Limit = fmt.Sprintf("%d transactions", r.MaxCount)
+			exceeded.Used = fmt.Sprintf("%d", used.Count)
+			return exceeded
+		}
+		return nil
+	}
+
+	total, err := used.Total.Add(transaction.Amount)
+	if err != nil {
+		return err
+	}
+	over, err := r.MaxAmount.LessThan(total)
+	if err != nil {
+		return err
+	}
+	if over {
+		exceeded.Limit = r.MaxAmount.String()
+		exceeded.Used = used.Total.String()
+		return exceeded
+	}
+	return nil
+}
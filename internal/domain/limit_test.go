@@ -0,0 +1,68 @@
+package domain
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"banking-ledger/internal/constants"
+	"banking-ledger/internal/money"
+)
+
+type fixedUsage LimitUsage
+
+func (u fixedUsage) Usage(string, constants.TransactionType, money.Currency, time.Time) (LimitUsage, error) {
+	return LimitUsage(u), nil
+}
+
+func TestCheckLimits(t *testing.T) {
+	maxSingle := money.MustParse("50000.00", money.USD)
+	maxDaily := money.MustParse("10000.00", money.USD)
+	rules := []*LimitRule{
+		{ID: "single", Name: "single deposit", AccountType: AccountTypeChecking, TransactionType: constants.TransactionTypeDeposit,
+			Kind: LimitKindSingleAmount, MaxAmount: &maxSingle, Enabled: true},
+		{ID: "daily", Name: "daily withdrawals", AccountType: AccountTypeChecking, TransactionType: constants.TransactionTypeWithdrawal,
+			Kind: LimitKindTotalAmount, MaxAmount: &maxDaily, WindowSeconds: 86400, Enabled: true},
+	}
+	account := &Account{ID: "acc-1", Type: AccountTypeChecking, Currency: money.USD}
+	used := fixedUsage{Count: 3, Total: money.MustParse("9000.00", money.USD)}
+
+	tests := []struct {
+		transactionType constants.TransactionType
+		amount          string
+		brokenRule      string
+	}{
+		{constants.TransactionTypeDeposit, "50000.00", ""},
+		{constants.TransactionTypeDeposit, "50000.01", "single"},
+		{constants.TransactionTypeWithdrawal, "1000.00", ""},
+		{constants.TransactionTypeWithdrawal, "1000.01", "daily"},
+		{constants.TransactionTypeTransfer, "99999.00", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.transactionType)+" "+tt.amount, func(t *testing.T) {
+			transaction := &Transaction{AccountID: account.ID, Type: tt.transactionType, Amount: money.MustParse(tt.amount, money.USD)}
+			err := CheckLimits(rules, used, account, transaction, time.Now())
+
+			var exceeded *LimitExceededError
+			switch {
+			case tt.brokenRule == "" && err != nil:
+				t.Errorf("CheckLimits() = %v, want nil", err)
+			case tt.brokenRule != "" && !errors.As(err, &exceeded):
+				t.Errorf("CheckLimits() = %v, want a LimitExceededError", err)
+			case tt.brokenRule != "" && (exceeded.RuleID != tt.brokenRule || !errors.Is(err, ErrLimitExceeded)):
+				t.Errorf("CheckLimits() broke rule %s, want %s", exceeded.RuleID, tt.brokenRule)
+			}
+		})
+	}
+}
+
+func TestLimitRuleSkipsOtherCurrencies(t *testing.T) {
+	maxAmount := money.MustParse("100.00", money.USD)
+	rule := &LimitRule{AccountType: AccountTypeChecking, TransactionType: constants.TransactionTypeDeposit,
+		Kind: LimitKindSingleAmount, MaxAmount: &maxAmount, Enabled: true}
+
+	if rule.Applies(&Account{Type: AccountTypeChecking, Currency: money.EUR}, constants.TransactionTypeDeposit) {
+		t.Error("USD amount rule applies to a EUR account")
+	}
+}
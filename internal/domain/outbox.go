@@ -0,0 +1,27 @@
+package domain
+
+import "time"
+
+// Queue message waiting to be published for a transaction. It is stored in
+// the same document as the pending transaction, so the two are written
+// atomically and a crash can never leave one without the other.
+type OutboxMessage struct {
+	TransactionID string
+	Payload       []byte
+	CreatedAt     time.Time
+	PublishedAt   *time.Time
+	Attempts      int
+	LastError     string
+	Requeues      int // times the pending sweep has put it back
+}
+
+type OutboxRepository interface {
+	ListUnpublished(limit int) ([]*OutboxMessage, error)
+	MarkPublished(transactionID string) error
+	MarkFailed(transactionID string, reason string) error
+	// Get returns the transaction's message, or nil if it never had one
+	Get(transactionID string) (*OutboxMessage, error)
+	// Requeue puts the transaction's message back in the outbox, behind those
+	// already waiting, for the relay to publish again
+	Requeue(transactionID string, payload []byte) error
+}
@@ -0,0 +1,87 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"banking-ledger/internal/money"
+)
+
+type AccountType string
+
+const (
+	AccountTypeChecking AccountType = "checking" // may have an overdraft limit
+	AccountTypeSavings  AccountType = "savings"  // never goes below zero
+	// Drawn down below zero as far as its credit limit; deposits repay it
+	AccountTypeCreditLine AccountType = "credit_line"
+)
+
+var ErrInvalidOverdraftLimit = errors.New("invalid overdraft limit")
+
+func (t AccountType) Valid() bool {
+	switch t {
+	case AccountTypeChecking, AccountTypeSavings, AccountTypeCreditLine:
+		return true
+	}
+	return false
+}
+
+// Audit record of a change to an account's overdraft or credit limit
+type OverdraftLimitChange struct {
+	ID        string      `json:"id"`
+	AccountID string      `json:"account_id"`
+	OldLimit  money.Money `json:"old_limit"`
+	NewLimit  money.Money `json:"new_limit"`
+	Reason    string      `json:"reason"`
+	Actor     string      `json:"actor"`
+	CreatedAt time.Time   `json:"created_at"`
+}
+
+// ValidateOverdraftLimit checks that limit suits an account of this type
+func (t AccountType) ValidateOverdraftLimit(limit money.Money) error {
+	if limit.IsNegative() {
+		return fmt.Errorf("%w: must not be negative", ErrInvalidOverdraftLimit)
+	}
+	switch t {
+	case AccountTypeSavings:
+		if !limit.IsZero() {
+			return fmt.Errorf("%w: savings accounts cannot be overdrawn", ErrInvalidOverdraftLimit)
+		}
+	case AccountTypeCreditLine:
+		if !limit.IsPositive() {
+			return fmt.Errorf("%w: credit lines need a positive credit limit", ErrInvalidOverdraftLimit)
+		}
+	}
+	return nil
+}
+
+// SpendableBalance is the available balance plus the overdraft limit: the
+// most that can be debited right now
+func (a *Account) SpendableBalance() (money.Money, error) {
+	available, err := a.AvailableBalance()
+	if err != nil {
+		return money.Money{}, err
+	}
+	if a.OverdraftLimit.Currency == "" {
+		return available, nil
+	}
+	return available.Add(a.OverdraftLimit)
+}
+
+// CheckFunds is the one rule for whether amount may be debited from the
+// account; the API pre-checks and the processor both apply it
+func (a *Account) CheckFunds(amount money.Money) error {
+	spendable, err := a.SpendableBalance()
+	if err != nil {
+		return err
+	}
+	insufficient, err := spendable.LessThan(amount)
+	if err != nil {
+		return err
+	}
+	if insufficient {
+		return ErrInsufficientFunds
+	}
+	return nil
+}
@@ -0,0 +1,49 @@
+package domain
+
+import (
+	"errors"
+	"testing"
+
+	"banking-ledger/internal/money"
+)
+
+func TestValidateOverdraftLimit(t *testing.T) {
+	tests := []struct {
+		accountType AccountType
+		limit       string
+		valid       bool
+	}{
+		{AccountTypeChecking, "0.00", true},
+		{AccountTypeChecking, "250.00", true},
+		{AccountTypeChecking, "-1.00", false},
+		{AccountTypeSavings, "0.00", true},
+		{AccountTypeSavings, "10.00", false},
+		{AccountTypeCreditLine, "1000.00", true},
+		{AccountTypeCreditLine, "0.00", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.accountType)+" "+tt.limit, func(t *testing.T) {
+			err := tt.accountType.ValidateOverdraftLimit(money.MustParse(tt.limit, money.USD))
+			if (err == nil) != tt.valid || (err != nil && !errors.Is(err, ErrInvalidOverdraftLimit)) {
+				t.Errorf("ValidateOverdraftLimit() = %v, want valid %v", err, tt.valid)
+			}
+		})
+	}
+}
+
+func TestCheckFundsCountsHeldFundsAndOverdraft(t *testing.T) {
+	account := &Account{
+		Currency:       money.USD,
+		Balance:        money.MustParse("20.00", money.USD),
+		Held:           money.MustParse("15.00", money.USD),
+		OverdraftLimit: money.MustParse("30.00", money.USD),
+	}
+
+	if err := account.CheckFunds(money.MustParse("35.00", money.USD)); err != nil {
+		t.Errorf("CheckFunds(35.00) = %v, want nil", err)
+	}
+	if err := account.CheckFunds(money.MustParse("35.01", money.USD)); !errors.Is(err, ErrInsufficientFunds) {
+		t.Errorf("CheckFunds(35.01) = %v, want ErrInsufficientFunds", err)
+	}
+}
@@ -0,0 +1,97 @@
+package domain
+
+import (
+	"fmt"
+	"time"
+
+	"banking-ledger/internal/constants"
+	"banking-ledger/internal/money"
+)
+
+type DiscrepancyKind string
+
+const (
+	// The cached or posted balance differs from the one the transaction log
+	// implies
+	DiscrepancyBalanceMismatch DiscrepancyKind = "balance_mismatch"
+	// The log's status disagrees with the processor's recorded decision
+	DiscrepancyStatusMismatch DiscrepancyKind = "status_mismatch"
+	// Completed in the log, but the processor never applied it
+	DiscrepancyNotApplied DiscrepancyKind = "not_applied"
+	// Still pending, with no decision, long after it was queued
+	DiscrepancyStuckPending DiscrepancyKind = "stuck_pending"
+)
+
+// Discrepancy is one disagreement between PostgreSQL and the transaction log.
+// Expected and Actual are balances or statuses depending on the kind.
+type Discrepancy struct {
+	Kind          DiscrepancyKind `json:"kind"`
+	AccountID     string          `json:"account_id,omitempty"`
+	TransactionID string          `json:"transaction_id,omitempty"`
+	Expected      string          `json:"expected,omitempty"`
+	Actual        string          `json:"actual,omitempty"`
+	Detail        string          `json:"detail,omitempty"`
+	Repaired      bool            `json:"repaired"`
+}
+
+type ReconciliationReport struct {
+	StartedAt           time.Time     `json:"started_at"`
+	FinishedAt          time.Time     `json:"finished_at"`
+	AccountsChecked     int           `json:"accounts_checked"`
+	TransactionsChecked int           `json:"transactions_checked"`
+	Discrepancies       []Discrepancy `json:"discrepancies"`
+}
+
+// ExpectedBalance replays the account's completed transactions in the log on
+// top of its opening balance. originals must hold the transaction each
+// reversal or refund among them undoes.
+func ExpectedBalance(accountID string, opening money.Money, transactions []*Transaction, originals map[string]*Transaction) (money.Money, error) {
+	balance := opening
+	for _, transaction := range transactions {
+		if transaction.Status != constants.TransactionStatusCompleted {
+			continue
+		}
+
+		var entry *JournalEntry
+		var err error
+		if transaction.ReversalOf != "" {
+			original, ok := originals[transaction.ReversalOf]
+			if !ok {
+				return money.Money{}, fmt.Errorf("original transaction %s of %s not found", transaction.ReversalOf, transaction.ID)
+			}
+			entry, err = NewReversalEntry(original, transaction)
+		} else {
+			entry, err = NewTransactionEntry(transaction)
+		}
+		if err != nil {
+			return money.Money{}, err
+		}
+
+		changes, err := entry.BalanceChanges()
+		if err != nil {
+			return money.Money{}, err
+		}
+		if change, ok := changes[accountID]; ok {
+			if balance, err = balance.Add(change); err != nil {
+				return money.Money{}, err
+			}
+		}
+	}
+	return balance, nil
+}
+
+// DecidedStatus is the status the processor's decision gives a transaction
+func (r *InboxRecord) DecidedStatus() constants.TransactionStatus {
+	if r.Outcome == InboxOutcomeRejected {
+		return constants.TransactionStatusFailed
+	}
+	return constants.TransactionStatusCompleted
+}
+
+// Failure is why the processor rejected the transaction, or nil if it did not
+func (r *InboxRecord) Failure() *TransactionFailure {
+	if r.Outcome != InboxOutcomeRejected {
+		return nil
+	}
+	return &TransactionFailure{Code: r.FailureCode, Message: r.Reason}
+}
@@ -0,0 +1,48 @@
+package domain
+
+import (
+	"testing"
+
+	"banking-ledger/internal/constants"
+	"banking-ledger/internal/money"
+)
+
+func TestExpectedBalance(t *testing.T) {
+	usd := func(amount string) money.Money { return money.MustParse(amount, money.USD) }
+	completed := constants.TransactionStatusCompleted
+
+	deposit := &Transaction{ID: "t1", AccountID: "acc-1", Type: constants.TransactionTypeDeposit, Amount: usd("50.00"), Status: completed}
+	transactions := []*Transaction{
+		deposit,
+		{ID: "t2", AccountID: "acc-1", ToAccountID: "acc-2", Type: constants.TransactionTypeTransfer, Amount: usd("30.00"), Status: completed},
+		{ID: "t3", AccountID: "acc-2", ToAccountID: "acc-1", Type: constants.TransactionTypeTransfer, Amount: usd("5.00"), Status: completed},
+		{ID: "t4", AccountID: "acc-1", Type: constants.TransactionTypeFee, Amount: usd("1.25"), FeeOf: "t2", Status: completed},
+		{ID: "t5", AccountID: "acc-1", Type: constants.TransactionTypeRefund, Amount: usd("20.00"), ReversalOf: "t1", Status: completed},
+		// Neither a failed nor a pending transaction moved any money
+		{ID: "t6", AccountID: "acc-1", Type: constants.TransactionTypeWithdrawal, Amount: usd("500.00"), Status: constants.TransactionStatusFailed},
+		{ID: "t7", AccountID: "acc-1", Type: constants.TransactionTypeWithdrawal, Amount: usd("10.00"), Status: constants.TransactionStatusPending},
+	}
+	originals := map[string]*Transaction{"t1": deposit}
+
+	got, err := ExpectedBalance("acc-1", usd("100.00"), transactions, originals)
+	if err != nil {
+		t.Fatalf("ExpectedBalance returned an error: %v", err)
+	}
+	// 100 + 50 - 30 + 5 - 1.25 - 20
+	if got.String() != "103.75" {
+		t.Errorf("ExpectedBalance = %s, want 103.75", got)
+	}
+
+	if _, err := ExpectedBalance("acc-1", usd("100.00"), transactions, nil); err == nil {
+		t.Error("expected an error when a refund's original is missing")
+	}
+}
+
+func TestInboxRecordDecidedStatus(t *testing.T) {
+	if got := (&InboxRecord{Outcome: InboxOutcomeApplied}).DecidedStatus(); got != constants.TransactionStatusCompleted {
+		t.Errorf("applied decides %s, want completed", got)
+	}
+	if got := (&InboxRecord{Outcome: InboxOutcomeRejected}).DecidedStatus(); got != constants.TransactionStatusFailed {
+		t.Errorf("rejected decides %s, want failed", got)
+	}
+}
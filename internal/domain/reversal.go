@@ -0,0 +1,76 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+
+	"banking-ledger/internal/constants"
+	"banking-ledger/internal/money"
+)
+
+var (
+	ErrNotReversible           = errors.New("transaction cannot be reversed")
+	ErrReversalExceedsOriginal = errors.New("reversals would exceed the original amount")
+)
+
+// ReversalRepository tracks how much of each transaction has been reversed or
+// refunded, so concurrent reversals of the same original cannot overshoot it
+type ReversalRepository interface {
+	// Add locks the original's running total and adds amount to it, or
+	// returns ErrReversalExceedsOriginal if the total would pass limit
+	Add(originalID string, amount, limit money.Money) error
+}
+
+func IsReversalType(t constants.TransactionType) bool {
+	return t == constants.TransactionTypeReversal || t == constants.TransactionTypeRefund
+}
+
+// CheckReversible reports whether original may be reversed or refunded at all
+func (t *Transaction) CheckReversible() error {
+	if t.Status != constants.TransactionStatusCompleted {
+		return fmt.Errorf("%w: transaction %s is %s", ErrNotReversible, t.ID, t.Status)
+	}
+	if IsReversalType(t.Type) {
+		return fmt.Errorf("%w: transaction %s is itself a %s", ErrNotReversible, t.ID, t.Type)
+	}
+	return nil
+}
+
+// NewReversalEntry builds the entry that undoes reversal.Amount of the
+// original transaction by posting the original's legs in the other direction
+func NewReversalEntry(original, reversal *Transaction) (*JournalEntry, error) {
+	if reversal.ReversalOf != original.ID {
+		return nil, fmt.Errorf("transaction %s does not reverse %s", reversal.ID, original.ID)
+	}
+	if reversal.Amount.Currency != original.Amount.Currency {
+		return nil, money.ErrCurrencyMismatch
+	}
+	originalEntry, err := NewTransactionEntry(original)
+	if err != nil {
+		return nil, err
+	}
+
+	full := reversal.Amount == original.Amount
+	// A cross-currency leg was converted at a rate, so only the exact
+	// opposite of the whole entry can be posted
+	if !full && len(originalEntry.Postings) != 2 {
+		return nil, fmt.Errorf("%w: partial refunds of cross-currency transfers are not supported", ErrNotReversible)
+	}
+
+	entry := &JournalEntry{
+		TransactionID: reversal.ID,
+		Description:   reversal.Description,
+	}
+	for _, p := range originalEntry.Postings {
+		direction := Credit
+		if p.Direction == Credit {
+			direction = Debit
+		}
+		amount := p.Amount
+		if !full {
+			amount = reversal.Amount
+		}
+		entry.Postings = append(entry.Postings, Posting{AccountID: p.AccountID, Direction: direction, Amount: amount})
+	}
+	return entry, entry.Validate()
+}
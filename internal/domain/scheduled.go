@@ -0,0 +1,189 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"banking-ledger/internal/constants"
+	"banking-ledger/internal/cron"
+	"banking-ledger/internal/money"
+)
+
+type ScheduleStatus string
+
+const (
+	ScheduleStatusActive    ScheduleStatus = "active"
+	ScheduleStatusPaused    ScheduleStatus = "paused"
+	ScheduleStatusCancelled ScheduleStatus = "cancelled"
+	ScheduleStatusCompleted ScheduleStatus = "completed" // a one-off that has run, or a cron with no runs left
+)
+
+type ExecutionOutcome string
+
+const (
+	ExecutionOutcomePending  ExecutionOutcome = "pending"  // claimed, transaction not yet queued
+	ExecutionOutcomeQueued   ExecutionOutcome = "queued"   // transaction queued for the processor
+	ExecutionOutcomeRejected ExecutionOutcome = "rejected" // transaction refused before queueing
+)
+
+var (
+	ErrScheduledTransactionNotFound = errors.New("scheduled transaction not found")
+	ErrInvalidScheduledTransaction  = errors.New("invalid scheduled transaction")
+	// A run was claimed by another scheduler, or the schedule changed under it
+	ErrExecutionClaimed = errors.New("scheduled run already claimed")
+)
+
+// ScheduledTransaction queues a deposit, withdrawal or transfer once at
+// RunAt, or at every time its Cron expression (UTC) matches. NextRunAt is
+// the next due time and is empty once nothing is left to run.
+type ScheduledTransaction struct {
+	ID          string                    `json:"id"`
+	AccountID   string                    `json:"account_id"`
+	ToAccountID string                    `json:"to_account_id,omitempty"`
+	Type        constants.TransactionType `json:"type"`
+	Amount      money.Money               `json:"amount"`
+	Description string                    `json:"description"`
+	RunAt       *time.Time                `json:"run_at,omitempty"`
+	Cron        string                    `json:"cron,omitempty"`
+	Status      ScheduleStatus            `json:"status"`
+	NextRunAt   *time.Time                `json:"next_run_at,omitempty"`
+	LastRunAt   *time.Time                `json:"last_run_at,omitempty"`
+	CreatedAt   time.Time                 `json:"created_at"`
+	UpdatedAt   time.Time                 `json:"updated_at"`
+}
+
+// ScheduledExecution records one run of a schedule. TransactionID is derived
+// from the schedule and DueAt, so a run is queued at most once however often
+// it is retried. TransactionStatus is filled in from the transaction log
+// when executions are listed.
+type ScheduledExecution struct {
+	ID                string                      `json:"id"`
+	ScheduleID        string                      `json:"schedule_id"`
+	DueAt             time.Time                   `json:"due_at"`
+	TransactionID     string                      `json:"transaction_id"`
+	Outcome           ExecutionOutcome            `json:"outcome"`
+	Error             string                      `json:"error,omitempty"`
+	TransactionStatus constants.TransactionStatus `json:"transaction_status,omitempty"`
+	CreatedAt         time.Time                   `json:"created_at"`
+	UpdatedAt         time.Time                   `json:"updated_at"`
+}
+
+type ScheduledTransactionRepository interface {
+	Create(schedule *ScheduledTransaction) error
+	GetByID(id string) (*ScheduledTransaction, error)
+	ListByAccount(accountID string) ([]*ScheduledTransaction, error)
+	// UpdateState locks the schedule, applies change to it and stores its
+	// status and next run time, so it cannot interleave with ClaimExecution
+	UpdateState(id string, change func(*ScheduledTransaction) error) (*ScheduledTransaction, error)
+	// ListDue returns active schedules whose next run is at or before now
+	ListDue(now time.Time, limit int) ([]*ScheduledTransaction, error)
+	// ClaimExecution records the run due at schedule.NextRunAt and advances
+	// the schedule to next, atomically. It returns ErrExecutionClaimed if
+	// the schedule is no longer active or due at that time.
+	ClaimExecution(schedule *ScheduledTransaction, execution *ScheduledExecution, next *time.Time) error
+	// FinishExecution stores the outcome of a claimed run
+	FinishExecution(execution *ScheduledExecution) error
+	// ListPendingExecutions returns claimed runs whose transaction was
+	// never queued, e.g. because the scheduler stopped in between
+	ListPendingExecutions(limit int) ([]*ScheduledExecution, error)
+	ListExecutions(scheduleID string) ([]*ScheduledExecution, error)
+}
+
+// Only transactions a customer could ask for directly can be scheduled
+func IsSchedulableType(transactionType constants.TransactionType) bool {
+	switch transactionType {
+	case constants.TransactionTypeDeposit,
+		constants.TransactionTypeWithdrawal,
+		constants.TransactionTypeTransfer:
+		return true
+	}
+	return false
+}
+
+// Validate checks the schedule has exactly one of RunAt and Cron and a
+// transaction that could be queued
+func (s *ScheduledTransaction) Validate(now time.Time) error {
+	if !IsSchedulableType(s.Type) {
+		return fmt.Errorf("%w: %q transactions cannot be scheduled", ErrInvalidScheduledTransaction, s.Type)
+	}
+	if (s.Type == constants.TransactionTypeTransfer) != (s.ToAccountID != "") {
+		return fmt.Errorf("%w: to_account_id is required for transfers and only for transfers", ErrInvalidScheduledTransaction)
+	}
+	if s.ToAccountID == s.AccountID {
+		return fmt.Errorf("%w: cannot transfer to the same account", ErrInvalidScheduledTransaction)
+	}
+	if !s.Amount.IsPositive() {
+		return fmt.Errorf("%w: amount must be positive", ErrInvalidScheduledTransaction)
+	}
+	if (s.RunAt == nil) == (s.Cron == "") {
+		return fmt.Errorf("%w: set either run_at or cron", ErrInvalidScheduledTransaction)
+	}
+	if s.RunAt != nil && !s.RunAt.After(now) {
+		return fmt.Errorf("%w: run_at must be in the future", ErrInvalidScheduledTransaction)
+	}
+	if s.Cron != "" {
+		if _, err := cron.Parse(s.Cron); err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidScheduledTransaction, err)
+		}
+	}
+	return nil
+}
+
+// NextAfter is the first run due after t, or nil if the schedule has no
+// more runs
+func (s *ScheduledTransaction) NextAfter(t time.Time) (*time.Time, error) {
+	if s.RunAt != nil {
+		if s.RunAt.After(t) {
+			next := *s.RunAt
+			return &next, nil
+		}
+		return nil, nil
+	}
+
+	expr, err := cron.Parse(s.Cron)
+	if err != nil {
+		return nil, err
+	}
+	next := expr.Next(t.UTC())
+	if next.IsZero() {
+		return nil, nil
+	}
+	return &next, nil
+}
+
+// Pause stops runs until Resume; runs due while paused are skipped
+func (s *ScheduledTransaction) Pause() error {
+	if s.Status != ScheduleStatusActive {
+		return fmt.Errorf("%w: only active schedules can be paused, this one is %s", ErrInvalidScheduledTransaction, s.Status)
+	}
+	s.Status = ScheduleStatusPaused
+	return nil
+}
+
+// Resume restarts a paused schedule from its first run after now
+func (s *ScheduledTransaction) Resume(now time.Time) error {
+	if s.Status != ScheduleStatusPaused {
+		return fmt.Errorf("%w: only paused schedules can be resumed, this one is %s", ErrInvalidScheduledTransaction, s.Status)
+	}
+	next, err := s.NextAfter(now)
+	if err != nil {
+		return err
+	}
+	s.NextRunAt = next
+	s.Status = ScheduleStatusActive
+	if next == nil {
+		s.Status = ScheduleStatusCompleted
+	}
+	return nil
+}
+
+// Cancel ends the schedule for good
+func (s *ScheduledTransaction) Cancel() error {
+	if s.Status == ScheduleStatusCancelled || s.Status == ScheduleStatusCompleted {
+		return fmt.Errorf("%w: schedule is already %s", ErrInvalidScheduledTransaction, s.Status)
+	}
+	s.Status = ScheduleStatusCancelled
+	s.NextRunAt = nil
+	return nil
+}
@@ -0,0 +1,100 @@
+package domain
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"banking-ledger/internal/constants"
+	"banking-ledger/internal/money"
+)
+
+func TestScheduledTransactionValidate(t *testing.T) {
+	now := time.Date(2024, time.January, 15, 12, 0, 0, 0, time.UTC)
+	tomorrow := now.AddDate(0, 0, 1)
+	yesterday := now.AddDate(0, 0, -1)
+	valid := ScheduledTransaction{
+		AccountID:   "acc-1",
+		ToAccountID: "acc-2",
+		Type:        constants.TransactionTypeTransfer,
+		Amount:      money.MustParse("200.00", money.USD),
+		Cron:        "0 9 1 * *",
+	}
+	if err := valid.Validate(now); err != nil {
+		t.Fatalf("Validate returned an error for a valid schedule: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		modify func(s *ScheduledTransaction)
+	}{
+		{"unschedulable type", func(s *ScheduledTransaction) { s.Type = constants.TransactionTypeRefund }},
+		{"transfer without destination", func(s *ScheduledTransaction) { s.ToAccountID = "" }},
+		{"deposit with destination", func(s *ScheduledTransaction) { s.Type = constants.TransactionTypeDeposit }},
+		{"transfer to itself", func(s *ScheduledTransaction) { s.ToAccountID = "acc-1" }},
+		{"zero amount", func(s *ScheduledTransaction) { s.Amount = money.Zero(money.USD) }},
+		{"neither run_at nor cron", func(s *ScheduledTransaction) { s.Cron = "" }},
+		{"both run_at and cron", func(s *ScheduledTransaction) { s.RunAt = &tomorrow }},
+		{"run_at in the past", func(s *ScheduledTransaction) { s.Cron, s.RunAt = "", &yesterday }},
+		{"bad cron", func(s *ScheduledTransaction) { s.Cron = "every monday" }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schedule := valid
+			tt.modify(&schedule)
+			if err := schedule.Validate(now); !errors.Is(err, ErrInvalidScheduledTransaction) {
+				t.Errorf("Validate = %v, want ErrInvalidScheduledTransaction", err)
+			}
+		})
+	}
+}
+
+func TestScheduledTransactionNextAfter(t *testing.T) {
+	now := time.Date(2024, time.January, 15, 12, 0, 0, 0, time.UTC)
+	runAt := now.Add(time.Hour)
+
+	oneOff := ScheduledTransaction{RunAt: &runAt}
+	if next, _ := oneOff.NextAfter(now); next == nil || !next.Equal(runAt) {
+		t.Errorf("one-off NextAfter(now) = %v, want %s", next, runAt)
+	}
+	if next, _ := oneOff.NextAfter(runAt); next != nil {
+		t.Errorf("one-off NextAfter(run_at) = %s, want nil", next)
+	}
+
+	monthly := ScheduledTransaction{Cron: "0 9 1 * *"}
+	want := time.Date(2024, time.February, 1, 9, 0, 0, 0, time.UTC)
+	if next, _ := monthly.NextAfter(now); next == nil || !next.Equal(want) {
+		t.Errorf("monthly NextAfter(now) = %v, want %s", next, want)
+	}
+}
+
+func TestScheduledTransactionLifecycle(t *testing.T) {
+	now := time.Date(2024, time.January, 15, 12, 0, 0, 0, time.UTC)
+	missed := time.Date(2024, time.January, 1, 9, 0, 0, 0, time.UTC)
+	schedule := ScheduledTransaction{Cron: "0 9 1 * *", Status: ScheduleStatusActive, NextRunAt: &missed}
+
+	if err := schedule.Resume(now); !errors.Is(err, ErrInvalidScheduledTransaction) {
+		t.Errorf("Resume on an active schedule = %v, want ErrInvalidScheduledTransaction", err)
+	}
+	if err := schedule.Pause(); err != nil {
+		t.Fatalf("Pause returned an error: %v", err)
+	}
+	if err := schedule.Resume(now); err != nil {
+		t.Fatalf("Resume returned an error: %v", err)
+	}
+	want := time.Date(2024, time.February, 1, 9, 0, 0, 0, time.UTC)
+	if schedule.Status != ScheduleStatusActive || schedule.NextRunAt == nil || !schedule.NextRunAt.Equal(want) {
+		t.Errorf("after Resume status = %s, next = %v, want active at %s", schedule.Status, schedule.NextRunAt, want)
+	}
+
+	if err := schedule.Cancel(); err != nil {
+		t.Fatalf("Cancel returned an error: %v", err)
+	}
+	if schedule.NextRunAt != nil {
+		t.Errorf("cancelled schedule still runs at %s", schedule.NextRunAt)
+	}
+	if err := schedule.Pause(); !errors.Is(err, ErrInvalidScheduledTransaction) {
+		t.Errorf("Pause on a cancelled schedule = %v, want ErrInvalidScheduledTransaction", err)
+	}
+}
@@ -0,0 +1,94 @@
+package domain
+
+import (
+	"errors"
+	"time"
+
+	"banking-ledger/internal/constants"
+	"banking-ledger/internal/money"
+)
+
+var ErrInvalidStatementPeriod = errors.New("statement period must end after it starts")
+
+// StatementLine is one applied transaction. Amount is signed: credits are
+// positive and debits negative. Balance is the ledger balance after it.
+type StatementLine struct {
+	Date          time.Time                 `json:"date"`
+	TransactionID string                    `json:"transaction_id,omitempty"`
+	Type          constants.TransactionType `json:"type,omitempty"`
+	Description   string                    `json:"description"`
+	Amount        money.Money               `json:"amount"`
+	Balance       money.Money               `json:"balance"`
+}
+
+// Statement covers the account's ledger from From up to, but not including, To
+type Statement struct {
+	AccountID      string          `json:"account_id"`
+	AccountName    string          `json:"account_name"`
+	Currency       money.Currency  `json:"currency"`
+	From           time.Time       `json:"from"`
+	To             time.Time       `json:"to"`
+	OpeningBalance money.Money     `json:"opening_balance"`
+	TotalCredits   money.Money     `json:"total_credits"`
+	TotalDebits    money.Money     `json:"total_debits"`
+	ClosingBalance money.Money     `json:"closing_balance"`
+	Lines          []StatementLine `json:"lines"`
+	GeneratedAt    time.Time       `json:"generated_at"`
+}
+
+// StatementEntry is one row of an account's balance history with the
+// description of the journal entry behind it
+type StatementEntry struct {
+	BalanceHistoryEntry
+	EntryDescription string
+}
+
+// NewStatement lists the account's balance history in order, one line per
+// journal entry. Each line's balance is the one recorded when the entry was
+// posted, so it matches the balance history API. Lines take their type and
+// description from the transaction log where the transaction is found there.
+func NewStatement(account *Account, from, to time.Time, opening money.Money, entries []StatementEntry, transactions map[string]*Transaction) (*Statement, error) {
+	statement := &Statement{
+		AccountID:      account.ID,
+		AccountName:    account.Name,
+		Currency:       account.Currency,
+		From:           from,
+		To:             to,
+		OpeningBalance: opening,
+		TotalCredits:   money.Zero(account.Currency),
+		TotalDebits:    money.Zero(account.Currency),
+		ClosingBalance: opening,
+		Lines:          []StatementLine{},
+	}
+
+	var err error
+	for _, entry := range entries {
+		if entry.Change.IsNegative() {
+			if statement.TotalDebits, err = statement.TotalDebits.Add(entry.Change.Neg()); err != nil {
+				return nil, err
+			}
+		} else if statement.TotalCredits, err = statement.TotalCredits.Add(entry.Change); err != nil {
+			return nil, err
+		}
+		statement.ClosingBalance = entry.Balance
+
+		line := StatementLine{
+			Date:          entry.CreatedAt,
+			TransactionID: entry.TransactionID,
+			Description:   entry.EntryDescription,
+			Amount:        entry.Change,
+			Balance:       entry.Balance,
+		}
+		if transaction, ok := transactions[entry.TransactionID]; ok {
+			line.Type = transaction.Type
+			if transaction.Description != "" {
+				line.Description = transaction.Description
+			}
+		}
+		if line.Description == "" {
+			line.Description = string(line.Type)
+		}
+		statement.Lines = append(statement.Lines, line)
+	}
+	return statement, nil
+}
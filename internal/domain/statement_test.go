@@ -0,0 +1,96 @@
+package domain
+
+import (
+	"testing"
+	"time"
+
+	"banking-ledger/internal/constants"
+	"banking-ledger/internal/money"
+)
+
+func TestNewStatementRunningBalance(t *testing.T) {
+	account := &Account{ID: "acc-1", Name: "Alice", Currency: money.USD}
+	from := time.Date(2024, time.March, 1, 0, 0, 0, 0, time.UTC)
+	to := from.AddDate(0, 1, 0)
+
+	entry := func(sequence int64, entryID, transactionID, change, balance string, day int) StatementEntry {
+		return StatementEntry{
+			BalanceHistoryEntry: BalanceHistoryEntry{
+				Sequence:      sequence,
+				AccountID:     account.ID,
+				EntryID:       entryID,
+				TransactionID: transactionID,
+				Change:        money.MustParse(change, money.USD),
+				Balance:       money.MustParse(balance, money.USD),
+				CreatedAt:     from.AddDate(0, 0, day),
+			},
+			EntryDescription: "entry " + entryID,
+		}
+	}
+	entries := []StatementEntry{
+		entry(1, "e1", "tx-1", "200.00", "300.00", 1),
+		// e3 was stamped before e2 but took the account's lock after it, so
+		// the history order and balances win over created_at
+		entry(2, "e2", "tx-2", "-50.00", "250.00", 3),
+		entry(3, "e3", "tx-3", "-21.50", "228.50", 2),
+	}
+	transactions := map[string]*Transaction{
+		"tx-1": {ID: "tx-1", Type: constants.TransactionTypeDeposit, Description: "Salary"},
+		"tx-2": {ID: "tx-2", Type: constants.TransactionTypeWithdrawal},
+	}
+
+	statement, err := NewStatement(account, from, to, money.MustParse("100.00", money.USD), entries, transactions)
+	if err != nil {
+		t.Fatalf("NewStatement returned an error: %v", err)
+	}
+
+	want := []struct {
+		description string
+		amount      string
+		balance     string
+	}{
+		{"Salary", "200.00", "300.00"},
+		{"entry e2", "-50.00", "250.00"},
+		{"entry e3", "-21.50", "228.50"},
+	}
+	if len(statement.Lines) != len(want) {
+		t.Fatalf("expected %d lines, got %d", len(want), len(statement.Lines))
+	}
+	for i, w := range want {
+		line := statement.Lines[i]
+		if line.Description != w.description || line.Amount.String() != w.amount || line.Balance.String() != w.balance {
+			t.Errorf("line %d: got %q %s %s, want %q %s %s", i,
+				line.Description, line.Amount, line.Balance, w.description, w.amount, w.balance)
+		}
+	}
+	if statement.Lines[1].Type != constants.TransactionTypeWithdrawal {
+		t.Errorf("expected line 1 to take the transaction's type, got %q", statement.Lines[1].Type)
+	}
+
+	if got := statement.TotalCredits.String(); got != "200.00" {
+		t.Errorf("expected total credits 200.00, got %s", got)
+	}
+	if got := statement.TotalDebits.String(); got != "71.50" {
+		t.Errorf("expected total debits 71.50, got %s", got)
+	}
+	if got := statement.ClosingBalance.String(); got != "228.50" {
+		t.Errorf("expected closing balance 228.50, got %s", got)
+	}
+}
+
+func TestNewStatementWithoutActivity(t *testing.T) {
+	account := &Account{ID: "acc-1", Currency: money.USD}
+	from := time.Date(2024, time.March, 1, 0, 0, 0, 0, time.UTC)
+	opening := money.MustParse("42.00", money.USD)
+
+	statement, err := NewStatement(account, from, from.AddDate(0, 1, 0), opening, nil, nil)
+	if err != nil {
+		t.Fatalf("NewStatement returned an error: %v", err)
+	}
+	if len(statement.Lines) != 0 {
+		t.Errorf("expected no lines, got %d", len(statement.Lines))
+	}
+	if statement.ClosingBalance != opening {
+		t.Errorf("expected closing balance %s, got %s", opening, statement.ClosingBalance)
+	}
+}
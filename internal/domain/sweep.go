@@ -0,0 +1,54 @@
+package domain
+
+import (
+	"time"
+
+	"banking-ledger/internal/constants"
+)
+
+type SweepAction string
+
+const (
+	// The processor had already decided; its status was brought up to date
+	SweepActionFinished SweepAction = "finished"
+	// Put back in the outbox to be published again
+	SweepActionRequeued SweepAction = "requeued"
+	// Given up on and marked failed
+	SweepActionFailed SweepAction = "failed"
+	// Left alone: the relay has not published it yet, or it was requeued recently
+	SweepActionWaiting SweepAction = "waiting"
+)
+
+// SweptTransaction is what the pending sweep did with one transaction
+type SweptTransaction struct {
+	TransactionID string                      `json:"transaction_id"`
+	AccountID     string                      `json:"account_id"`
+	CreatedAt     time.Time                   `json:"created_at"`
+	Action        SweepAction                 `json:"action"`
+	Status        constants.TransactionStatus `json:"status"`
+	Reason        string                      `json:"reason,omitempty"`
+}
+
+type SweepReport struct {
+	StartedAt    time.Time          `json:"started_at"`
+	FinishedAt   time.Time          `json:"finished_at"`
+	Transactions []SweptTransaction `json:"transactions"`
+}
+
+// NextSweepAction decides what to do with a transaction still pending at the
+// cutoff. decision is the processor's inbox record and message the outbox
+// message, either of which may be nil.
+func NextSweepAction(decision *InboxRecord, message *OutboxMessage, cutoff time.Time, maxRequeues int) SweepAction {
+	switch {
+	case decision != nil:
+		return SweepActionFinished
+	case message != nil && message.PublishedAt == nil:
+		// The relay keeps retrying until the broker takes it
+		return SweepActionWaiting
+	case message != nil && message.CreatedAt.After(cutoff):
+		return SweepActionWaiting
+	case message != nil && message.Requeues >= maxRequeues:
+		return SweepActionFailed
+	}
+	return SweepActionRequeued
+}
@@ -0,0 +1,37 @@
+package domain
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNextSweepAction(t *testing.T) {
+	cutoff := time.Date(2024, time.March, 1, 12, 0, 0, 0, time.UTC)
+	published := cutoff.Add(-time.Hour)
+	message := func(createdAt time.Time, publishedAt *time.Time, requeues int) *OutboxMessage {
+		return &OutboxMessage{CreatedAt: createdAt, PublishedAt: publishedAt, Requeues: requeues}
+	}
+
+	tests := []struct {
+		name     string
+		decision *InboxRecord
+		message  *OutboxMessage
+		want     SweepAction
+	}{
+		{"already decided", &InboxRecord{Outcome: InboxOutcomeApplied}, message(published, &published, 0), SweepActionFinished},
+		{"decided after the limit", &InboxRecord{Outcome: InboxOutcomeRejected}, message(published, &published, 3), SweepActionFinished},
+		{"not yet published", nil, message(published, nil, 0), SweepActionWaiting},
+		{"requeued after the cutoff", nil, message(cutoff.Add(time.Minute), &published, 1), SweepActionWaiting},
+		{"published but never processed", nil, message(published, &published, 0), SweepActionRequeued},
+		{"no outbox message", nil, nil, SweepActionRequeued},
+		{"out of requeues", nil, message(published, &published, 3), SweepActionFailed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := NextSweepAction(tt.decision, tt.message, cutoff, 3); got != tt.want {
+				t.Errorf("NextSweepAction = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
@@ -2,23 +2,69 @@ package domain
 
 import (
 	"banking-ledger/internal/constants"
+	"banking-ledger/internal/money"
 	"time"
 )
 
 type Transaction struct {
-	ID          string                      `json:"id"`
-	AccountID   string                      `json:"account_id"`
-	Type        constants.TransactionType   `json:"type"`
-	Amount      float64                     `json:"amount"`
-	Status      constants.TransactionStatus `json:"status"`
-	Description string                      `json:"description"`
-	CreatedAt   time.Time                   `json:"created_at"`
-	UpdatedAt   time.Time                   `json:"updated_at"`
+	ID               string                      `json:"id" bson:"id"`
+	AccountID        string                      `json:"account_id" bson:"account_id"`
+	ToAccountID      string                      `json:"to_account_id,omitempty" bson:"to_account_id,omitempty"` // credited side of a transfer
+	Type             constants.TransactionType   `json:"type" bson:"type"`
+	Amount           money.Money                 `json:"amount" bson:"amount"`
+	ToAmount         *money.Money                `json:"to_amount,omitempty" bson:"to_amount,omitempty"`     // credited amount of a cross-currency transfer
+	FXRate           *FXRate                     `json:"fx_rate,omitempty" bson:"fx_rate,omitempty"`         // rate ToAmount was converted at
+	HoldID           string                      `json:"hold_id,omitempty" bson:"hold_id,omitempty"`         // hold a capture settles
+	ReversalOf       string                      `json:"reversal_of,omitempty" bson:"reversal_of,omitempty"` // transaction a reversal or refund undoes
+	FeeOf            string                      `json:"fee_of,omitempty" bson:"fee_of,omitempty"`           // transaction a fee is charged for
+	Fee              *money.Money                `json:"fee,omitempty" bson:"fee,omitempty"`                 // fee charged for this transaction
+	FeeTransactionID string                      `json:"fee_transaction_id,omitempty" bson:"fee_transaction_id,omitempty"`
+	Status           constants.TransactionStatus `json:"status" bson:"status"`
+	Failure          *TransactionFailure         `json:"failure,omitempty" bson:"failure,omitempty"`               // why a failed transaction failed
+	StatusHistory    []StatusChange              `json:"status_history,omitempty" bson:"status_history,omitempty"` // oldest first
+	Description      string                      `json:"description" bson:"description"`
+	CreatedAt        time.Time                   `json:"created_at" bson:"created_at"`
+	UpdatedAt        time.Time                   `json:"updated_at" bson:"updated_at"`
+}
+
+// Criteria for listing transactions; zero values are ignored
+type TransactionFilter struct {
+	AccountID   string // matches either side of a transfer
+	Types       []constants.TransactionType
+	Statuses    []constants.TransactionStatus
+	MinAmount   *money.Money
+	MaxAmount   *money.Money
+	CreatedFrom *time.Time
+	CreatedTo   *time.Time // exclusive
+	Description string     // case-insensitive substring
+	Cursor      string     // NextCursor of the previous page
+	Limit       int
+}
+
+// Transactions are ordered newest first; NextCursor is empty on the last page
+type TransactionPage struct {
+	Transactions []*Transaction `json:"transactions"`
+	NextCursor   string         `json:"next_cursor,omitempty"`
 }
 
 type TransactionRepository interface {
 	Create(transaction *Transaction) error
+	// CreateWithOutbox stores the transaction together with the message
+	// that announces it to the processor
+	CreateWithOutbox(transaction *Transaction, payload []byte) error
 	GetByID(id string) (*Transaction, error)
+	// ListByIDs returns the transactions found among ids, in no particular order
+	ListByIDs(ids []string) ([]*Transaction, error)
 	ListByAccountID(accountID string) ([]*Transaction, error)
-	UpdateStatus(id string, status constants.TransactionStatus) error
+	// ListReversals returns the reversals and refunds of a transaction
+	ListReversals(originalID string) ([]*Transaction, error)
+	List(filter TransactionFilter) (*TransactionPage, error)
+	// UpdateStatus moves the transaction to a new status and appends the
+	// change to its history. It returns ErrInvalidTransactionTransition if the
+	// current status cannot move there; setting a final status it already has
+	// changes nothing.
+	UpdateStatus(id string, update StatusUpdate) error
+	// RecordFee stores a fee transaction and links it from the transaction
+	// it was charged for; recording the same fee again changes nothing
+	RecordFee(fee *Transaction) error
 }
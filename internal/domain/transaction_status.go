@@ -0,0 +1,124 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"banking-ledger/internal/constants"
+	"banking-ledger/internal/money"
+)
+
+var ErrInvalidTransactionTransition = errors.New("invalid transaction status transition")
+
+type FailureCode string
+
+const (
+	FailureInsufficientFunds       FailureCode = "insufficient_funds"
+	FailureAccountNotFound         FailureCode = "account_not_found"
+	FailureAccountRestricted       FailureCode = "account_restricted"
+	FailureHoldInvalid             FailureCode = "hold_invalid" // missing, released, expired or exceeded
+	FailureReversalExceedsOriginal FailureCode = "reversal_exceeds_original"
+	FailureLimitExceeded           FailureCode = "limit_exceeded"
+	FailureCurrencyMismatch        FailureCode = "currency_mismatch"
+	FailureInvalidTransaction      FailureCode = "invalid_transaction" // no valid journal entry can be built
+	FailureNotProcessed            FailureCode = "not_processed"       // given up on by the pending sweep
+	FailureInternal                FailureCode = "internal_error"
+)
+
+// Why a transaction failed: a stable code for clients and the message of the
+// error behind it
+type TransactionFailure struct {
+	Code    FailureCode `json:"code" bson:"code"`
+	Message string      `json:"message" bson:"message"`
+}
+
+// FailureFor classifies the error a transaction was rejected with
+func FailureFor(err error) *TransactionFailure {
+	code := FailureInternal
+	switch {
+	case errors.Is(err, ErrInsufficientFunds):
+		code = FailureInsufficientFunds
+	case errors.Is(err, ErrAccountNotFound):
+		code = FailureAccountNotFound
+	case errors.Is(err, ErrAccountRestricted):
+		code = FailureAccountRestricted
+	case errors.Is(err, ErrHoldNotFound), errors.Is(err, ErrHoldNotActive), errors.Is(err, ErrCaptureExceedsHold):
+		code = FailureHoldInvalid
+	case errors.Is(err, ErrReversalExceedsOriginal):
+		code = FailureReversalExceedsOriginal
+	case errors.Is(err, ErrLimitExceeded):
+		code = FailureLimitExceeded
+	case errors.Is(err, money.ErrCurrencyMismatch):
+		code = FailureCurrencyMismatch
+	case errors.Is(err, ErrUnbalancedEntry), errors.Is(err, ErrInvalidTransaction), errors.Is(err, ErrNotReversible):
+		code = FailureInvalidTransaction
+	}
+	return &TransactionFailure{Code: code, Message: err.Error()}
+}
+
+// IsRejection reports whether err refuses a transaction on its merits, so
+// asking again unchanged gets the same answer. Anything else, such as a
+// database that could not be reached, may succeed when retried.
+func IsRejection(err error) bool {
+	return errors.Is(err, ErrInvalidTransaction) ||
+		errors.Is(err, ErrInsufficientFunds) ||
+		errors.Is(err, ErrAccountNotFound) ||
+		errors.Is(err, ErrAccountRestricted) ||
+		errors.Is(err, ErrHoldNotFound) ||
+		errors.Is(err, ErrHoldNotActive) ||
+		errors.Is(err, ErrCaptureExceedsHold) ||
+		errors.Is(err, ErrNotReversible) ||
+		errors.Is(err, ErrReversalExceedsOriginal) ||
+		errors.Is(err, ErrUnbalancedEntry) ||
+		errors.Is(err, ErrLimitExceeded) ||
+		errors.Is(err, ErrFXRateNotFound) ||
+		errors.Is(err, money.ErrCurrencyMismatch) ||
+		errors.Is(err, money.ErrInvalidAmount) ||
+		errors.Is(err, money.ErrTooPrecise) ||
+		errors.Is(err, money.ErrOverflow)
+}
+
+// One entry of a transaction's status history. From is empty for the status
+// it was created with; Instance names the processor that made the change.
+type StatusChange struct {
+	From     constants.TransactionStatus `json:"from,omitempty" bson:"from,omitempty"`
+	To       constants.TransactionStatus `json:"to" bson:"to"`
+	At       time.Time                   `json:"at" bson:"at"`
+	Instance string                      `json:"instance,omitempty" bson:"instance,omitempty"`
+}
+
+// StatusUpdate moves a transaction to a new status; Failure is only kept
+// when the new status is failed
+type StatusUpdate struct {
+	Status   constants.TransactionStatus
+	Failure  *TransactionFailure
+	Instance string
+}
+
+// CheckTransactionTransition returns ErrInvalidTransactionTransition unless a
+// transaction may move from one status to the other. Pending and processing
+// may move to processing, completed or failed; completed and failed are final.
+func CheckTransactionTransition(from, to constants.TransactionStatus) error {
+	switch from {
+	case constants.TransactionStatusPending, constants.TransactionStatusProcessing:
+		switch to {
+		case constants.TransactionStatusProcessing, constants.TransactionStatusCompleted, constants.TransactionStatusFailed:
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: %s to %s", ErrInvalidTransactionTransition, from, to)
+}
+
+// InitialStatusHistory gives a transaction being created the first entry of
+// its history, unless it already has one
+func (t *Transaction) InitialStatusHistory() {
+	if len(t.StatusHistory) > 0 {
+		return
+	}
+	at := t.CreatedAt
+	if at.IsZero() {
+		at = time.Now()
+	}
+	t.StatusHistory = []StatusChange{{To: t.Status, At: at}}
+}
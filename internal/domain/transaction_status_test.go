@@ -0,0 +1,64 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"banking-ledger/internal/constants"
+	"banking-ledger/internal/money"
+)
+
+func TestCheckTransactionTransition(t *testing.T) {
+	pending := constants.TransactionStatusPending
+	processing := constants.TransactionStatusProcessing
+	completed := constants.TransactionStatusCompleted
+	failed := constants.TransactionStatusFailed
+
+	tests := []struct {
+		from, to constants.TransactionStatus
+		allowed  bool
+	}{
+		{pending, processing, true},
+		{pending, completed, true},
+		{pending, failed, true},
+		{processing, processing, true},
+		{processing, completed, true},
+		{processing, failed, true},
+		{processing, pending, false},
+		{completed, failed, false},
+		{completed, processing, false},
+		{failed, completed, false},
+		{failed, pending, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%s to %s", tt.from, tt.to), func(t *testing.T) {
+			err := CheckTransactionTransition(tt.from, tt.to)
+			if (err == nil) != tt.allowed || (err != nil && !errors.Is(err, ErrInvalidTransactionTransition)) {
+				t.Errorf("CheckTransactionTransition() = %v, want allowed %v", err, tt.allowed)
+			}
+		})
+	}
+}
+
+func TestFailureFor(t *testing.T) {
+	tests := []struct {
+		err  error
+		want FailureCode
+	}{
+		{ErrInsufficientFunds, FailureInsufficientFunds},
+		{fmt.Errorf("%w: account a is frozen", ErrAccountRestricted), FailureAccountRestricted},
+		{ErrCaptureExceedsHold, FailureHoldInvalid},
+		{ErrLimitExceeded, FailureLimitExceeded},
+		{money.ErrCurrencyMismatch, FailureCurrencyMismatch},
+		{errors.New("connection reset"), FailureInternal},
+	}
+
+	for _, tt := range tests {
+		failure := FailureFor(tt.err)
+		if failure.Code != tt.want || failure.Message != tt.err.Error() {
+			t.Errorf("FailureFor(%v) = %+v, want code %s", tt.err, failure, tt.want)
+		}
+	}
+}
@@ -0,0 +1,20 @@
+package domain
+
+// Repositories bound to a single database transaction
+type TxRepositories struct {
+	Accounts  AccountRepository
+	Journal   JournalRepository
+	Inbox     InboxRepository
+	Holds     HoldRepository
+	Reversals ReversalRepository
+	Limits    LimitRepository
+	Fees      FeeRepository
+	Events    AccountEventStore
+}
+
+// UnitOfWork runs fn inside one database transaction. Everything written
+// through the repositories handed to fn commits together, or not at all if
+// fn returns an error.
+type UnitOfWork interface {
+	Do(fn func(tx TxRepositories) error) error
+}
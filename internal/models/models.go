@@ -1,21 +1,250 @@
 package models
 
-import "banking-ledger/internal/constants"
+import (
+	"encoding/json"
+	"time"
 
+	"banking-ledger/internal/constants"
+	"banking-ledger/internal/money"
+)
+
+// Amounts are decoded as json.Number so the decimal text reaches money.Parse
+// untouched; both 500.00 and "500.00" are accepted.
+// Currency fields are optional: accounts default to USD, and transaction
+// amounts default to the currency of the account they are made against.
 type CreateAccountRequest struct {
-	Name          string  `json:"name" binding:"required"`
-	InitialAmount float64 `json:"initial_amount" binding:"min=0"`
+	Name           string      `json:"name" binding:"required"`
+	Type           string      `json:"type"`
+	Currency       string      `json:"currency"`
+	InitialAmount  json.Number `json:"initial_amount"`
+	OverdraftLimit json.Number `json:"overdraft_limit"`
 }
 
 type TransactionRequest struct {
-	Amount      float64 `json:"amount" binding:"required,min=0.01"`
-	Description string  `json:"description"`
+	Amount      json.Number `json:"amount" binding:"required"`
+	Currency    string      `json:"currency"`
+	Description string      `json:"description"`
+}
+
+type TransferRequest struct {
+	FromAccountID string      `json:"from_account_id" binding:"required"`
+	ToAccountID   string      `json:"to_account_id" binding:"required"`
+	Amount        json.Number `json:"amount" binding:"required"`
+	Currency      string      `json:"currency"`
+	Description   string      `json:"description"`
+}
+
+// ExpiresAt is optional and defaults to seven days from now
+type CreateHoldRequest struct {
+	Amount      json.Number `json:"amount" binding:"required"`
+	Currency    string      `json:"currency"`
+	Description string      `json:"description"`
+	ExpiresAt   *time.Time  `json:"expires_at"`
+}
+
+// Amount is optional; without it the whole hold is captured
+type CaptureHoldRequest struct {
+	Amount      json.Number `json:"amount"`
+	Description string      `json:"description"`
+}
+
+type ReverseTransactionRequest struct {
+	Description string `json:"description"`
+}
+
+// Amount is in the currency of the transaction being refunded
+type RefundTransactionRequest struct {
+	Amount      json.Number `json:"amount" binding:"required"`
+	Description string      `json:"description"`
+}
+
+// Limit is in the account's currency; zero removes the overdraft
+type ChangeOverdraftLimitRequest struct {
+	Limit  json.Number `json:"limit" binding:"required"`
+	Reason string      `json:"reason" binding:"required"`
+	Actor  string      `json:"actor" binding:"required"`
+}
+
+// Status is one of active, frozen, debit_blocked, credit_blocked or closed
+type ChangeAccountStatusRequest struct {
+	Status string `json:"status" binding:"required"`
+	Reason string `json:"reason" binding:"required"`
+	Actor  string `json:"actor" binding:"required"`
+}
+
+// Rate is the number of units of To that one unit of From buys
+type SetFXRateRequest struct {
+	From string      `json:"from" binding:"required"`
+	To   string      `json:"to" binding:"required"`
+	Rate json.Number `json:"rate" binding:"required"`
+}
+
+// Set either AccountID or AccountType. MaxAmount defaults to the account's
+// currency for account rules and to USD for account type rules; Enabled
+// defaults to true.
+type LimitRuleRequest struct {
+	Name            string                    `json:"name" binding:"required"`
+	AccountID       string                    `json:"account_id"`
+	AccountType     string                    `json:"account_type"`
+	TransactionType constants.TransactionType `json:"transaction_type" binding:"required"`
+	Kind            string                    `json:"kind" binding:"required"`
+	MaxAmount       json.Number               `json:"max_amount"`
+	Currency        string                    `json:"currency"`
+	MaxCount        int                       `json:"max_count"`
+	WindowSeconds   int64                     `json:"window_seconds"`
+	Enabled         *bool                     `json:"enabled"`
+}
+
+// Amounts are in Currency, which defaults to USD; rates are fractions of
+// the amount, so "0.015" is 1.5%
+type FeeScheduleRequest struct {
+	Name            string                    `json:"name" binding:"required"`
+	TransactionType constants.TransactionType `json:"transaction_type" binding:"required"`
+	AccountType     string                    `json:"account_type"`
+	Currency        string                    `json:"currency"`
+	Kind            string                    `json:"kind" binding:"required"`
+	Flat            json.Number               `json:"flat"`
+	Rate            json.Number               `json:"rate"`
+	Tiers           []FeeTierRequest          `json:"tiers"`
+	Min             json.Number               `json:"min"`
+	Max             json.Number               `json:"max"`
+	Enabled         *bool                     `json:"enabled"`
+}
+
+// UpTo is left out of the last tier
+type FeeTierRequest struct {
+	UpTo json.Number `json:"up_to"`
+	Flat json.Number `json:"flat"`
+	Rate json.Number `json:"rate"`
+}
+
+type FeeWaiverRequest struct {
+	Waived *bool `json:"waived" binding:"required"`
+}
+
+// AnnualRate is a fraction, so "0.035" is 3.5% a year
+type InterestPlanRequest struct {
+	Name       string      `json:"name" binding:"required"`
+	AnnualRate json.Number `json:"annual_rate" binding:"required"`
+	Method     string      `json:"method" binding:"required"`
+	DayCount   string      `json:"day_count" binding:"required"`
+	Enabled    *bool       `json:"enabled"`
+}
+
+// An empty PlanID takes the account off its plan
+type AssignInterestPlanRequest struct {
+	PlanID string `json:"plan_id"`
+}
+
+// Set RunAt for a one-off or Cron (five fields, UTC) for a recurring
+// transaction. Type is deposit, withdrawal or transfer; ToAccountID is only
+// for transfers.
+type ScheduledTransactionRequest struct {
+	Type        constants.TransactionType `json:"type" binding:"required"`
+	ToAccountID string                    `json:"to_account_id"`
+	Amount      json.Number               `json:"amount" binding:"required"`
+	Currency    string                    `json:"currency"`
+	Description string                    `json:"description"`
+	RunAt       *time.Time                `json:"run_at"`
+	Cron        string                    `json:"cron"`
 }
 
 type TransactionMessage struct {
 	TransactionID string                    `json:"transaction_id"`
 	AccountID     string                    `json:"account_id"`
+	ToAccountID   string                    `json:"to_account_id,omitempty"`
 	Type          constants.TransactionType `json:"type"`
-	Amount        float64                   `json:"amount"`
+	Amount        money.Money               `json:"amount"`
+	ToAmount      *money.Money              `json:"to_amount,omitempty"`
+	HoldID        string                    `json:"hold_id,omitempty"`
+	ReversalOf    string                    `json:"reversal_of,omitempty"`
 	Description   string                    `json:"description"`
 }
+
+// InitialBalance parses the opening amount in the account's currency,
+// treating an empty value as zero
+func (r CreateAccountRequest) InitialBalance() (money.Money, error) {
+	currency := money.DefaultCurrency
+	if r.Currency != "" {
+		var err error
+		if currency, err = money.ParseCurrency(r.Currency); err != nil {
+			return money.Money{}, err
+		}
+	}
+	if r.InitialAmount == "" {
+		return money.Zero(currency), nil
+	}
+	return money.Parse(r.InitialAmount.String(), currency)
+}
+
+// Money parses the held amount, defaulting to the account's currency
+func (r CreateHoldRequest) Money(accountCurrency money.Currency) (money.Money, error) {
+	return parseAmount(r.Amount, r.Currency, accountCurrency)
+}
+
+// Limit parses the overdraft or credit limit in the account's currency,
+// treating an empty value as zero
+func (r CreateAccountRequest) Limit(currency money.Currency) (money.Money, error) {
+	if r.OverdraftLimit == "" {
+		return money.Zero(currency), nil
+	}
+	return money.Parse(r.OverdraftLimit.String(), currency)
+}
+
+// Money parses the requested amount, defaulting to the account's currency
+func (r TransactionRequest) Money(accountCurrency money.Currency) (money.Money, error) {
+	return parseAmount(r.Amount, r.Currency, accountCurrency)
+}
+
+// Money parses the scheduled amount, defaulting to the account's currency
+func (r ScheduledTransactionRequest) Money(accountCurrency money.Currency) (money.Money, error) {
+	return parseAmount(r.Amount, r.Currency, accountCurrency)
+}
+
+// Money parses the requested amount, defaulting to the source account's currency
+func (r TransferRequest) Money(accountCurrency money.Currency) (money.Money, error) {
+	return parseAmount(r.Amount, r.Currency, accountCurrency)
+}
+
+// Limit parses the rule's amount cap, or returns nil when it has none
+func (r LimitRuleRequest) Limit(fallback money.Currency) (*money.Money, error) {
+	if r.MaxAmount == "" {
+		return nil, nil
+	}
+	limit, err := parseAmount(r.MaxAmount, r.Currency, fallback)
+	if err != nil {
+		return nil, err
+	}
+	return &limit, nil
+}
+
+// ScheduleCurrency parses the schedule's currency, defaulting to USD
+func (r FeeScheduleRequest) ScheduleCurrency() (money.Currency, error) {
+	if r.Currency == "" {
+		return money.DefaultCurrency, nil
+	}
+	return money.ParseCurrency(r.Currency)
+}
+
+// OptionalAmount parses an amount that may be left out, returning nil if it is
+func OptionalAmount(amount json.Number, currency money.Currency) (*money.Money, error) {
+	if amount == "" {
+		return nil, nil
+	}
+	parsed, err := money.Parse(amount.String(), currency)
+	if err != nil {
+		return nil, err
+	}
+	return &parsed, nil
+}
+
+func parseAmount(amount json.Number, currencyCode string, fallback money.Currency) (money.Money, error) {
+	currency := fallback
+	if currencyCode != "" {
+		var err error
+		if currency, err = money.ParseCurrency(currencyCode); err != nil {
+			return money.Money{}, err
+		}
+	}
+	return money.Parse(amount.String(), currency)
+}
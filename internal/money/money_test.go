@@ -0,0 +1,197 @@
+package money
+
+import (
+	"encoding/json"
+	"errors"
+	"math/big"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    int64
+		wantErr error
+	}{
+		{name: "Whole number", input: "100", want: 10000},
+		{name: "Two decimals", input: "100.25", want: 10025},
+		{name: "One decimal", input: "0.1", want: 10},
+		{name: "Trailing zeros beyond precision", input: "5.1000", want: 510},
+		{name: "Negative", input: "-0.05", want: -5},
+		{name: "Too precise", input: "1.005", wantErr: ErrTooPrecise},
+		{name: "Empty", input: "", wantErr: ErrInvalidAmount},
+		{name: "Exponent notation", input: "1e3", wantErr: ErrInvalidAmount},
+		{name: "Missing fraction", input: "1.", wantErr: ErrInvalidAmount},
+		{name: "Overflow", input: "999999999999999999999", wantErr: ErrOverflow},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Parse(tt.input, USD)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("Parse(%q) error = %v, want %v", tt.input, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Parse(%q) returned an error: %v", tt.input, err)
+			}
+			if got.Amount != tt.want {
+				t.Errorf("Parse(%q) = %d, want %d", tt.input, got.Amount, tt.want)
+			}
+		})
+	}
+}
+
+func TestRepeatedAdditionIsExact(t *testing.T) {
+	balance := Zero(USD)
+	dime := MustParse("0.10", USD)
+	for i := 0; i < 1000; i++ {
+		var err error
+		if balance, err = balance.Add(dime); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := balance.String(); got != "100.00" {
+		t.Errorf("balance = %s, want 100.00", got)
+	}
+}
+
+func TestAddCurrencyMismatch(t *testing.T) {
+	_, err := New(100, USD).Add(New(100, "EUR"))
+	if !errors.Is(err, ErrCurrencyMismatch) {
+		t.Errorf("Add() error = %v, want %v", err, ErrCurrencyMismatch)
+	}
+}
+
+func TestString(t *testing.T) {
+	tests := map[int64]string{
+		0:     "0.00",
+		5:     "0.05",
+		-5:    "-0.05",
+		12345: "123.45",
+	}
+	for minor, want := range tests {
+		if got := New(minor, USD).String(); got != want {
+			t.Errorf("New(%d).String() = %s, want %s", minor, got, want)
+		}
+	}
+}
+
+func TestCurrencyMinorUnits(t *testing.T) {
+	tests := []struct {
+		code, input, want string
+	}{
+		{"usd", "12.34", "12.34"},
+		{"JPY", "1234", "1234"},
+		{"BHD", "1.234", "1.234"},
+	}
+	for _, tt := range tests {
+		currency, err := ParseCurrency(tt.code)
+		if err != nil {
+			t.Fatalf("ParseCurrency(%q) error = %v", tt.code, err)
+		}
+		m, err := Parse(tt.input, currency)
+		if err != nil {
+			t.Fatalf("Parse(%q, %s) error = %v", tt.input, currency, err)
+		}
+		if got := m.String(); got != tt.want {
+			t.Errorf("Parse(%q, %s) = %s, want %s", tt.input, currency, got, tt.want)
+		}
+	}
+
+	if _, err := Parse("1.5", JPY); !errors.Is(err, ErrTooPrecise) {
+		t.Errorf("Parse(1.5, JPY) error = %v, want %v", err, ErrTooPrecise)
+	}
+	if _, err := ParseCurrency("XYZ"); err == nil {
+		t.Error("ParseCurrency(XYZ) succeeded, want error")
+	}
+}
+
+func TestConvert(t *testing.T) {
+	rate, _ := new(big.Rat).SetString("149.537")
+	got, err := MustParse("10.05", USD).Convert(JPY, rate, RoundHalfEven)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 10.05 * 149.537 = 1502.84685 yen, and yen have no minor unit
+	if want := New(1503, JPY); got != want {
+		t.Errorf("Convert() = %s %s, want %s %s", got, got.Currency, want, want.Currency)
+	}
+}
+
+func TestMulRounding(t *testing.T) {
+	amount := MustParse("0.05", USD)
+	half := big.NewRat(1, 2)
+	tests := []struct {
+		mode RoundingMode
+		want int64
+	}{
+		{RoundHalfEven, 2},
+		{RoundHalfUp, 3},
+		{RoundDown, 2},
+		{RoundUp, 3},
+	}
+	for _, tt := range tests {
+		got, err := amount.Mul(half, tt.mode)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.Amount != tt.want {
+			t.Errorf("Mul(1/2, %d) = %d, want %d", tt.mode, got.Amount, tt.want)
+		}
+	}
+}
+
+func TestJSONRoundTrip(t *testing.T) {
+	data, err := json.Marshal(MustParse("1234.50", USD))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != `{"amount":"1234.50","currency":"USD"}` {
+		t.Errorf("Marshal() = %s", data)
+	}
+
+	var decoded Money
+	if err := json.Unmarshal([]byte(`{"amount":1234.5,"currency":"USD"}`), &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if decoded.Amount != 123450 {
+		t.Errorf("Unmarshal() = %d, want 123450", decoded.Amount)
+	}
+}
+
+func TestBSONLegacyFloat(t *testing.T) {
+	data, err := bson.Marshal(bson.M{"amount": 0.1 + 0.2})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var doc struct {
+		Amount Money `bson:"amount"`
+	}
+	if err := bson.Unmarshal(data, &doc); err != nil {
+		t.Fatal(err)
+	}
+	if doc.Amount.Amount != 30 || doc.Amount.Currency != DefaultCurrency {
+		t.Errorf("legacy amount decoded as %+v", doc.Amount)
+	}
+
+	data, err = bson.Marshal(doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var roundTrip struct {
+		Amount Money `bson:"amount"`
+	}
+	if err := bson.Unmarshal(data, &roundTrip); err != nil {
+		t.Fatal(err)
+	}
+	if roundTrip.Amount != doc.Amount {
+		t.Errorf("round trip = %+v, want %+v", roundTrip.Amount, doc.Amount)
+	}
+}
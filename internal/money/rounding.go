@@ -0,0 +1,54 @@
+package money
+
+import "math/big"
+
+type RoundingMode int
+
+const (
+	// Round to nearest, ties to even (banker's rounding)
+	RoundHalfEven RoundingMode = iota
+	// Round to nearest, ties away from zero
+	RoundHalfUp
+	// Truncate towards zero
+	RoundDown
+	// Round away from zero
+	RoundUp
+)
+
+// RoundRat rounds an exact rational to an integer using the given mode
+func RoundRat(r *big.Rat, mode RoundingMode) *big.Int {
+	num := new(big.Int).Set(r.Num())
+	den := r.Denom()
+
+	negative := num.Sign() < 0
+	num.Abs(num)
+
+	quo, rem := new(big.Int).QuoRem(num, den, new(big.Int))
+	if rem.Sign() != 0 {
+		twice := new(big.Int).Lsh(rem, 1)
+		half := twice.Cmp(den)
+		roundAway := false
+		switch mode {
+		case RoundHalfEven:
+			roundAway = half > 0 || (half == 0 && quo.Bit(0) == 1)
+		case RoundHalfUp:
+			roundAway = half >= 0
+		case RoundUp:
+			roundAway = true
+		case RoundDown:
+			roundAway = false
+		}
+		if roundAway {
+			quo.Add(quo, big.NewInt(1))
+		}
+	}
+
+	if negative {
+		quo.Neg(quo)
+	}
+	return quo
+}
+
+func pow10(n int) *big.Int {
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(n)), nil)
+}
@@ -0,0 +1,83 @@
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"time"
+
+	"banking-ledger/internal/domain"
+	"banking-ledger/internal/queue"
+)
+
+// Messages published per poll
+const batchSize = 100
+
+// Relay publishes outbox messages to RabbitMQ and marks them sent once the
+// broker confirms them. A crash between the two publishes the message again
+// on the next poll; the processor's inbox makes that harmless.
+type Relay struct {
+	outboxRepo domain.OutboxRepository
+	producer   queue.Producer
+	interval   time.Duration
+}
+
+func NewRelay(outboxRepo domain.OutboxRepository, producer queue.Producer, interval time.Duration) *Relay {
+	return &Relay{
+		outboxRepo: outboxRepo,
+		producer:   producer,
+		interval:   interval,
+	}
+}
+
+// Run polls the outbox until ctx is cancelled
+func (r *Relay) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	log.Printf("Outbox relay started, polling every %s", r.interval)
+	for {
+		select {
+		case <-ctx.Done():
+			log.Println("Outbox relay stopped")
+			return
+		case <-ticker.C:
+			for {
+				published, err := r.PublishPending()
+				if err != nil {
+					log.Printf("Outbox relay: %v", err)
+				}
+				// A full batch means more may be waiting
+				if err != nil || published < batchSize {
+					break
+				}
+			}
+		}
+	}
+}
+
+// PublishPending sends one batch of unpublished messages and returns how
+// many were confirmed
+func (r *Relay) PublishPending() (int, error) {
+	messages, err := r.outboxRepo.ListUnpublished(batchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	published := 0
+	for _, message := range messages {
+		if err := r.producer.PublishTransaction(json.RawMessage(message.Payload)); err != nil {
+			log.Printf("Failed to publish transaction %s: %v", message.TransactionID, err)
+			if err := r.outboxRepo.MarkFailed(message.TransactionID, err.Error()); err != nil {
+				log.Printf("Failed to record publish failure for %s: %v", message.TransactionID, err)
+			}
+			// Keep ordering: later messages wait for the next poll
+			return published, err
+		}
+		if err := r.outboxRepo.MarkPublished(message.TransactionID); err != nil {
+			return published, err
+		}
+		published++
+	}
+	return published, nil
+}
@@ -0,0 +1,127 @@
+package outbox
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"banking-ledger/internal/domain"
+)
+
+type memoryOutbox struct {
+	messages []*domain.OutboxMessage
+}
+
+func (o *memoryOutbox) ListUnpublished(limit int) ([]*domain.OutboxMessage, error) {
+	var pending []*domain.OutboxMessage
+	for _, message := range o.messages {
+		if message.PublishedAt == nil && len(pending) < limit {
+			pending = append(pending, message)
+		}
+	}
+	return pending, nil
+}
+
+func (o *memoryOutbox) find(id string) *domain.OutboxMessage {
+	for _, message := range o.messages {
+		if message.TransactionID == id {
+			return message
+		}
+	}
+	return nil
+}
+
+func (o *memoryOutbox) MarkPublished(id string) error {
+	now := time.Now()
+	message := o.find(id)
+	message.PublishedAt = &now
+	message.Attempts++
+	return nil
+}
+
+func (o *memoryOutbox) MarkFailed(id string, reason string) error {
+	message := o.find(id)
+	message.LastError = reason
+	message.Attempts++
+	return nil
+}
+
+func (o *memoryOutbox) Get(id string) (*domain.OutboxMessage, error) {
+	return o.find(id), nil
+}
+
+func (o *memoryOutbox) Requeue(id string, payload []byte) error {
+	message := o.find(id)
+	message.Payload = payload
+	message.CreatedAt = time.Now()
+	message.PublishedAt = nil
+	message.Requeues++
+	return nil
+}
+
+type stubProducer struct {
+	published []string
+	failOn    string
+}
+
+func (p *stubProducer) PublishTransaction(message interface{}) error {
+	body, _ := json.Marshal(message)
+	if string(body) == p.failOn {
+		return errors.New("broker unavailable")
+	}
+	p.published = append(p.published, string(body))
+	return nil
+}
+
+func (p *stubProducer) Close() error { return nil }
+
+func TestPublishPending(t *testing.T) {
+	repo := &memoryOutbox{messages: []*domain.OutboxMessage{
+		{TransactionID: "t1", Payload: []byte(`{"transaction_id":"t1"}`)},
+		{TransactionID: "t2", Payload: []byte(`{"transaction_id":"t2"}`)},
+		{TransactionID: "t3", Payload: []byte(`{"transaction_id":"t3"}`)},
+	}}
+	producer := &stubProducer{failOn: `{"transaction_id":"t2"}`}
+	relay := NewRelay(repo, producer, time.Second)
+
+	published, err := relay.PublishPending()
+	if err == nil {
+		t.Fatal("PublishPending() should report the failed publish")
+	}
+	if published != 1 {
+		t.Errorf("published = %d, want 1", published)
+	}
+	if repo.find("t2").LastError == "" || repo.find("t3").PublishedAt != nil {
+		t.Error("messages after a failed publish must stay in the outbox")
+	}
+
+	producer.failOn = ""
+	if published, err = relay.PublishPending(); err != nil || published != 2 {
+		t.Fatalf("PublishPending() = %d, %v; want 2, nil", published, err)
+	}
+	if len(producer.published) != 3 || producer.published[1] != `{"transaction_id":"t2"}` {
+		t.Errorf("published in wrong order: %v", producer.published)
+	}
+}
+
+func TestPublishPendingSendsRequeuedMessage(t *testing.T) {
+	repo := &memoryOutbox{messages: []*domain.OutboxMessage{
+		{TransactionID: "t1", Payload: []byte(`{"transaction_id":"t1"}`)},
+	}}
+	producer := &stubProducer{}
+	relay := NewRelay(repo, producer, time.Second)
+
+	if _, err := relay.PublishPending(); err != nil {
+		t.Fatalf("PublishPending() returned an error: %v", err)
+	}
+	if err := repo.Requeue("t1", []byte(`{"transaction_id":"t1"}`)); err != nil {
+		t.Fatalf("Requeue() returned an error: %v", err)
+	}
+	if published, err := relay.PublishPending(); err != nil || published != 1 {
+		t.Fatalf("PublishPending() = %d, %v; want 1, nil", published, err)
+	}
+	if len(producer.published) != 2 {
+		t.Errorf("published %d messages, want 2", len(producer.published))
+	}
+}
@@ -0,0 +1,752 @@
+package processor
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"banking-ledger/internal/constants"
+	"banking-ledger/internal/domain"
+	"banking-ledger/internal/money"
+)
+
+var errCrash = errors.New("simulated crash")
+
+// Fails the named step once, standing in for a process dying at that point
+type faults struct {
+	mu      sync.Mutex
+	step    string
+	tripped bool
+}
+
+func (f *faults) hit(step string) error {
+	if f == nil {
+		return nil
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.step == step && !f.tripped {
+		f.tripped = true
+		return fmt.Errorf("%w at %s", errCrash, step)
+	}
+	return nil
+}
+
+// In-memory stand-in for the MongoDB transaction log
+type memoryTransactionRepo struct {
+	mu           sync.Mutex
+	transactions map[string]*domain.Transaction
+	faults       *faults
+}
+
+func newMemoryTransactionRepo() *memoryTransactionRepo {
+	return &memoryTransactionRepo{transactions: make(map[string]*domain.Transaction)}
+}
+
+func (r *memoryTransactionRepo) Create(transaction *domain.Transaction) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	transaction.InitialStatusHistory()
+	stored := *transaction
+	r.transactions[transaction.ID] = &stored
+	return nil
+}
+
+func (r *memoryTransactionRepo) CreateWithOutbox(transaction *domain.Transaction, payload []byte) error {
+	return r.Create(transaction)
+}
+
+func (r *memoryTransactionRepo) GetByID(id string) (*domain.Transaction, error) {
+	if err := r.faults.hit("get transaction"); err != nil {
+		return nil, err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	transaction, ok := r.transactions[id]
+	if !ok {
+		return nil, fmt.Errorf("transaction not found")
+	}
+	copied := *transaction
+	return &copied, nil
+}
+
+func (r *memoryTransactionRepo) ListByIDs(ids []string) ([]*domain.Transaction, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var transactions []*domain.Transaction
+	for _, id := range ids {
+		if transaction, ok := r.transactions[id]; ok {
+			copied := *transaction
+			transactions = append(transactions, &copied)
+		}
+	}
+	return transactions, nil
+}
+
+func (r *memoryTransactionRepo) ListByAccountID(accountID string) ([]*domain.Transaction, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var transactions []*domain.Transaction
+	for _, transaction := range r.transactions {
+		if transaction.AccountID == accountID || transaction.ToAccountID == accountID {
+			copied := *transaction
+			transactions = append(transactions, &copied)
+		}
+	}
+	return transactions, nil
+}
+
+func (r *memoryTransactionRepo) ListReversals(originalID string) ([]*domain.Transaction, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var transactions []*domain.Transaction
+	for _, transaction := range r.transactions {
+		if transaction.ReversalOf == originalID {
+			copied := *transaction
+			transactions = append(transactions, &copied)
+		}
+	}
+	return transactions, nil
+}
+
+func (r *memoryTransactionRepo) List(filter domain.TransactionFilter) (*domain.TransactionPage, error) {
+	transactions, err := r.ListByAccountID(filter.AccountID)
+	return &domain.TransactionPage{Transactions: transactions}, err
+}
+
+func (r *memoryTransactionRepo) RecordFee(fee *domain.Transaction) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.transactions[fee.ID]; !ok {
+		stored := *fee
+		r.transactions[fee.ID] = &stored
+	}
+	charged, ok := r.transactions[fee.FeeOf]
+	if !ok {
+		return fmt.Errorf("transaction not found")
+	}
+	amount := fee.Amount
+	charged.Fee = &amount
+	charged.FeeTransactionID = fee.ID
+	return nil
+}
+
+func (r *memoryTransactionRepo) UpdateStatus(id string, update domain.StatusUpdate) error {
+	step := "update status"
+	if update.Status == constants.TransactionStatusProcessing {
+		step = "mark processing"
+	}
+	if err := r.faults.hit(step); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	transaction, ok := r.transactions[id]
+	if !ok {
+		return fmt.Errorf("transaction not found")
+	}
+	if transaction.Status == update.Status && update.Status != constants.TransactionStatusProcessing {
+		return nil
+	}
+	if err := domain.CheckTransactionTransition(transaction.Status, update.Status); err != nil {
+		return err
+	}
+	now := time.Now()
+	if update.Status == constants.TransactionStatusFailed {
+		transaction.Failure = update.Failure
+	}
+	transaction.StatusHistory = append(transaction.StatusHistory, domain.StatusChange{
+		From:     transaction.Status,
+		To:       update.Status,
+		At:       now,
+		Instance: update.Instance,
+	})
+	transaction.Status = update.Status
+	transaction.UpdatedAt = now
+	return nil
+}
+
+// In-memory stand-in for PostgreSQL. Each Do works on a copy of the state
+// that only replaces the committed state if fn and the commit succeed.
+type memoryLedger struct {
+	mu     sync.Mutex
+	state  *ledgerState
+	faults *faults
+	locks  [][]string // the IDs of every LockForUpdate call, in call order
+}
+
+type ledgerState struct {
+	accounts  map[string]domain.Account
+	entries   []domain.JournalEntry
+	history   []domain.BalanceHistoryEntry
+	inbox     map[string]domain.InboxRecord
+	holds     map[string]domain.Hold
+	reversed  map[string]money.Money
+	rules     []domain.LimitRule
+	usage     []domain.Transaction
+	schedules []domain.FeeSchedule
+	charges   map[string]domain.FeeCharge
+}
+
+func newMemoryLedger() *memoryLedger {
+	return &memoryLedger{state: &ledgerState{
+		accounts: make(map[string]domain.Account),
+		inbox:    make(map[string]domain.InboxRecord),
+		holds:    make(map[string]domain.Hold),
+		reversed: make(map[string]money.Money),
+		charges:  make(map[string]domain.FeeCharge),
+	}}
+}
+
+func (s *ledgerState) clone() *ledgerState {
+	copied := &ledgerState{
+		accounts:  make(map[string]domain.Account, len(s.accounts)),
+		entries:   append([]domain.JournalEntry(nil), s.entries...),
+		history:   append([]domain.BalanceHistoryEntry(nil), s.history...),
+		inbox:     make(map[string]domain.InboxRecord, len(s.inbox)),
+		holds:     make(map[string]domain.Hold, len(s.holds)),
+		reversed:  make(map[string]money.Money, len(s.reversed)),
+		rules:     append([]domain.LimitRule(nil), s.rules...),
+		usage:     append([]domain.Transaction(nil), s.usage...),
+		schedules: append([]domain.FeeSchedule(nil), s.schedules...),
+		charges:   make(map[string]domain.FeeCharge, len(s.charges)),
+	}
+	for id, account := range s.accounts {
+		copied.accounts[id] = account
+	}
+	for id, record := range s.inbox {
+		copied.inbox[id] = record
+	}
+	for id, hold := range s.holds {
+		copied.holds[id] = hold
+	}
+	for id, total := range s.reversed {
+		copied.reversed[id] = total
+	}
+	for id, charge := range s.charges {
+		copied.charges[id] = charge
+	}
+	return copied
+}
+
+func (l *memoryLedger) Do(fn func(tx domain.TxRepositories) error) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	state := l.state.clone()
+	repos := &memoryRepos{state: state, faults: l.faults, locks: &l.locks}
+	holds := &memoryHolds{state: state}
+	txRepos := domain.TxRepositories{
+		Accounts:  repos,
+		Journal:   repos,
+		Inbox:     repos,
+		Holds:     holds,
+		Reversals: &memoryReversals{state: state},
+		Limits:    &memoryLimits{state: state},
+		Fees:      &memoryFees{state: state},
+	}
+	if err := fn(txRepos); err != nil {
+		return err
+	}
+	if err := l.faults.hit("commit"); err != nil {
+		return err
+	}
+	l.state = state
+	return nil
+}
+
+func (l *memoryLedger) balance(accountID string) money.Money {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.state.accounts[accountID].Balance
+}
+
+// Implements the account, journal and inbox repositories over one state
+type memoryRepos struct {
+	state  *ledgerState
+	faults *faults
+	locks  *[][]string
+}
+
+func (r *memoryRepos) Create(account *domain.Account) error {
+	r.state.accounts[account.ID] = *account
+	return nil
+}
+
+func (r *memoryRepos) GetByID(id string) (*domain.Account, error) {
+	account, ok := r.state.accounts[id]
+	if !ok {
+		return nil, domain.ErrAccountNotFound
+	}
+	return &account, nil
+}
+
+func (r *memoryRepos) LockForUpdate(ids ...string) (map[string]*domain.Account, error) {
+	if err := r.faults.hit("lock accounts"); err != nil {
+		return nil, err
+	}
+	if r.locks != nil {
+		*r.locks = append(*r.locks, append([]string(nil), ids...))
+	}
+	accounts := make(map[string]*domain.Account, len(ids))
+	for _, id := range ids {
+		account, err := r.GetByID(id)
+		if err != nil {
+			return nil, err
+		}
+		accounts[id] = account
+	}
+	return accounts, nil
+}
+
+func (r *memoryRepos) List(filter domain.AccountFilter) (*domain.AccountPage, error) {
+	accounts := make([]*domain.Account, 0, len(r.state.accounts))
+	for _, account := range r.state.accounts {
+		copied := account
+		accounts = append(accounts, &copied)
+	}
+	return &domain.AccountPage{Accounts: accounts}, nil
+}
+
+func (r *memoryRepos) UpdateStatus(change *domain.AccountStatusChange) error {
+	account, ok := r.state.accounts[change.AccountID]
+	if !ok {
+		return domain.ErrAccountNotFound
+	}
+	account.Status = change.ToStatus
+	r.state.accounts[change.AccountID] = account
+	return nil
+}
+
+func (r *memoryRepos) ListStatusChanges(accountID string) ([]*domain.AccountStatusChange, error) {
+	return nil, nil
+}
+
+func (r *memoryRepos) UpdateOverdraftLimit(change *domain.OverdraftLimitChange) error {
+	account, ok := r.state.accounts[change.AccountID]
+	if !ok {
+		return domain.ErrAccountNotFound
+	}
+	account.OverdraftLimit = change.NewLimit
+	r.state.accounts[change.AccountID] = account
+	return nil
+}
+
+func (r *memoryRepos) ListOverdraftLimitChanges(accountID string) ([]*domain.OverdraftLimitChange, error) {
+	return nil, nil
+}
+
+func (r *memoryRepos) SetFeesWaived(id string, waived bool) error {
+	account, ok := r.state.accounts[id]
+	if !ok {
+		return domain.ErrAccountNotFound
+	}
+	account.FeesWaived = waived
+	r.state.accounts[id] = account
+	return nil
+}
+
+func (r *memoryRepos) Post(entry *domain.JournalEntry) error {
+	if err := r.faults.hit("post entry"); err != nil {
+		return err
+	}
+	if err := entry.Validate(); err != nil {
+		return err
+	}
+	changes, err := entry.BalanceChanges()
+	if err != nil {
+		return err
+	}
+	for accountID, change := range changes {
+		account, ok := r.state.accounts[accountID]
+		if !ok {
+			return domain.ErrAccountNotFound
+		}
+		if account.Balance, err = account.Balance.Add(change); err != nil {
+			return err
+		}
+		r.state.accounts[accountID] = account
+	}
+	entry.CreatedAt = time.Now()
+	r.state.entries = append(r.state.entries, *entry)
+	for accountID, change := range changes {
+		r.state.history = append(r.state.history, domain.BalanceHistoryEntry{
+			Sequence:      int64(len(r.state.history) + 1),
+			AccountID:     accountID,
+			EntryID:       entry.ID,
+			TransactionID: entry.TransactionID,
+			Change:        change,
+			Balance:       r.state.accounts[accountID].Balance,
+			CreatedAt:     entry.CreatedAt,
+		})
+	}
+	return nil
+}
+
+func (r *memoryRepos) BalanceHistoryAt(accountID string, asOf time.Time) (*domain.BalanceHistoryEntry, error) {
+	for i := len(r.state.history) - 1; i >= 0; i-- {
+		entry := r.state.history[i]
+		if entry.AccountID == accountID && !entry.CreatedAt.After(asOf) {
+			return &entry, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *memoryRepos) ListBalanceHistory(accountID string, filter domain.BalanceHistoryFilter) (*domain.BalanceHistoryPage, error) {
+	page := &domain.BalanceHistoryPage{}
+	for i := len(r.state.history) - 1; i >= 0; i-- {
+		entry := r.state.history[i]
+		if entry.AccountID == accountID {
+			page.Entries = append(page.Entries, &entry)
+		}
+	}
+	return page, nil
+}
+
+func (r *memoryRepos) ListStatementEntries(accountID string, from, to time.Time) ([]domain.StatementEntry, error) {
+	descriptions := make(map[string]string)
+	for _, entry := range r.state.entries {
+		descriptions[entry.ID] = entry.Description
+	}
+	var entries []domain.StatementEntry
+	for _, entry := range r.state.history {
+		if entry.AccountID == accountID && !entry.CreatedAt.Before(from) && entry.CreatedAt.Before(to) {
+			entries = append(entries, domain.StatementEntry{BalanceHistoryEntry: entry, EntryDescription: descriptions[entry.EntryID]})
+		}
+	}
+	return entries, nil
+}
+
+func (r *memoryRepos) ComputeBalance(accountID string, currency money.Currency) (money.Money, error) {
+	balance := money.Zero(currency)
+	for i := range r.state.entries {
+		changes, err := r.state.entries[i].BalanceChanges()
+		if err != nil {
+			return money.Money{}, err
+		}
+		if change, ok := changes[accountID]; ok {
+			if balance, err = balance.Add(change); err != nil {
+				return money.Money{}, err
+			}
+		}
+	}
+	return balance, nil
+}
+
+func (r *memoryRepos) ComputeBalanceAsOf(accountID string, currency money.Currency, asOf time.Time) (money.Money, error) {
+	balance := money.Zero(currency)
+	for i := range r.state.entries {
+		if !r.state.entries[i].CreatedAt.Before(asOf) {
+			continue
+		}
+		changes, err := r.state.entries[i].BalanceChanges()
+		if err != nil {
+			return money.Money{}, err
+		}
+		if change, ok := changes[accountID]; ok {
+			if balance, err = balance.Add(change); err != nil {
+				return money.Money{}, err
+			}
+		}
+	}
+	return balance, nil
+}
+
+func (r *memoryRepos) OpeningBalance(accountID string, currency money.Currency) (money.Money, error) {
+	balance := money.Zero(currency)
+	for i := range r.state.entries {
+		if r.state.entries[i].TransactionID != "" {
+			continue
+		}
+		changes, err := r.state.entries[i].BalanceChanges()
+		if err != nil {
+			return money.Money{}, err
+		}
+		if change, ok := changes[accountID]; ok {
+			if balance, err = balance.Add(change); err != nil {
+				return money.Money{}, err
+			}
+		}
+	}
+	return balance, nil
+}
+
+func (r *memoryRepos) ListAccountPostings(accountID string, from, to time.Time) ([]domain.AccountPosting, error) {
+	var postings []domain.AccountPosting
+	for _, entry := range r.state.entries {
+		if entry.CreatedAt.Before(from) || !entry.CreatedAt.Before(to) {
+			continue
+		}
+		for _, p := range entry.Postings {
+			if p.AccountID == accountID {
+				postings = append(postings, domain.AccountPosting{Posting: p, TransactionID: entry.TransactionID, EntryDescription: entry.Description})
+			}
+		}
+	}
+	return postings, nil
+}
+
+func (r *memoryRepos) RecomputeBalances() error {
+	return nil
+}
+
+func (r *memoryRepos) TrialBalance() ([]domain.TrialBalanceLine, error) {
+	return nil, nil
+}
+
+func (r *memoryRepos) Claim(transactionID string) (*domain.InboxRecord, error) {
+	if err := r.faults.hit("claim"); err != nil {
+		return nil, err
+	}
+	if record, ok := r.state.inbox[transactionID]; ok {
+		return &record, nil
+	}
+	r.state.inbox[transactionID] = domain.InboxRecord{TransactionID: transactionID, ProcessedAt: time.Now()}
+	return nil, nil
+}
+
+func (r *memoryRepos) SetOutcome(transactionID string, outcome domain.InboxOutcome, failure *domain.TransactionFailure) error {
+	if err := r.faults.hit("set outcome"); err != nil {
+		return err
+	}
+	record := r.state.inbox[transactionID]
+	record.Outcome = outcome
+	record.FailureCode, record.Reason = "", ""
+	if failure != nil {
+		record.FailureCode, record.Reason = failure.Code, failure.Message
+	}
+	r.state.inbox[transactionID] = record
+	return nil
+}
+
+func (r *memoryRepos) Get(transactionID string) (*domain.InboxRecord, error) {
+	if record, ok := r.state.inbox[transactionID]; ok {
+		return &record, nil
+	}
+	return nil, nil
+}
+
+// Hold repository over the same state; kept apart from memoryRepos because
+// its method names clash with the account repository's
+type memoryHolds struct {
+	state *ledgerState
+}
+
+func (h *memoryHolds) Create(hold *domain.Hold) error {
+	h.state.holds[hold.ID] = *hold
+	account := h.state.accounts[hold.AccountID]
+	held, err := account.Held.Add(hold.Amount)
+	if err != nil {
+		return err
+	}
+	account.Held = held
+	h.state.accounts[hold.AccountID] = account
+	return nil
+}
+
+func (h *memoryHolds) GetByID(id string) (*domain.Hold, error) {
+	hold, ok := h.state.holds[id]
+	if !ok {
+		return nil, domain.ErrHoldNotFound
+	}
+	return &hold, nil
+}
+
+func (h *memoryHolds) LockForUpdate(id string) (*domain.Hold, error) {
+	return h.GetByID(id)
+}
+
+func (h *memoryHolds) ListByAccountID(accountID string) ([]*domain.Hold, error) {
+	var holds []*domain.Hold
+	for _, hold := range h.state.holds {
+		if hold.AccountID == accountID {
+			copied := hold
+			holds = append(holds, &copied)
+		}
+	}
+	return holds, nil
+}
+
+func (h *memoryHolds) ListExpired(now time.Time, limit int) ([]*domain.Hold, error) {
+	return nil, nil
+}
+
+func (h *memoryHolds) Release(id string, status domain.HoldStatus, captured *money.Money, transactionID string) error {
+	hold, ok := h.state.holds[id]
+	if !ok {
+		return domain.ErrHoldNotFound
+	}
+	if hold.Status != domain.HoldStatusActive {
+		return domain.ErrHoldNotActive
+	}
+	hold.Status = status
+	hold.CapturedAmount = captured
+	hold.TransactionID = transactionID
+	h.state.holds[id] = hold
+
+	account := h.state.accounts[hold.AccountID]
+	held, err := account.Held.Sub(hold.Amount)
+	if err != nil {
+		return err
+	}
+	account.Held = held
+	h.state.accounts[hold.AccountID] = account
+	return nil
+}
+
+type memoryReversals struct {
+	state *ledgerState
+}
+
+func (r *memoryReversals) Add(originalID string, amount, limit money.Money) error {
+	total, ok := r.state.reversed[originalID]
+	if !ok {
+		total = money.Zero(amount.Currency)
+	}
+	total, err := total.Add(amount)
+	if err != nil {
+		return err
+	}
+	if exceeds, _ := limit.LessThan(total); exceeds {
+		return domain.ErrReversalExceedsOriginal
+	}
+	r.state.reversed[originalID] = total
+	return nil
+}
+
+// Limit rules and usage over the same state; usage keeps the applied
+// transactions themselves
+type memoryLimits struct {
+	state *ledgerState
+}
+
+func (l *memoryLimits) CreateRule(rule *domain.LimitRule) error {
+	l.state.rules = append(l.state.rules, *rule)
+	return nil
+}
+
+func (l *memoryLimits) GetRule(id string) (*domain.LimitRule, error) {
+	for _, rule := range l.state.rules {
+		if rule.ID == id {
+			return &rule, nil
+		}
+	}
+	return nil, domain.ErrLimitRuleNotFound
+}
+
+func (l *memoryLimits) UpdateRule(rule *domain.LimitRule) error {
+	for i := range l.state.rules {
+		if l.state.rules[i].ID == rule.ID {
+			l.state.rules[i] = *rule
+			return nil
+		}
+	}
+	return domain.ErrLimitRuleNotFound
+}
+
+func (l *memoryLimits) DeleteRule(id string) error {
+	for i := range l.state.rules {
+		if l.state.rules[i].ID == id {
+			l.state.rules = append(l.state.rules[:i], l.state.rules[i+1:]...)
+			return nil
+		}
+	}
+	return domain.ErrLimitRuleNotFound
+}
+
+func (l *memoryLimits) ListRules() ([]*domain.LimitRule, error) {
+	rules := make([]*domain.LimitRule, 0, len(l.state.rules))
+	for i := range l.state.rules {
+		rule := l.state.rules[i]
+		rules = append(rules, &rule)
+	}
+	return rules, nil
+}
+
+func (l *memoryLimits) ListApplicableRules(account *domain.Account, transactionType constants.TransactionType) ([]*domain.LimitRule, error) {
+	var rules []*domain.LimitRule
+	for i := range l.state.rules {
+		if rule := l.state.rules[i]; rule.Applies(account, transactionType) {
+			rules = append(rules, &rule)
+		}
+	}
+	return rules, nil
+}
+
+func (l *memoryLimits) Usage(accountID string, transactionType constants.TransactionType, currency money.Currency, since time.Time) (domain.LimitUsage, error) {
+	usage := domain.LimitUsage{Total: money.Zero(currency)}
+	for _, transaction := range l.state.usage {
+		if transaction.AccountID != accountID || transaction.Type != transactionType || !transaction.UpdatedAt.After(since) {
+			continue
+		}
+		usage.Count++
+		if transaction.Amount.Currency == currency {
+			usage.Total, _ = usage.Total.Add(transaction.Amount)
+		}
+	}
+	return usage, nil
+}
+
+func (l *memoryLimits) RecordUsage(transaction *domain.Transaction) error {
+	recorded := *transaction
+	recorded.UpdatedAt = time.Now()
+	l.state.usage = append(l.state.usage, recorded)
+	return nil
+}
+
+// Fee schedules and charges over the same state; charges are keyed by the
+// transaction they were charged for
+type memoryFees struct {
+	state *ledgerState
+}
+
+func (f *memoryFees) CreateSchedule(schedule *domain.FeeSchedule) error {
+	f.state.schedules = append(f.state.schedules, *schedule)
+	return nil
+}
+
+func (f *memoryFees) GetSchedule(id string) (*domain.FeeSchedule, error) {
+	for _, schedule := range f.state.schedules {
+		if schedule.ID == id {
+			return &schedule, nil
+		}
+	}
+	return nil, domain.ErrFeeScheduleNotFound
+}
+
+func (f *memoryFees) UpdateSchedule(schedule *domain.FeeSchedule) error {
+	return nil
+}
+
+func (f *memoryFees) DeleteSchedule(id string) error {
+	return nil
+}
+
+func (f *memoryFees) ListSchedules() ([]*domain.FeeSchedule, error) {
+	return f.ListApplicableSchedules(nil, "")
+}
+
+func (f *memoryFees) ListApplicableSchedules(account *domain.Account, transactionType constants.TransactionType) ([]*domain.FeeSchedule, error) {
+	var schedules []*domain.FeeSchedule
+	for i := range f.state.schedules {
+		schedule := f.state.schedules[i]
+		if account == nil || schedule.Applies(account, transactionType) {
+			schedules = append(schedules, &schedule)
+		}
+	}
+	return schedules, nil
+}
+
+func (f *memoryFees) RecordCharge(charge *domain.FeeCharge) error {
+	f.state.charges[charge.TransactionID] = *charge
+	return nil
+}
+
+func (f *memoryFees) GetCharge(transactionID string) (*domain.FeeCharge, error) {
+	if charge, ok := f.state.charges[transactionID]; ok {
+		return &charge, nil
+	}
+	return nil, nil
+}
@@ -4,25 +4,39 @@ import (
 	"banking-ledger/internal/constants"
 	"banking-ledger/internal/domain"
 	"banking-ledger/internal/models"
+	"banking-ledger/internal/money"
 	"context"
+	"errors"
+	"fmt"
 	"log"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
 )
 
 type TransactionProcessor struct {
-	accountRepo     domain.AccountRepository
 	transactionRepo domain.TransactionRepository
+	unitOfWork      domain.UnitOfWork
+	instanceID      string // recorded in the status history of what it processes
 }
 
 func NewTransactionProcessor(
-	accountRepo domain.AccountRepository,
 	transactionRepo domain.TransactionRepository,
+	unitOfWork domain.UnitOfWork,
+	instanceID string,
 ) *TransactionProcessor {
 	return &TransactionProcessor{
-		accountRepo:     accountRepo,
 		transactionRepo: transactionRepo,
+		unitOfWork:      unitOfWork,
+		instanceID:      instanceID,
 	}
 }
 
+// ProcessTransaction applies a queued transaction exactly once. The message
+// may be delivered any number of times: the inbox row written alongside the
+// postings records the decision, and every later delivery only finishes the
+// Mongo status update that an earlier attempt may not have reached.
 func (p *TransactionProcessor) ProcessTransaction(ctx context.Context, msg models.TransactionMessage) error {
 	transaction, err := p.transactionRepo.GetByID(msg.TransactionID)
 	if err != nil {
@@ -30,51 +44,300 @@ func (p *TransactionProcessor) ProcessTransaction(ctx context.Context, msg model
 		return err
 	}
 
-	account, err := p.accountRepo.GetByID(msg.AccountID)
-	if err != nil {
-		log.Printf("Failed to retrieve account %s: %v", msg.AccountID, err)
-		// Mark transaction as failed
-		_ = p.transactionRepo.UpdateStatus(transaction.ID, constants.TransactionStatusFailed)
+	// A transaction left processing was picked up by an attempt that did not
+	// finish; the inbox tells whether its money already moved
+	if transaction.Status != constants.TransactionStatusPending && transaction.Status != constants.TransactionStatusProcessing {
+		log.Printf("Transaction %s is already %s, skipping redelivery", transaction.ID, transaction.Status)
+		return nil
+	}
+	if err := p.updateStatus(transaction.ID, constants.TransactionStatusProcessing, nil); err != nil {
+		log.Printf("Failed to mark transaction %s processing: %v", transaction.ID, err)
 		return err
 	}
 
-	// Calculate new balance based on transaction type
-	var newBalance float64
-	switch transaction.Type {
-	case constants.TransactionTypeDeposit:
-		newBalance = account.Balance + transaction.Amount
-	case constants.TransactionTypeWithdrawal:
-		// Double check balance sufficiency
-		if account.Balance < transaction.Amount {
-			log.Printf("Insufficient funds in account %s for transaction %s", account.ID, transaction.ID)
-			// Mark transaction as failed
-			_ = p.transactionRepo.UpdateStatus(transaction.ID, constants.TransactionStatusFailed)
-			return nil // Don't retry
-		}
-		newBalance = account.Balance - transaction.Amount
-	default:
-		log.Printf("Unknown transaction type: %s", transaction.Type)
-		// Mark transaction as failed
-		_ = p.transactionRepo.UpdateStatus(transaction.ID, constants.TransactionStatusFailed)
+	var original *domain.Transaction
+	if transaction.ReversalOf != "" {
+		original, err = p.transactionRepo.GetByID(transaction.ReversalOf)
+		if err != nil && !errors.Is(err, domain.ErrTransactionNotFound) {
+			log.Printf("Failed to retrieve original transaction %s: %v", transaction.ReversalOf, err)
+			return err
+		}
+	}
+
+	entry, err := newEntry(transaction, original)
+	if err != nil {
+		log.Printf("Cannot build journal entry for transaction %s: %v", transaction.ID, err)
+		failure := &domain.TransactionFailure{Code: domain.FailureInvalidTransaction, Message: err.Error()}
+		_ = p.updateStatus(transaction.ID, constants.TransactionStatusFailed, failure)
 		return nil // Don't retry
 	}
 
-	// Update account balance
-	if err := p.accountRepo.UpdateBalance(account.ID, newBalance); err != nil {
-		log.Printf("Failed to update balance for account %s: %v", account.ID, err)
-		// Mark transaction as failed
-		_ = p.transactionRepo.UpdateStatus(transaction.ID, constants.TransactionStatusFailed)
+	// The inbox claim, funds check and postings share one database
+	// transaction, so every leg of the entry and its fee are applied once or
+	// not at all
+	var outcome domain.InboxOutcome
+	var failure *domain.TransactionFailure
+	var fee *domain.FeeCharge
+	err = p.unitOfWork.Do(func(tx domain.TxRepositories) error {
+		prior, err := tx.Inbox.Claim(transaction.ID)
+		if err != nil {
+			return err
+		}
+		if prior != nil {
+			log.Printf("Transaction %s was already %s, finishing status update", transaction.ID, prior.Outcome)
+			outcome = prior.Outcome
+			failure = prior.Failure()
+			if outcome == domain.InboxOutcomeApplied {
+				fee, err = tx.Fees.GetCharge(transaction.ID)
+			}
+			return err
+		}
+
+		hold, err := lockCapturedHold(tx, transaction)
+		var accounts map[string]*domain.Account
+		if err == nil {
+			accounts, err = lockAccounts(tx, transaction, entry)
+		}
+		var feeEntry *domain.JournalEntry
+		if err == nil {
+			fee, feeEntry, err = chargeFee(tx, accounts, transaction)
+		}
+		if err == nil {
+			err = checkFunds(accounts, hold, entry, feeEntry)
+		}
+		if err == nil {
+			err = checkLimits(tx, transaction)
+		}
+		// Counted last, so a rejection above leaves the running total alone
+		if err == nil && original != nil {
+			err = tx.Reversals.Add(original.ID, transaction.Amount, original.Amount)
+		}
+		if domain.IsRejection(err) {
+			log.Printf("Transaction %s rejected: %v", transaction.ID, err)
+			outcome = domain.InboxOutcomeRejected
+			failure = domain.FailureFor(err)
+			return tx.Inbox.SetOutcome(transaction.ID, outcome, failure)
+		} else if err != nil {
+			return err
+		}
+
+		if err := tx.Journal.Post(entry); err != nil {
+			return err
+		}
+		if fee != nil {
+			if err := tx.Journal.Post(feeEntry); err != nil {
+				return err
+			}
+			if err := tx.Fees.RecordCharge(fee); err != nil {
+				return err
+			}
+		}
+		if hold != nil {
+			captured := transaction.Amount
+			if err := tx.Holds.Release(hold.ID, domain.HoldStatusCaptured, &captured, transaction.ID); err != nil {
+				return err
+			}
+		}
+		if domain.IsLimitedType(transaction.Type) {
+			if err := tx.Limits.RecordUsage(transaction); err != nil {
+				return err
+			}
+		}
+		outcome = domain.InboxOutcomeApplied
+		return tx.Inbox.SetOutcome(transaction.ID, outcome, nil)
+	})
+	if err != nil {
+		log.Printf("Failed to apply transaction %s: %v", transaction.ID, err)
 		return err
 	}
 
-	// Mark transaction as completed
-	if err := p.transactionRepo.UpdateStatus(transaction.ID, constants.TransactionStatusCompleted); err != nil {
+	// Written before the status, so a completed transaction always shows its fee
+	if fee != nil {
+		if err := p.transactionRepo.RecordFee(fee.Transaction()); err != nil {
+			log.Printf("Failed to record fee for transaction %s: %v", transaction.ID, err)
+			return err
+		}
+	}
+
+	status := constants.TransactionStatusCompleted
+	if outcome == domain.InboxOutcomeRejected {
+		status = constants.TransactionStatusFailed
+	}
+	if err := p.updateStatus(transaction.ID, status, failure); err != nil {
+		// The ledger already holds the outcome; the redelivery will retry this,
+		// and cmd/reconcile -repair can finish it if redelivery gives up
 		log.Printf("Failed to update transaction status: %v", err)
-		// This is problematic as the balance was updated but the transaction status wasn't
-		//  we'd need to handle this inconsistency
 		return err
 	}
 
-	log.Printf("Successfully processed transaction %s for account %s", transaction.ID, account.ID)
+	log.Printf("Processed %s transaction %s for account %s: %s", transaction.Type, transaction.ID, transaction.AccountID, status)
 	return nil
 }
+
+func (p *TransactionProcessor) updateStatus(id string, status constants.TransactionStatus, failure *domain.TransactionFailure) error {
+	return p.transactionRepo.UpdateStatus(id, domain.StatusUpdate{
+		Status:   status,
+		Failure:  failure,
+		Instance: p.instanceID,
+	})
+}
+
+// Builds the journal entry for a transaction; reversals and refunds mirror
+// the entry of the original they undo
+func newEntry(transaction, original *domain.Transaction) (*domain.JournalEntry, error) {
+	if transaction.ReversalOf == "" {
+		return domain.NewTransactionEntry(transaction)
+	}
+	if original == nil {
+		return nil, fmt.Errorf("original transaction %s not found", transaction.ReversalOf)
+	}
+	if err := original.CheckReversible(); err != nil {
+		return nil, err
+	}
+	return domain.NewReversalEntry(original, transaction)
+}
+
+// Locks the hold a capture settles and checks it can still be captured.
+// Returns nil for every other transaction type.
+func lockCapturedHold(tx domain.TxRepositories, transaction *domain.Transaction) (*domain.Hold, error) {
+	if transaction.HoldID == "" {
+		return nil, nil
+	}
+	hold, err := tx.Holds.LockForUpdate(transaction.HoldID)
+	if err != nil {
+		return nil, err
+	}
+	if hold.AccountID != transaction.AccountID {
+		return nil, fmt.Errorf("%w: hold %s belongs to another account", domain.ErrHoldNotActive, hold.ID)
+	}
+	if err := hold.CheckCapture(transaction.Amount, time.Now()); err != nil {
+		return nil, err
+	}
+	return hold, nil
+}
+
+// Locks every customer account the transaction can move, in a single call and
+// in ID order, so two transactions over the same accounts always queue for
+// them in the same order whichever way the money goes. A fee is debited from
+// the transaction's account and credited to a system account, which has no
+// row, so the set is known before the fee is priced.
+func lockAccounts(tx domain.TxRepositories, transaction *domain.Transaction, entry *domain.JournalEntry) (map[string]*domain.Account, error) {
+	changes, err := entry.BalanceChanges()
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]string, 0, len(changes)+1)
+	for accountID := range changes {
+		ids = append(ids, accountID)
+	}
+	if _, ok := changes[transaction.AccountID]; !ok && domain.IsFeeableType(transaction.Type) {
+		ids = append(ids, transaction.AccountID)
+	}
+	sort.Strings(ids)
+	return tx.Accounts.LockForUpdate(ids...)
+}
+
+// Prices the transaction with the schedules that apply to its account, read
+// from the locked row so a waiver cannot change before the postings commit.
+// Returns the charge and the entry that posts it, or nils when no fee is due.
+func chargeFee(tx domain.TxRepositories, accounts map[string]*domain.Account, transaction *domain.Transaction) (*domain.FeeCharge, *domain.JournalEntry, error) {
+	if !domain.IsFeeableType(transaction.Type) {
+		return nil, nil, nil
+	}
+	account := accounts[transaction.AccountID]
+	schedules, err := tx.Fees.ListApplicableSchedules(account, transaction.Type)
+	if err != nil {
+		return nil, nil, err
+	}
+	charge, err := domain.NewFeeCharge(schedules, account, transaction)
+	if err != nil || charge == nil {
+		return nil, nil, err
+	}
+	charge.ID = uuid.New().String()
+	charge.CreatedAt = time.Now()
+
+	entry, err := domain.NewTransactionEntry(charge.Transaction())
+	if err != nil {
+		return nil, nil, err
+	}
+	return charge, entry, nil
+}
+
+// Double checks, against the rows lockAccounts locked, that each account the
+// entries touch allows the movement and that the debited ones can cover it
+// from their available balance and overdraft limit; funds reserved by the
+// hold being captured count as available. The locks are held until the
+// postings commit, so concurrent processors cannot both spend the same funds,
+// and a status change made after the message was queued still applies. Nil
+// entries are skipped.
+func checkFunds(accounts map[string]*domain.Account, hold *domain.Hold, entries ...*domain.JournalEntry) error {
+	changes := make(map[string]money.Money)
+	for _, entry := range entries {
+		if entry == nil {
+			continue
+		}
+		entryChanges, err := entry.BalanceChanges()
+		if err != nil {
+			return err
+		}
+		for accountID, change := range entryChanges {
+			if total, ok := changes[accountID]; ok {
+				if change, err = total.Add(change); err != nil {
+					return err
+				}
+			}
+			changes[accountID] = change
+		}
+	}
+
+	for accountID, change := range changes {
+		if _, ok := accounts[accountID]; !ok {
+			return fmt.Errorf("account %s was not locked", accountID)
+		}
+		// Postings must be in the currency the account is held in
+		if accounts[accountID].Currency != change.Currency {
+			return money.ErrCurrencyMismatch
+		}
+		if !change.IsNegative() {
+			if err := accounts[accountID].CheckCredit(); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := accounts[accountID].CheckDebit(); err != nil {
+			return err
+		}
+		// The captured hold's funds are already set aside for this debit
+		needed := change.Neg()
+		if hold != nil && hold.AccountID == accountID {
+			var err error
+			if needed, err = needed.Sub(hold.Amount); err != nil {
+				return err
+			}
+			if !needed.IsPositive() {
+				continue
+			}
+		}
+		if err := accounts[accountID].CheckFunds(needed); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Checks the account's limit rules again, now that the account row is locked
+// and usage includes every transaction applied before this one
+func checkLimits(tx domain.TxRepositories, transaction *domain.Transaction) error {
+	if !domain.IsLimitedType(transaction.Type) {
+		return nil
+	}
+	account, err := tx.Accounts.GetByID(transaction.AccountID)
+	if err != nil {
+		return err
+	}
+	rules, err := tx.Limits.ListApplicableRules(account, transaction.Type)
+	if err != nil {
+		return err
+	}
+	return domain.CheckLimits(rules, tx.Limits, account, transaction, time.Now())
+}
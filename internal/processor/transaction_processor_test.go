@@ -0,0 +1,650 @@
+package processor
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"banking-ledger/internal/constants"
+	"banking-ledger/internal/domain"
+	"banking-ledger/internal/models"
+	"banking-ledger/internal/money"
+)
+
+func setupProcessor(t *testing.T, transaction domain.Transaction) (*TransactionProcessor, *memoryTransactionRepo, *memoryLedger) {
+	t.Helper()
+
+	ledger := newMemoryLedger()
+	for _, id := range []string{"acc-1", "acc-2"} {
+		ledger.state.accounts[id] = domain.Account{
+			ID:       id,
+			Currency: money.USD,
+			Balance:  money.MustParse("100.00", money.USD),
+			Held:     money.Zero(money.USD),
+			Status:   domain.AccountStatusActive,
+		}
+	}
+
+	transactions := newMemoryTransactionRepo()
+	transaction.Status = constants.TransactionStatusPending
+	_ = transactions.Create(&transaction)
+
+	return NewTransactionProcessor(transactions, ledger, "processor-test"), transactions, ledger
+}
+
+// Every step of ProcessTransaction can die; whatever the crash point, the
+// broker redelivers and the money must have moved exactly once
+func TestProcessTransactionSurvivesCrashes(t *testing.T) {
+	steps := []string{
+		"get transaction",
+		"mark processing",
+		"claim",
+		"lock accounts",
+		"post entry",
+		"set outcome",
+		"commit",
+		"update status",
+	}
+	transfer := domain.Transaction{
+		ID:          "txn-1",
+		AccountID:   "acc-1",
+		ToAccountID: "acc-2",
+		Type:        constants.TransactionTypeTransfer,
+		Amount:      money.MustParse("30.00", money.USD),
+	}
+	msg := models.TransactionMessage{TransactionID: transfer.ID, AccountID: transfer.AccountID}
+
+	for _, step := range steps {
+		t.Run("crash at "+step, func(t *testing.T) {
+			processor, transactions, ledger := setupProcessor(t, transfer)
+			crash := &faults{step: step}
+			transactions.faults = crash
+			ledger.faults = crash
+
+			if err := processor.ProcessTransaction(context.Background(), msg); !errors.Is(err, errCrash) {
+				t.Fatalf("first delivery error = %v, want simulated crash", err)
+			}
+			// Redeliver twice: once to recover, once more to prove it is a no-op
+			for i := 0; i < 2; i++ {
+				if err := processor.ProcessTransaction(context.Background(), msg); err != nil {
+					t.Fatalf("redelivery %d returned an error: %v", i+1, err)
+				}
+			}
+
+			if got := ledger.balance("acc-1").String(); got != "70.00" {
+				t.Errorf("source balance = %s, want 70.00", got)
+			}
+			if got := ledger.balance("acc-2").String(); got != "130.00" {
+				t.Errorf("destination balance = %s, want 130.00", got)
+			}
+			if n := len(ledger.state.entries); n != 1 {
+				t.Errorf("journal entries = %d, want 1", n)
+			}
+			if n := len(ledger.state.history); n != 2 {
+				t.Errorf("balance history rows = %d, want 2", n)
+			}
+			stored, _ := transactions.GetByID(transfer.ID)
+			if stored.Status != constants.TransactionStatusCompleted {
+				t.Errorf("status = %s, want completed", stored.Status)
+			}
+		})
+	}
+}
+
+func TestProcessTransactionRecordsBalanceHistory(t *testing.T) {
+	transfer := domain.Transaction{
+		ID:          "txn-history",
+		AccountID:   "acc-1",
+		ToAccountID: "acc-2",
+		Type:        constants.TransactionTypeTransfer,
+		Amount:      money.MustParse("30.00", money.USD),
+	}
+	processor, _, ledger := setupProcessor(t, transfer)
+	msg := models.TransactionMessage{TransactionID: transfer.ID, AccountID: transfer.AccountID}
+	before := time.Now()
+
+	if err := processor.ProcessTransaction(context.Background(), msg); err != nil {
+		t.Fatalf("ProcessTransaction returned an error: %v", err)
+	}
+
+	journal := &memoryRepos{state: ledger.state}
+	for accountID, want := range map[string]struct{ change, balance string }{
+		"acc-1": {"-30.00", "70.00"},
+		"acc-2": {"30.00", "130.00"},
+	} {
+		last, err := journal.BalanceHistoryAt(accountID, time.Now())
+		if err != nil {
+			t.Fatalf("BalanceHistoryAt returned an error: %v", err)
+		}
+		if last == nil {
+			t.Fatalf("no balance history for %s", accountID)
+		}
+		if last.TransactionID != transfer.ID || last.Change.String() != want.change || last.Balance.String() != want.balance {
+			t.Errorf("%s history = %s %s %s, want %s %s %s", accountID,
+				last.TransactionID, last.Change, last.Balance, transfer.ID, want.change, want.balance)
+		}
+		if earlier, _ := journal.BalanceHistoryAt(accountID, before.Add(-time.Second)); earlier != nil {
+			t.Errorf("%s has history before the transfer: %+v", accountID, earlier)
+		}
+	}
+}
+
+func TestProcessTransactionRedeliveredAfterCompletion(t *testing.T) {
+	deposit := domain.Transaction{
+		ID:        "txn-2",
+		AccountID: "acc-1",
+		Type:      constants.TransactionTypeDeposit,
+		Amount:    money.MustParse("5.00", money.USD),
+	}
+	processor, _, ledger := setupProcessor(t, deposit)
+	msg := models.TransactionMessage{TransactionID: deposit.ID, AccountID: deposit.AccountID}
+
+	for i := 0; i < 3; i++ {
+		if err := processor.ProcessTransaction(context.Background(), msg); err != nil {
+			t.Fatalf("delivery %d returned an error: %v", i+1, err)
+		}
+	}
+
+	if got := ledger.balance("acc-1").String(); got != "105.00" {
+		t.Errorf("balance = %s, want 105.00", got)
+	}
+}
+
+func TestProcessTransactionRejectionIsRemembered(t *testing.T) {
+	withdrawal := domain.Transaction{
+		ID:        "txn-3",
+		AccountID: "acc-1",
+		Type:      constants.TransactionTypeWithdrawal,
+		Amount:    money.MustParse("150.00", money.USD),
+	}
+	processor, transactions, ledger := setupProcessor(t, withdrawal)
+	transactions.faults = &faults{step: "update status"}
+	msg := models.TransactionMessage{TransactionID: withdrawal.ID, AccountID: withdrawal.AccountID}
+
+	if err := processor.ProcessTransaction(context.Background(), msg); !errors.Is(err, errCrash) {
+		t.Fatalf("first delivery error = %v, want simulated crash", err)
+	}
+
+	// Funds arrive before the redelivery; the earlier rejection must stand
+	account := ledger.state.accounts["acc-1"]
+	account.Balance = money.MustParse("500.00", money.USD)
+	ledger.state.accounts["acc-1"] = account
+
+	if err := processor.ProcessTransaction(context.Background(), msg); err != nil {
+		t.Fatalf("redelivery returned an error: %v", err)
+	}
+
+	stored, _ := transactions.GetByID(withdrawal.ID)
+	if stored.Status != constants.TransactionStatusFailed {
+		t.Errorf("status = %s, want failed", stored.Status)
+	}
+	if stored.Failure == nil || stored.Failure.Code != domain.FailureInsufficientFunds {
+		t.Errorf("failure = %+v, want %s", stored.Failure, domain.FailureInsufficientFunds)
+	}
+	if got := ledger.balance("acc-1").String(); got != "500.00" {
+		t.Errorf("balance = %s, want 500.00", got)
+	}
+}
+
+func TestProcessTransactionRecordsStatusHistory(t *testing.T) {
+	withdrawal := domain.Transaction{
+		ID:        "txn-status-history",
+		AccountID: "acc-1",
+		Type:      constants.TransactionTypeWithdrawal,
+		Amount:    money.MustParse("150.00", money.USD),
+	}
+	processor, transactions, _ := setupProcessor(t, withdrawal)
+	msg := models.TransactionMessage{TransactionID: withdrawal.ID, AccountID: withdrawal.AccountID}
+
+	for i := 0; i < 2; i++ {
+		if err := processor.ProcessTransaction(context.Background(), msg); err != nil {
+			t.Fatalf("delivery %d returned an error: %v", i+1, err)
+		}
+	}
+
+	stored, _ := transactions.GetByID(withdrawal.ID)
+	if stored.Failure == nil || stored.Failure.Code != domain.FailureInsufficientFunds || stored.Failure.Message == "" {
+		t.Errorf("failure = %+v, want %s with a message", stored.Failure, domain.FailureInsufficientFunds)
+	}
+	want := []domain.StatusChange{
+		{To: constants.TransactionStatusPending},
+		{From: constants.TransactionStatusPending, To: constants.TransactionStatusProcessing, Instance: "processor-test"},
+		{From: constants.TransactionStatusProcessing, To: constants.TransactionStatusFailed, Instance: "processor-test"},
+	}
+	if len(stored.StatusHistory) != len(want) {
+		t.Fatalf("status history = %+v, want %d changes", stored.StatusHistory, len(want))
+	}
+	for i, change := range stored.StatusHistory {
+		change.At = time.Time{}
+		if change != want[i] {
+			t.Errorf("status change %d = %+v, want %+v", i, change, want[i])
+		}
+	}
+
+	err := transactions.UpdateStatus(withdrawal.ID, domain.StatusUpdate{Status: constants.TransactionStatusCompleted})
+	if !errors.Is(err, domain.ErrInvalidTransactionTransition) {
+		t.Errorf("completing a failed transaction = %v, want %v", err, domain.ErrInvalidTransactionTransition)
+	}
+}
+
+func TestProcessTransactionRejectsCurrencyMismatch(t *testing.T) {
+	deposit := domain.Transaction{
+		ID:        "txn-4",
+		AccountID: "acc-1",
+		Type:      constants.TransactionTypeDeposit,
+		Amount:    money.MustParse("10.00", money.EUR),
+	}
+	processor, transactions, ledger := setupProcessor(t, deposit)
+	msg := models.TransactionMessage{TransactionID: deposit.ID, AccountID: deposit.AccountID}
+
+	if err := processor.ProcessTransaction(context.Background(), msg); err != nil {
+		t.Fatalf("ProcessTransaction returned an error: %v", err)
+	}
+
+	stored, _ := transactions.GetByID(deposit.ID)
+	if stored.Status != constants.TransactionStatusFailed {
+		t.Errorf("status = %s, want failed", stored.Status)
+	}
+	if got := ledger.balance("acc-1").String(); got != "100.00" {
+		t.Errorf("balance = %s, want 100.00", got)
+	}
+}
+
+// The message was queued while the account was active; the freeze that
+// happened since must still stop it
+func TestProcessTransactionRejectsFrozenAccount(t *testing.T) {
+	deposit := domain.Transaction{
+		ID:        "txn-5",
+		AccountID: "acc-1",
+		Type:      constants.TransactionTypeDeposit,
+		Amount:    money.MustParse("10.00", money.USD),
+	}
+	processor, transactions, ledger := setupProcessor(t, deposit)
+	account := ledger.state.accounts["acc-1"]
+	account.Status = domain.AccountStatusFrozen
+	ledger.state.accounts["acc-1"] = account
+	msg := models.TransactionMessage{TransactionID: deposit.ID, AccountID: deposit.AccountID}
+
+	if err := processor.ProcessTransaction(context.Background(), msg); err != nil {
+		t.Fatalf("ProcessTransaction returned an error: %v", err)
+	}
+
+	stored, _ := transactions.GetByID(deposit.ID)
+	if stored.Status != constants.TransactionStatusFailed {
+		t.Errorf("status = %s, want failed", stored.Status)
+	}
+	if got := ledger.balance("acc-1").String(); got != "100.00" {
+		t.Errorf("balance = %s, want 100.00", got)
+	}
+}
+
+// Capturing part of a hold spends the held funds, even though the rest of the
+// balance is reserved by it, and releases what was not captured
+func TestProcessTransactionCapturesHold(t *testing.T) {
+	capture := domain.Transaction{
+		ID:        "txn-6",
+		AccountID: "acc-1",
+		Type:      constants.TransactionTypeCapture,
+		Amount:    money.MustParse("60.00", money.USD),
+		HoldID:    "hold-1",
+	}
+	processor, transactions, ledger := setupProcessor(t, capture)
+	err := (&memoryHolds{state: ledger.state}).Create(&domain.Hold{
+		ID:        "hold-1",
+		AccountID: "acc-1",
+		Amount:    money.MustParse("80.00", money.USD),
+		Status:    domain.HoldStatusActive,
+		ExpiresAt: time.Now().Add(time.Hour),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg := models.TransactionMessage{TransactionID: capture.ID, AccountID: capture.AccountID}
+
+	if err := processor.ProcessTransaction(context.Background(), msg); err != nil {
+		t.Fatalf("ProcessTransaction returned an error: %v", err)
+	}
+
+	stored, _ := transactions.GetByID(capture.ID)
+	if stored.Status != constants.TransactionStatusCompleted {
+		t.Errorf("status = %s, want completed", stored.Status)
+	}
+	account := ledger.state.accounts["acc-1"]
+	if got := account.Balance.String(); got != "40.00" {
+		t.Errorf("ledger balance = %s, want 40.00", got)
+	}
+	if !account.Held.IsZero() {
+		t.Errorf("held balance = %s, want 0.00", account.Held)
+	}
+	hold := ledger.state.holds["hold-1"]
+	if hold.Status != domain.HoldStatusCaptured || hold.CapturedAmount.String() != "60.00" {
+		t.Errorf("hold = %s capturing %v, want captured 60.00", hold.Status, hold.CapturedAmount)
+	}
+
+	// A second capture of the same hold must be rejected
+	again := capture
+	again.ID = "txn-7"
+	again.Status = constants.TransactionStatusPending
+	_ = transactions.Create(&again)
+	msg.TransactionID = again.ID
+	if err := processor.ProcessTransaction(context.Background(), msg); err != nil {
+		t.Fatalf("ProcessTransaction returned an error: %v", err)
+	}
+	stored, _ = transactions.GetByID(again.ID)
+	if stored.Status != constants.TransactionStatusFailed {
+		t.Errorf("second capture status = %s, want failed", stored.Status)
+	}
+}
+
+// Two refunds queued before either was applied cannot together return more
+// than the original deposit
+func TestProcessTransactionRefundsAddUp(t *testing.T) {
+	deposit := domain.Transaction{
+		ID:        "txn-8",
+		AccountID: "acc-1",
+		Type:      constants.TransactionTypeDeposit,
+		Amount:    money.MustParse("50.00", money.USD),
+	}
+	processor, transactions, ledger := setupProcessor(t, deposit)
+	if err := processor.ProcessTransaction(context.Background(), models.TransactionMessage{TransactionID: deposit.ID}); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, id := range []string{"refund-1", "refund-2"} {
+		_ = transactions.Create(&domain.Transaction{
+			ID:         id,
+			AccountID:  "acc-1",
+			Type:       constants.TransactionTypeRefund,
+			Amount:     money.MustParse("30.00", money.USD),
+			ReversalOf: deposit.ID,
+			Status:     constants.TransactionStatusPending,
+		})
+		if err := processor.ProcessTransaction(context.Background(), models.TransactionMessage{TransactionID: id}); err != nil {
+			t.Fatalf("ProcessTransaction(%s) returned an error: %v", id, err)
+		}
+	}
+
+	first, _ := transactions.GetByID("refund-1")
+	second, _ := transactions.GetByID("refund-2")
+	if first.Status != constants.TransactionStatusCompleted || second.Status != constants.TransactionStatusFailed {
+		t.Errorf("refund statuses = %s, %s; want completed, failed", first.Status, second.Status)
+	}
+	if got := ledger.balance("acc-1").String(); got != "120.00" {
+		t.Errorf("balance = %s, want 120.00", got)
+	}
+}
+
+// A withdrawal may take the balance below zero as far as the overdraft limit,
+// but not a cent further
+func TestProcessTransactionHonoursOverdraftLimit(t *testing.T) {
+	tests := []struct {
+		amount      string
+		wantStatus  constants.TransactionStatus
+		wantBalance string
+	}{
+		{"150.00", constants.TransactionStatusCompleted, "-50.00"},
+		{"150.01", constants.TransactionStatusFailed, "100.00"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.amount, func(t *testing.T) {
+			withdrawal := domain.Transaction{
+				ID:        "txn-overdraft",
+				AccountID: "acc-1",
+				Type:      constants.TransactionTypeWithdrawal,
+				Amount:    money.MustParse(tt.amount, money.USD),
+			}
+			processor, transactions, ledger := setupProcessor(t, withdrawal)
+			account := ledger.state.accounts["acc-1"]
+			account.OverdraftLimit = money.MustParse("50.00", money.USD)
+			ledger.state.accounts["acc-1"] = account
+			msg := models.TransactionMessage{TransactionID: withdrawal.ID, AccountID: withdrawal.AccountID}
+
+			if err := processor.ProcessTransaction(context.Background(), msg); err != nil {
+				t.Fatalf("ProcessTransaction returned an error: %v", err)
+			}
+
+			stored, _ := transactions.GetByID(withdrawal.ID)
+			if stored.Status != tt.wantStatus {
+				t.Errorf("status = %s, want %s", stored.Status, tt.wantStatus)
+			}
+			if got := ledger.balance("acc-1").String(); got != tt.wantBalance {
+				t.Errorf("balance = %s, want %s", got, tt.wantBalance)
+			}
+		})
+	}
+}
+
+// Usage recorded by applied transactions counts towards the rule, so the
+// withdrawal past the hourly count is rejected and names the rule it broke
+func TestProcessTransactionEnforcesLimitRules(t *testing.T) {
+	first := domain.Transaction{
+		ID:        "txn-limit-1",
+		AccountID: "acc-1",
+		Type:      constants.TransactionTypeWithdrawal,
+		Amount:    money.MustParse("10.00", money.USD),
+	}
+	processor, transactions, ledger := setupProcessor(t, first)
+	ledger.state.rules = append(ledger.state.rules, domain.LimitRule{
+		ID:              "rule-1",
+		Name:            "two withdrawals an hour",
+		AccountID:       "acc-1",
+		TransactionType: constants.TransactionTypeWithdrawal,
+		Kind:            domain.LimitKindCount,
+		MaxCount:        2,
+		WindowSeconds:   3600,
+		Enabled:         true,
+	})
+
+	ids := []string{first.ID, "txn-limit-2", "txn-limit-3"}
+	for _, id := range ids[1:] {
+		next := first
+		next.ID = id
+		next.Status = constants.TransactionStatusPending
+		_ = transactions.Create(&next)
+	}
+	for _, id := range ids {
+		msg := models.TransactionMessage{TransactionID: id, AccountID: "acc-1"}
+		if err := processor.ProcessTransaction(context.Background(), msg); err != nil {
+			t.Fatalf("ProcessTransaction(%s) returned an error: %v", id, err)
+		}
+	}
+
+	for i, id := range ids {
+		want := constants.TransactionStatusCompleted
+		if i == 2 {
+			want = constants.TransactionStatusFailed
+		}
+		if stored, _ := transactions.GetByID(id); stored.Status != want {
+			t.Errorf("%s status = %s, want %s", id, stored.Status, want)
+		}
+	}
+	if got := ledger.balance("acc-1").String(); got != "80.00" {
+		t.Errorf("balance = %s, want 80.00", got)
+	}
+	if reason := ledger.state.inbox[ids[2]].Reason; !strings.Contains(reason, "two withdrawals an hour") {
+		t.Errorf("rejection reason = %q, want it to name the rule", reason)
+	}
+}
+
+// The fee is posted with the withdrawal, recorded as its own transaction and
+// shown on the withdrawal; together they must fit the available funds
+func TestProcessTransactionChargesFee(t *testing.T) {
+	fee := money.MustParse("2.00", money.USD)
+	schedule := domain.FeeSchedule{
+		ID:              "fee-1",
+		Name:            "ATM fee",
+		TransactionType: constants.TransactionTypeWithdrawal,
+		Currency:        money.USD,
+		Kind:            domain.FeeKindFlat,
+		Flat:            &fee,
+		Enabled:         true,
+	}
+
+	tests := []struct {
+		amount      string
+		wantStatus  constants.TransactionStatus
+		wantBalance string
+	}{
+		{"98.00", constants.TransactionStatusCompleted, "0.00"},
+		{"98.01", constants.TransactionStatusFailed, "100.00"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.amount, func(t *testing.T) {
+			withdrawal := domain.Transaction{
+				ID:        "txn-fee",
+				AccountID: "acc-1",
+				Type:      constants.TransactionTypeWithdrawal,
+				Amount:    money.MustParse(tt.amount, money.USD),
+			}
+			processor, transactions, ledger := setupProcessor(t, withdrawal)
+			ledger.state.schedules = append(ledger.state.schedules, schedule)
+			msg := models.TransactionMessage{TransactionID: withdrawal.ID, AccountID: withdrawal.AccountID}
+
+			if err := processor.ProcessTransaction(context.Background(), msg); err != nil {
+				t.Fatalf("ProcessTransaction returned an error: %v", err)
+			}
+
+			stored, _ := transactions.GetByID(withdrawal.ID)
+			if stored.Status != tt.wantStatus {
+				t.Errorf("status = %s, want %s", stored.Status, tt.wantStatus)
+			}
+			if got := ledger.balance("acc-1").String(); got != tt.wantBalance {
+				t.Errorf("balance = %s, want %s", got, tt.wantBalance)
+			}
+			if tt.wantStatus == constants.TransactionStatusFailed {
+				return
+			}
+
+			if stored.Fee == nil || stored.Fee.String() != "2.00" {
+				t.Fatalf("fee on withdrawal = %v, want 2.00", stored.Fee)
+			}
+			charged, err := transactions.GetByID(stored.FeeTransactionID)
+			if err != nil {
+				t.Fatalf("fee transaction not recorded: %v", err)
+			}
+			if charged.Type != constants.TransactionTypeFee || charged.FeeOf != withdrawal.ID {
+				t.Errorf("fee transaction = %+v, want a fee linked to %s", charged, withdrawal.ID)
+			}
+		})
+	}
+}
+
+// Every account a transaction touches is locked in one call, in ID order, so
+// processors working on overlapping accounts queue instead of deadlocking
+func TestProcessTransactionLocksAccountsOnceInOrder(t *testing.T) {
+	tests := []struct {
+		name        string
+		transaction domain.Transaction
+		want        []string
+	}{
+		{
+			name: "withdrawal",
+			transaction: domain.Transaction{ID: "txn-withdrawal", AccountID: "acc-2",
+				Type: constants.TransactionTypeWithdrawal, Amount: money.MustParse("10.00", money.USD)},
+			want: []string{"acc-2"},
+		},
+		{
+			name: "transfer to a lower ID",
+			transaction: domain.Transaction{ID: "txn-down", AccountID: "acc-2", ToAccountID: "acc-1",
+				Type: constants.TransactionTypeTransfer, Amount: money.MustParse("10.00", money.USD)},
+			want: []string{"acc-1", "acc-2"},
+		},
+		{
+			name: "transfer to a higher ID",
+			transaction: domain.Transaction{ID: "txn-up", AccountID: "acc-1", ToAccountID: "acc-2",
+				Type: constants.TransactionTypeTransfer, Amount: money.MustParse("10.00", money.USD)},
+			want: []string{"acc-1", "acc-2"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			processor, _, ledger := setupProcessor(t, tt.transaction)
+			msg := models.TransactionMessage{TransactionID: tt.transaction.ID, AccountID: tt.transaction.AccountID}
+			if err := processor.ProcessTransaction(context.Background(), msg); err != nil {
+				t.Fatalf("ProcessTransaction returned an error: %v", err)
+			}
+			if want := [][]string{tt.want}; !reflect.DeepEqual(ledger.locks, want) {
+				t.Errorf("locks = %v, want %v", ledger.locks, want)
+			}
+		})
+	}
+}
+
+// Opposite transfers must queue for the accounts in the same order, fee or
+// not, or two processors could each hold the lock the other is waiting for
+func TestProcessTransactionLocksOppositeFeeTransfersInOrder(t *testing.T) {
+	fee := money.MustParse("1.00", money.USD)
+	schedule := domain.FeeSchedule{
+		ID:              "fee-transfer",
+		Name:            "Transfer fee",
+		TransactionType: constants.TransactionTypeTransfer,
+		Currency:        money.USD,
+		Kind:            domain.FeeKindFlat,
+		Flat:            &fee,
+		Enabled:         true,
+	}
+	forward := domain.Transaction{
+		ID:          "txn-forward",
+		AccountID:   "acc-1",
+		ToAccountID: "acc-2",
+		Type:        constants.TransactionTypeTransfer,
+		Amount:      money.MustParse("10.00", money.USD),
+	}
+	backward := forward
+	backward.ID, backward.AccountID, backward.ToAccountID = "txn-backward", "acc-2", "acc-1"
+
+	processor, transactions, ledger := setupProcessor(t, forward)
+	ledger.state.schedules = append(ledger.state.schedules, schedule)
+	backward.Status = constants.TransactionStatusPending
+	_ = transactions.Create(&backward)
+
+	for _, transaction := range []domain.Transaction{backward, forward} {
+		msg := models.TransactionMessage{TransactionID: transaction.ID, AccountID: transaction.AccountID}
+		if err := processor.ProcessTransaction(context.Background(), msg); err != nil {
+			t.Fatalf("ProcessTransaction(%s) returned an error: %v", transaction.ID, err)
+		}
+	}
+
+	want := [][]string{{"acc-1", "acc-2"}, {"acc-1", "acc-2"}}
+	if !reflect.DeepEqual(ledger.locks, want) {
+		t.Errorf("locks = %v, want each transfer to lock %v once", ledger.locks, want[0])
+	}
+	for _, id := range []string{"acc-1", "acc-2"} {
+		if got := ledger.balance(id).String(); got != "99.00" {
+			t.Errorf("%s balance = %s, want 99.00", id, got)
+		}
+	}
+}
+
+func TestProcessTransactionPostsInterest(t *testing.T) {
+	interest := domain.Transaction{
+		ID:        "txn-interest",
+		AccountID: "acc-1",
+		Type:      constants.TransactionTypeInterest,
+		Amount:    money.MustParse("0.41", money.USD),
+	}
+	processor, transactions, ledger := setupProcessor(t, interest)
+	msg := models.TransactionMessage{TransactionID: interest.ID, AccountID: interest.AccountID}
+
+	if err := processor.ProcessTransaction(context.Background(), msg); err != nil {
+		t.Fatalf("ProcessTransaction returned an error: %v", err)
+	}
+
+	stored, _ := transactions.GetByID(interest.ID)
+	if stored.Status != constants.TransactionStatusCompleted {
+		t.Errorf("status = %s, want completed", stored.Status)
+	}
+	if got := ledger.balance("acc-1").String(); got != "100.41" {
+		t.Errorf("balance = %s, want 100.41", got)
+	}
+	entry := ledger.state.entries[len(ledger.state.entries)-1]
+	if entry.Postings[0].AccountID != domain.SystemAccountInterestExpense || entry.Postings[0].Direction != domain.Debit {
+		t.Errorf("first posting = %+v, want a debit to %s", entry.Postings[0], domain.SystemAccountInterestExpense)
+	}
+}
@@ -2,19 +2,30 @@ package queue
 
 import (
 	"encoding/json"
+	"errors"
+	"sync"
+	"time"
 
 	"github.com/streadway/amqp"
 )
 
+// How long to wait for the broker to confirm a publish
+const confirmTimeout = 5 * time.Second
+
+var ErrPublishNotConfirmed = errors.New("broker did not confirm the message")
+
 type Producer interface {
 	PublishTransaction(message interface{}) error
 	Close() error
 }
 
 type RabbitMQProducer struct {
-	conn    *amqp.Connection
-	channel *amqp.Channel
-	queue   string
+	conn     *amqp.Connection
+	channel  *amqp.Channel
+	queue    string
+	confirms chan amqp.Confirmation
+	mu       sync.Mutex
+	lastTag  uint64 // delivery tag of the latest publish on the channel
 }
 
 // creates a new RabbitMQ producer
@@ -45,21 +56,34 @@ func NewRabbitMQProducer(url, queueName string) (*RabbitMQProducer, error) {
 		return nil, err
 	}
 
+	// Publisher confirms: the broker acks each message once it has taken
+	// responsibility for it
+	if err := ch.Confirm(false); err != nil {
+		ch.Close()
+		conn.Close()
+		return nil, err
+	}
+
 	return &RabbitMQProducer{
-		conn:    conn,
-		channel: ch,
-		queue:   queueName,
+		conn:     conn,
+		channel:  ch,
+		queue:    queueName,
+		confirms: ch.NotifyPublish(make(chan amqp.Confirmation, 1)),
 	}, nil
 }
 
-// publishes a transaction message to the queue
+// publishes a transaction message to the queue and waits for the broker's confirmation
 func (p *RabbitMQProducer) PublishTransaction(message interface{}) error {
 	body, err := json.Marshal(message)
 	if err != nil {
 		return err
 	}
 
-	return p.channel.Publish(
+	// Confirmations arrive in publish order, so one message is in flight at a time
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	err = p.channel.Publish(
 		"",      // exchange
 		p.queue, // routing key
 		false,   // mandatory
@@ -69,6 +93,30 @@ func (p *RabbitMQProducer) PublishTransaction(message interface{}) error {
 			Body:         body,
 			DeliveryMode: amqp.Persistent, // Ensure message persistence
 		})
+	if err != nil {
+		return err
+	}
+	p.lastTag++
+
+	timeout := time.After(confirmTimeout)
+	for {
+		select {
+		case confirm, ok := <-p.confirms:
+			if !ok {
+				return ErrPublishNotConfirmed
+			}
+			// Late confirmation of an earlier, timed-out publish
+			if confirm.DeliveryTag < p.lastTag {
+				continue
+			}
+			if !confirm.Ack {
+				return ErrPublishNotConfirmed
+			}
+			return nil
+		case <-timeout:
+			return ErrPublishNotConfirmed
+		}
+	}
 }
 
 // Close closes the connection and channel
@@ -2,10 +2,272 @@ package models
 
 import "time"
 
+// Balance is kept as the decimal text Postgres returns so it is never
+// round-tripped through float64; see money.Parse.
 type Account struct {
+	ID             string    `gorm:"primaryKey"`
+	Name           string    `gorm:"not null"`
+	Type           string    `gorm:"type:varchar(16);default:'checking';not null"`
+	Currency       string    `gorm:"type:varchar(3);default:'USD';not null"`
+	Balance        string    `gorm:"type:decimal(23,3);default:0;not null"`
+	Held           string    `gorm:"type:decimal(23,3);default:0;not null"`
+	OverdraftLimit string    `gorm:"type:decimal(23,3);default:0;not null"`
+	Status         string    `gorm:"type:varchar(16);default:'active';not null"`
+	FeesWaived     bool      `gorm:"default:false;not null"`
+	InterestPlanID *string   `gorm:"index"`
+	CreatedAt      time.Time `gorm:"default:CURRENT_TIMESTAMP"`
+	UpdatedAt      time.Time `gorm:"default:CURRENT_TIMESTAMP"`
+	Version        int64     `gorm:"not null;default:0"` // last account event applied
+}
+
+// Append-only; a row is never updated or deleted. Amount and OverdraftLimit
+// are in Currency, the account's currency.
+type AccountEvent struct {
+	Sequence       int64   `gorm:"primaryKey;autoIncrement"`
+	AccountID      string  `gorm:"not null;uniqueIndex:idx_account_events_version,priority:1"`
+	Version        int64   `gorm:"not null;uniqueIndex:idx_account_events_version,priority:2"`
+	Type           string  `gorm:"type:varchar(32);not null"`
+	Name           string  `gorm:"not null;default:''"`
+	AccountType    string  `gorm:"type:varchar(16);not null;default:''"`
+	Currency       string  `gorm:"type:varchar(3);not null"`
+	OverdraftLimit *string `gorm:"type:decimal(23,3)"`
+	Amount         *string `gorm:"type:decimal(23,3)"`
+	EntryID        *string
+	Status         string    `gorm:"type:varchar(16);not null;default:''"`
+	OccurredAt     time.Time `gorm:"not null"`
+}
+
+type AccountSnapshot struct {
+	AccountID      string    `gorm:"primaryKey"`
+	Version        int64     `gorm:"primaryKey"`
+	Name           string    `gorm:"not null"`
+	Type           string    `gorm:"type:varchar(16);not null"`
+	Currency       string    `gorm:"type:varchar(3);not null"`
+	Balance        string    `gorm:"type:decimal(23,3);not null"`
+	OverdraftLimit string    `gorm:"type:decimal(23,3);not null"`
+	Status         string    `gorm:"type:varchar(16);not null"`
+	CreatedAt      time.Time `gorm:"not null"` // when the account was opened
+	UpdatedAt      time.Time `gorm:"not null"`
+	TakenAt        time.Time `gorm:"default:CURRENT_TIMESTAMP"`
+}
+
+type OverdraftLimitChange struct {
+	ID        string    `gorm:"primaryKey"`
+	AccountID string    `gorm:"not null;index"`
+	OldLimit  string    `gorm:"type:decimal(23,3);not null"`
+	NewLimit  string    `gorm:"type:decimal(23,3);not null"`
+	Currency  string    `gorm:"type:varchar(3);not null"`
+	Reason    string    `gorm:"not null"`
+	Actor     string    `gorm:"not null"`
+	CreatedAt time.Time `gorm:"default:CURRENT_TIMESTAMP"`
+}
+
+type AccountStatusChange struct {
+	ID         string    `gorm:"primaryKey"`
+	AccountID  string    `gorm:"not null;index"`
+	FromStatus string    `gorm:"type:varchar(16);not null"`
+	ToStatus   string    `gorm:"type:varchar(16);not null"`
+	Reason     string    `gorm:"not null"`
+	Actor      string    `gorm:"not null"`
+	CreatedAt  time.Time `gorm:"default:CURRENT_TIMESTAMP"`
+}
+
+type JournalEntry struct {
+	ID            string    `gorm:"primaryKey"`
+	TransactionID *string   `gorm:"uniqueIndex"`
+	Description   string    `gorm:"not null;default:''"`
+	CreatedAt     time.Time `gorm:"default:CURRENT_TIMESTAMP"`
+	Postings      []Posting `gorm:"foreignKey:EntryID"`
+}
+
+type Posting struct {
+	ID        string    `gorm:"primaryKey"`
+	EntryID   string    `gorm:"not null;index"`
+	AccountID string    `gorm:"not null;index"`
+	Direction string    `gorm:"type:varchar(6);not null"`
+	Amount    string    `gorm:"type:decimal(23,3);not null"`
+	Currency  string    `gorm:"type:varchar(3);not null"`
+	CreatedAt time.Time `gorm:"default:CURRENT_TIMESTAMP"`
+}
+
+// One row per journal entry per customer account it moved. Balance is the
+// account's ledger balance straight after the entry.
+type BalanceHistory struct {
+	Sequence      int64  `gorm:"primaryKey;autoIncrement"`
+	AccountID     string `gorm:"not null;uniqueIndex:idx_balance_history_account_entry;index:idx_balance_history_account_time,priority:1"`
+	EntryID       string `gorm:"not null;uniqueIndex:idx_balance_history_account_entry"`
+	TransactionID *string
+	Change        string    `gorm:"type:decimal(23,3);not null"`
+	Balance       string    `gorm:"type:decimal(23,3);not null"`
+	Currency      string    `gorm:"type:varchar(3);not null"`
+	CreatedAt     time.Time `gorm:"not null;index:idx_balance_history_account_time,priority:2"`
+}
+
+func (BalanceHistory) TableName() string {
+	return "balance_history"
+}
+
+type IdempotencyKey struct {
+	Key          string `gorm:"primaryKey"`
+	Fingerprint  string `gorm:"not null"`
+	StatusCode   int
+	ResponseBody []byte
+	CreatedAt    time.Time `gorm:"default:CURRENT_TIMESTAMP;index"`
+	CompletedAt  *time.Time
+}
+
+type ProcessedTransaction struct {
+	TransactionID string    `gorm:"primaryKey"`
+	Outcome       string    `gorm:"type:varchar(16);not null"`
+	FailureCode   string    `gorm:"type:varchar(32);not null;default:''"`
+	Reason        string    `gorm:"not null;default:''"`
+	ProcessedAt   time.Time `gorm:"default:CURRENT_TIMESTAMP"`
+}
+
+// Rate is stored as unscaled numeric text so it keeps every digit it was given
+type FXRate struct {
+	FromCurrency string    `gorm:"type:varchar(3);primaryKey"`
+	ToCurrency   string    `gorm:"type:varchar(3);primaryKey"`
+	Rate         string    `gorm:"type:numeric;not null"`
+	AsOf         time.Time `gorm:"not null"`
+	UpdatedAt    time.Time `gorm:"default:CURRENT_TIMESTAMP"`
+}
+
+type Hold struct {
+	ID             string    `gorm:"primaryKey"`
+	AccountID      string    `gorm:"not null;index"`
+	Amount         string    `gorm:"type:decimal(23,3);not null"`
+	Currency       string    `gorm:"type:varchar(3);not null"`
+	CapturedAmount *string   `gorm:"type:decimal(23,3)"`
+	Status         string    `gorm:"type:varchar(16);not null;index:idx_holds_status_expires_at,priority:1"`
+	Description    string    `gorm:"not null;default:''"`
+	TransactionID  string    `gorm:"not null;default:''"`
+	ExpiresAt      time.Time `gorm:"not null;index:idx_holds_status_expires_at,priority:2"`
+	CreatedAt      time.Time `gorm:"default:CURRENT_TIMESTAMP"`
+	UpdatedAt      time.Time `gorm:"default:CURRENT_TIMESTAMP"`
+}
+
+// Running total reversed or refunded from one original transaction
+type ReversalTotal struct {
+	OriginalID string    `gorm:"primaryKey"`
+	Amount     string    `gorm:"type:decimal(23,3);not null;default:0"`
+	Currency   string    `gorm:"type:varchar(3);not null"`
+	UpdatedAt  time.Time `gorm:"default:CURRENT_TIMESTAMP"`
+}
+
+type LimitRule struct {
+	ID              string    `gorm:"primaryKey"`
+	Name            string    `gorm:"not null"`
+	AccountID       string    `gorm:"not null;default:'';index"`
+	AccountType     string    `gorm:"type:varchar(16);not null;default:'';index"`
+	TransactionType string    `gorm:"type:varchar(16);not null"`
+	Kind            string    `gorm:"type:varchar(16);not null"`
+	MaxAmount       *string   `gorm:"type:decimal(23,3)"`
+	Currency        string    `gorm:"type:varchar(3);not null;default:''"`
+	MaxCount        int       `gorm:"not null;default:0"`
+	WindowSeconds   int64     `gorm:"not null;default:0"`
+	Enabled         bool      `gorm:"not null;default:true"`
+	CreatedAt       time.Time `gorm:"default:CURRENT_TIMESTAMP"`
+	UpdatedAt       time.Time `gorm:"default:CURRENT_TIMESTAMP"`
+}
+
+// One applied transaction, counted towards its account's limits
+type LimitUsage struct {
+	TransactionID   string    `gorm:"primaryKey"`
+	AccountID       string    `gorm:"not null;index:idx_limit_usages_account,priority:1"`
+	TransactionType string    `gorm:"type:varchar(16);not null;index:idx_limit_usages_account,priority:2"`
+	Amount          string    `gorm:"type:decimal(23,3);not null"`
+	Currency        string    `gorm:"type:varchar(3);not null"`
+	CreatedAt       time.Time `gorm:"not null;index:idx_limit_usages_account,priority:3"`
+}
+
+// Tiers hold the domain's JSON form of the fee tiers
+type FeeSchedule struct {
+	ID              string    `gorm:"primaryKey"`
+	Name            string    `gorm:"not null"`
+	TransactionType string    `gorm:"type:varchar(16);not null;index"`
+	AccountType     string    `gorm:"type:varchar(16);not null;default:''"`
+	Currency        string    `gorm:"type:varchar(3);not null"`
+	Kind            string    `gorm:"type:varchar(16);not null"`
+	Flat            *string   `gorm:"type:decimal(23,3)"`
+	Rate            *string   `gorm:"type:numeric"`
+	Tiers           []byte    `gorm:"type:jsonb"`
+	Min             *string   `gorm:"type:decimal(23,3)"`
+	Max             *string   `gorm:"type:decimal(23,3)"`
+	Enabled         bool      `gorm:"not null;default:true"`
+	CreatedAt       time.Time `gorm:"default:CURRENT_TIMESTAMP"`
+	UpdatedAt       time.Time `gorm:"default:CURRENT_TIMESTAMP"`
+}
+
+type FeeCharge struct {
+	ID            string    `gorm:"primaryKey"`
+	TransactionID string    `gorm:"not null;uniqueIndex"`
+	AccountID     string    `gorm:"not null;index"`
+	ScheduleID    string    `gorm:"not null;index"`
+	Amount        string    `gorm:"type:decimal(23,3);not null"`
+	Currency      string    `gorm:"type:varchar(3);not null"`
+	Description   string    `gorm:"not null;default:''"`
+	CreatedAt     time.Time `gorm:"default:CURRENT_TIMESTAMP"`
+}
+
+type InterestPlan struct {
+	ID         string    `gorm:"primaryKey"`
+	Name       string    `gorm:"not null"`
+	AnnualRate string    `gorm:"type:numeric;not null"`
+	Method     string    `gorm:"type:varchar(16);not null"`
+	DayCount   string    `gorm:"type:varchar(16);not null"`
+	Enabled    bool      `gorm:"not null;default:true"`
+	CreatedAt  time.Time `gorm:"default:CURRENT_TIMESTAMP"`
+	UpdatedAt  time.Time `gorm:"default:CURRENT_TIMESTAMP"`
+}
+
+// Amount keeps the exact daily interest; only postings are rounded
+type InterestAccrual struct {
+	ID        string    `gorm:"primaryKey"`
+	AccountID string    `gorm:"not null;uniqueIndex:idx_interest_accruals_account_date"`
+	PlanID    string    `gorm:"not null;index"`
+	Date      time.Time `gorm:"type:date;not null;uniqueIndex:idx_interest_accruals_account_date"`
+	Basis     string    `gorm:"type:numeric;not null"`
+	Rate      string    `gorm:"type:numeric;not null"`
+	Amount    string    `gorm:"type:decimal(33,10);not null"`
+	Currency  string    `gorm:"type:varchar(3);not null"`
+	PostingID string    `gorm:"not null;default:'';index"`
+	CreatedAt time.Time `gorm:"default:CURRENT_TIMESTAMP"`
+}
+
+type InterestPosting struct {
 	ID        string    `gorm:"primaryKey"`
-	Name      string    `gorm:"not null"`
-	Balance   float64   `gorm:"type:decimal(20,2);default:0.00;not null"`
+	AccountID string    `gorm:"not null;uniqueIndex:idx_interest_postings_account_period"`
+	Period    string    `gorm:"type:varchar(7);not null;uniqueIndex:idx_interest_postings_account_period"`
+	Amount    string    `gorm:"type:decimal(23,3);not null"`
+	Currency  string    `gorm:"type:varchar(3);not null"`
 	CreatedAt time.Time `gorm:"default:CURRENT_TIMESTAMP"`
-	UpdatedAt time.Time `gorm:"default:CURRENT_TIMESTAMP"`
+}
+
+type ScheduledTransaction struct {
+	ID          string `gorm:"primaryKey"`
+	AccountID   string `gorm:"not null;index"`
+	ToAccountID string `gorm:"not null;default:''"`
+	Type        string `gorm:"type:varchar(16);not null"`
+	Amount      string `gorm:"type:decimal(23,3);not null"`
+	Currency    string `gorm:"type:varchar(3);not null"`
+	Description string `gorm:"not null;default:''"`
+	RunAt       *time.Time
+	Cron        string     `gorm:"not null;default:''"`
+	Status      string     `gorm:"type:varchar(16);not null;index:idx_scheduled_transactions_due,priority:1"`
+	NextRunAt   *time.Time `gorm:"index:idx_scheduled_transactions_due,priority:2"`
+	LastRunAt   *time.Time
+	CreatedAt   time.Time `gorm:"default:CURRENT_TIMESTAMP"`
+	UpdatedAt   time.Time `gorm:"default:CURRENT_TIMESTAMP"`
+}
+
+type ScheduledExecution struct {
+	ID            string    `gorm:"primaryKey"`
+	ScheduleID    string    `gorm:"not null;uniqueIndex:idx_scheduled_executions_schedule_due"`
+	DueAt         time.Time `gorm:"not null;uniqueIndex:idx_scheduled_executions_schedule_due"`
+	TransactionID string    `gorm:"not null;uniqueIndex"`
+	Outcome       string    `gorm:"type:varchar(16);not null;index"`
+	Error         string    `gorm:"not null;default:''"`
+	CreatedAt     time.Time `gorm:"default:CURRENT_TIMESTAMP"`
+	UpdatedAt     time.Time `gorm:"default:CURRENT_TIMESTAMP"`
 }
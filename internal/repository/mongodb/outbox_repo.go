@@ -0,0 +1,179 @@
+package mongodb
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"banking-ledger/internal/domain"
+)
+
+// Stored shape of a transaction; the outbox rides along in the same document
+type transactionDocument struct {
+	domain.Transaction `bson:",inline"`
+	Outbox             *outboxDocument `bson:"outbox,omitempty"`
+}
+
+type outboxDocument struct {
+	Payload     []byte     `bson:"payload"`
+	CreatedAt   time.Time  `bson:"created_at"`
+	PublishedAt *time.Time `bson:"published_at"`
+	Attempts    int        `bson:"attempts"`
+	LastError   string     `bson:"last_error,omitempty"`
+	Requeues    int        `bson:"requeues,omitempty"`
+}
+
+func mapOutboxDocumentToDomain(doc *transactionDocument) *domain.OutboxMessage {
+	return &domain.OutboxMessage{
+		TransactionID: doc.ID,
+		Payload:       doc.Outbox.Payload,
+		CreatedAt:     doc.Outbox.CreatedAt,
+		PublishedAt:   doc.Outbox.PublishedAt,
+		Attempts:      doc.Outbox.Attempts,
+		LastError:     doc.Outbox.LastError,
+		Requeues:      doc.Outbox.Requeues,
+	}
+}
+
+// Reads and updates the outbox messages embedded in transaction documents
+type OutboxRepository struct {
+	collection *mongo.Collection
+}
+
+func NewOutboxRepository(conn *Connection) *OutboxRepository {
+	r := &OutboxRepository{
+		collection: conn.Database.Collection("transactions"),
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// Only unpublished messages are indexed, so the index stays small
+	_, err := r.collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "outbox.created_at", Value: 1}},
+		Options: options.Index().
+			SetName("outbox_unpublished").
+			SetPartialFilterExpression(bson.M{"outbox.published_at": bson.M{"$type": "null"}}),
+	})
+	if err != nil {
+		log.Printf("Failed to create outbox index: %v", err)
+	}
+	return r
+}
+
+// Returns the oldest messages not yet confirmed by the broker
+func (r *OutboxRepository) ListUnpublished(limit int) ([]*domain.OutboxMessage, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	cursor, err := r.collection.Find(ctx,
+		bson.M{"outbox.published_at": bson.M{"$type": "null"}},
+		options.Find().
+			SetSort(bson.D{{Key: "outbox.created_at", Value: 1}}).
+			SetLimit(int64(limit)).
+			SetProjection(bson.M{"id": 1, "outbox": 1}),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list outbox messages: %v", err)
+	}
+	defer cursor.Close(ctx)
+
+	var docs []transactionDocument
+	if err = cursor.All(ctx, &docs); err != nil {
+		return nil, fmt.Errorf("failed to decode outbox messages: %v", err)
+	}
+
+	messages := make([]*domain.OutboxMessage, len(docs))
+	for i := range docs {
+		messages[i] = mapOutboxDocumentToDomain(&docs[i])
+	}
+	return messages, nil
+}
+
+// Reads the message stored with a transaction
+func (r *OutboxRepository) Get(transactionID string) (*domain.OutboxMessage, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var doc transactionDocument
+	err := r.collection.FindOne(ctx, bson.M{"id": transactionID},
+		options.FindOne().SetProjection(bson.M{"id": 1, "outbox": 1}),
+	).Decode(&doc)
+	if err == mongo.ErrNoDocuments {
+		return nil, domain.ErrTransactionNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve outbox message: %v", err)
+	}
+	if doc.Outbox == nil {
+		return nil, nil
+	}
+	return mapOutboxDocumentToDomain(&doc), nil
+}
+
+// Marks the message unpublished again with a fresh creation time, so the
+// relay sends it after the messages already waiting
+func (r *OutboxRepository) Requeue(transactionID string, payload []byte) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	update := bson.M{
+		"$set": bson.M{
+			"outbox.payload":      payload,
+			"outbox.created_at":   time.Now(),
+			"outbox.published_at": nil,
+		},
+		"$inc": bson.M{"outbox.requeues": 1},
+	}
+	result, err := r.collection.UpdateOne(ctx, bson.M{"id": transactionID}, update)
+	if err != nil {
+		return fmt.Errorf("failed to requeue outbox message: %v", err)
+	}
+	if result.MatchedCount == 0 {
+		return domain.ErrTransactionNotFound
+	}
+	return nil
+}
+
+// Records the broker's confirmation of a message
+func (r *OutboxRepository) MarkPublished(transactionID string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	update := bson.M{
+		"$set": bson.M{"outbox.published_at": time.Now()},
+		"$inc": bson.M{"outbox.attempts": 1},
+	}
+	result, err := r.collection.UpdateOne(ctx, bson.M{"id": transactionID}, update)
+	if err != nil {
+		return fmt.Errorf("failed to mark outbox message published: %v", err)
+	}
+	if result.MatchedCount == 0 {
+		return fmt.Errorf("transaction not found")
+	}
+	return nil
+}
+
+// Records a failed publish attempt; the message stays in the outbox
+func (r *OutboxRepository) MarkFailed(transactionID string, reason string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	update := bson.M{
+		"$set": bson.M{"outbox.last_error": reason},
+		"$inc": bson.M{"outbox.attempts": 1},
+	}
+	result, err := r.collection.UpdateOne(ctx, bson.M{"id": transactionID}, update)
+	if err != nil {
+		return fmt.Errorf("failed to record outbox publish failure: %v", err)
+	}
+	if result.MatchedCount == 0 {
+		return fmt.Errorf("transaction not found")
+	}
+	return nil
+}
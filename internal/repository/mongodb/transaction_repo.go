@@ -2,11 +2,17 @@ package mongodb
 
 import (
 	"context"
+	"encoding/base64"
+	"encoding/json"
 	"fmt"
+	"log"
+	"regexp"
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 
 	"banking-ledger/internal/constants"
 	"banking-ledger/internal/domain"
@@ -16,10 +22,53 @@ type TransactionRepository struct {
 	collection *mongo.Collection
 }
 
+const (
+	defaultPageSize = 50
+	maxPageSize     = 200
+
+	maxStatusUpdateAttempts = 3
+)
+
 func NewTransactionRepository(conn *Connection) *TransactionRepository {
-	return &TransactionRepository{
+	r := &TransactionRepository{
 		collection: conn.Database.Collection("transactions"),
 	}
+	r.ensureIndexes()
+	return r
+}
+
+// Indexes back the lookups and the newest-first listing per account
+func (r *TransactionRepository) ensureIndexes() {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	_, err := r.collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "id", Value: 1}},
+			Options: options.Index().SetName("id_unique").SetUnique(true),
+		},
+		{
+			Keys:    bson.D{{Key: "account_id", Value: 1}, {Key: "created_at", Value: -1}, {Key: "id", Value: -1}},
+			Options: options.Index().SetName("account_created"),
+		},
+		{
+			Keys: bson.D{{Key: "to_account_id", Value: 1}, {Key: "created_at", Value: -1}, {Key: "id", Value: -1}},
+			Options: options.Index().SetName("to_account_created").
+				SetPartialFilterExpression(bson.M{"to_account_id": bson.M{"$exists": true}}),
+		},
+		{
+			Keys:    bson.D{{Key: "created_at", Value: -1}, {Key: "id", Value: -1}},
+			Options: options.Index().SetName("created"),
+		},
+		{
+			Keys: bson.D{{Key: "reversal_of", Value: 1}},
+			Options: options.Index().SetName("reversal_of").
+				SetPartialFilterExpression(bson.M{"reversal_of": bson.M{"$exists": true}}),
+		},
+	})
+	if err != nil {
+		log.Printf("Failed to create transaction indexes: %v", err)
+	}
 }
 
 // Inserts a new transaction into the database
@@ -27,6 +76,7 @@ func (r *TransactionRepository) Create(transaction *domain.Transaction) error {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
+	transaction.InitialStatusHistory()
 	_, err := r.collection.InsertOne(ctx, transaction)
 	if err != nil {
 		return fmt.Errorf("failed to create transaction: %v", err)
@@ -34,6 +84,27 @@ func (r *TransactionRepository) Create(transaction *domain.Transaction) error {
 	return nil
 }
 
+// Inserts a new transaction with its outgoing queue message in one document
+func (r *TransactionRepository) CreateWithOutbox(transaction *domain.Transaction, payload []byte) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	transaction.InitialStatusHistory()
+	doc := transactionDocument{
+		Transaction: *transaction,
+		Outbox: &outboxDocument{
+			Payload:   payload,
+			CreatedAt: time.Now(),
+		},
+	}
+
+	_, err := r.collection.InsertOne(ctx, doc)
+	if err != nil {
+		return fmt.Errorf("failed to create transaction: %v", err)
+	}
+	return nil
+}
+
 // Retrieves a transaction by its ID
 func (r *TransactionRepository) GetByID(id string) (*domain.Transaction, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
@@ -43,21 +114,46 @@ func (r *TransactionRepository) GetByID(id string) (*domain.Transaction, error)
 	err := r.collection.FindOne(ctx, bson.M{"id": id}).Decode(&transaction)
 	if err != nil {
 		if err == mongo.ErrNoDocuments {
-			return nil, fmt.Errorf("transaction not found")
+			return nil, domain.ErrTransactionNotFound
 		}
 		return nil, fmt.Errorf("failed to retrieve transaction: %v", err)
 	}
 	return &transaction, nil
 }
 
-// Retrieves all transactions for a specific account
+// Retrieves the transactions with the given IDs; missing ones are left out
+func (r *TransactionRepository) ListByIDs(ids []string) ([]*domain.Transaction, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	cursor, err := r.collection.Find(ctx, bson.M{"id": bson.M{"$in": ids}})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list transactions: %v", err)
+	}
+	defer cursor.Close(ctx)
+
+	var transactions []*domain.Transaction
+	if err = cursor.All(ctx, &transactions); err != nil {
+		return nil, fmt.Errorf("failed to decode transactions: %v", err)
+	}
+	return transactions, nil
+}
+
+// Retrieves all transactions for a specific account, including transfers it received
 func (r *TransactionRepository) ListByAccountID(accountID string) ([]*domain.Transaction, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
 	cursor, err := r.collection.Find(ctx, bson.M{
-		"account_id": accountID,
-	})
+		"$or": bson.A{
+			bson.M{"account_id": accountID},
+			bson.M{"to_account_id": accountID},
+		},
+	}, options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}, {Key: "id", Value: 1}}))
 	if err != nil {
 		return nil, fmt.Errorf("failed to list transactions: %v", err)
 	}
@@ -71,26 +167,249 @@ func (r *TransactionRepository) ListByAccountID(accountID string) ([]*domain.Tra
 	return transactions, nil
 }
 
-// Updates the status of a transaction
-func (r *TransactionRepository) UpdateStatus(id string, status constants.TransactionStatus) error {
+// Retrieves the reversals and refunds of a transaction, oldest first
+func (r *TransactionRepository) ListReversals(originalID string) ([]*domain.Transaction, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
-	update := bson.M{
-		"$set": bson.M{
-			"status":     status,
-			"updated_at": time.Now(),
-		},
+	cursor, err := r.collection.Find(ctx, bson.M{"reversal_of": originalID},
+		options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}, {Key: "id", Value: 1}}))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list reversals: %v", err)
+	}
+	defer cursor.Close(ctx)
+
+	var transactions []*domain.Transaction
+	if err = cursor.All(ctx, &transactions); err != nil {
+		return nil, fmt.Errorf("failed to decode transactions: %v", err)
 	}
 
-	result, err := r.collection.UpdateOne(ctx, bson.M{"id": id}, update)
+	return transactions, nil
+}
+
+// Position of the last transaction on a page
+type pageCursor struct {
+	CreatedAt time.Time `json:"c"`
+	ID        string    `json:"i"`
+}
+
+func encodeCursor(t *domain.Transaction) string {
+	data, _ := json.Marshal(pageCursor{CreatedAt: t.CreatedAt, ID: t.ID})
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeCursor(value string) (*pageCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(value)
 	if err != nil {
-		return fmt.Errorf("failed to update transaction status: %v", err)
+		return nil, domain.ErrInvalidCursor
 	}
+	var c pageCursor
+	if err := json.Unmarshal(data, &c); err != nil || c.ID == "" {
+		return nil, domain.ErrInvalidCursor
+	}
+	return &c, nil
+}
 
-	if result.MatchedCount == 0 {
-		return fmt.Errorf("transaction not found")
+// Matches the amount a transaction moved through the filtered account: the
+// credited to_amount when the account received a cross-currency transfer,
+// the amount otherwise. Minor units only compare within one currency.
+func amountCondition(filter domain.TransactionFilter) bson.M {
+	inRange := func(field string) bson.M {
+		bounds := bson.M{}
+		condition := bson.M{field + ".amount": bounds}
+		if filter.MinAmount != nil {
+			bounds["$gte"] = filter.MinAmount.Amount
+			condition[field+".currency"] = filter.MinAmount.Currency
+		}
+		if filter.MaxAmount != nil {
+			bounds["$lte"] = filter.MaxAmount.Amount
+			condition[field+".currency"] = filter.MaxAmount.Currency
+		}
+		return condition
+	}
+	if filter.AccountID == "" {
+		return inRange("amount")
 	}
 
+	sent := inRange("amount")
+	sent["account_id"] = filter.AccountID
+	received := inRange("amount")
+	received["to_account_id"] = filter.AccountID
+	received["to_amount"] = nil
+	receivedConverted := inRange("to_amount")
+	receivedConverted["to_account_id"] = filter.AccountID
+	return bson.M{"$or": bson.A{sent, received, receivedConverted}}
+}
+
+// Builds the query for one page of List. Ties on created_at are broken by
+// id so paging never skips or repeats.
+func listQuery(filter domain.TransactionFilter) (bson.M, error) {
+	conditions := bson.A{}
+	if filter.AccountID != "" {
+		conditions = append(conditions, bson.M{"$or": bson.A{
+			bson.M{"account_id": filter.AccountID},
+			bson.M{"to_account_id": filter.AccountID},
+		}})
+	}
+	if len(filter.Types) > 0 {
+		conditions = append(conditions, bson.M{"type": bson.M{"$in": filter.Types}})
+	}
+	if len(filter.Statuses) > 0 {
+		conditions = append(conditions, bson.M{"status": bson.M{"$in": filter.Statuses}})
+	}
+	if filter.MinAmount != nil || filter.MaxAmount != nil {
+		conditions = append(conditions, amountCondition(filter))
+	}
+	if filter.CreatedFrom != nil {
+		conditions = append(conditions, bson.M{"created_at": bson.M{"$gte": *filter.CreatedFrom}})
+	}
+	if filter.CreatedTo != nil {
+		conditions = append(conditions, bson.M{"created_at": bson.M{"$lt": *filter.CreatedTo}})
+	}
+	if filter.Description != "" {
+		conditions = append(conditions, bson.M{"description": primitive.Regex{
+			Pattern: regexp.QuoteMeta(filter.Description),
+			Options: "i",
+		}})
+	}
+	if filter.Cursor != "" {
+		after, err := decodeCursor(filter.Cursor)
+		if err != nil {
+			return nil, err
+		}
+		conditions = append(conditions, bson.M{"$or": bson.A{
+			bson.M{"created_at": bson.M{"$lt": after.CreatedAt}},
+			bson.M{"created_at": after.CreatedAt, "id": bson.M{"$lt": after.ID}},
+		}})
+	}
+
+	query := bson.M{}
+	if len(conditions) > 0 {
+		query["$and"] = conditions
+	}
+	return query, nil
+}
+
+// Lists transactions matching the filter, newest first, one page at a time
+func (r *TransactionRepository) List(filter domain.TransactionFilter) (*domain.TransactionPage, error) {
+	query, err := listQuery(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = defaultPageSize
+	}
+	if limit > maxPageSize {
+		limit = maxPageSize
+	}
+
+	// One extra document tells us whether another page exists
+	cursor, err := r.collection.Find(ctx, query, options.Find().
+		SetSort(bson.D{{Key: "created_at", Value: -1}, {Key: "id", Value: -1}}).
+		SetLimit(int64(limit+1)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list transactions: %v", err)
+	}
+	defer cursor.Close(ctx)
+
+	transactions := []*domain.Transaction{}
+	if err = cursor.All(ctx, &transactions); err != nil {
+		return nil, fmt.Errorf("failed to decode transactions: %v", err)
+	}
+
+	page := &domain.TransactionPage{Transactions: transactions}
+	if len(transactions) > limit {
+		page.Transactions = transactions[:limit]
+		page.NextCursor = encodeCursor(transactions[limit-1])
+	}
+	return page, nil
+}
+
+// Moves a transaction to a new status and records the change in its history.
+// The update only matches the status the transition was checked against, so
+// a concurrent change is read again and checked afresh.
+func (r *TransactionRepository) UpdateStatus(id string, update domain.StatusUpdate) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	for attempt := 0; attempt < maxStatusUpdateAttempts; attempt++ {
+		var current domain.Transaction
+		err := r.collection.FindOne(ctx, bson.M{"id": id},
+			options.FindOne().SetProjection(bson.M{"status": 1}),
+		).Decode(&current)
+		if err == mongo.ErrNoDocuments {
+			return domain.ErrTransactionNotFound
+		}
+		if err != nil {
+			return fmt.Errorf("failed to retrieve transaction status: %v", err)
+		}
+		if current.Status == update.Status && current.Status != constants.TransactionStatusProcessing {
+			return nil
+		}
+		if err := domain.CheckTransactionTransition(current.Status, update.Status); err != nil {
+			return err
+		}
+
+		now := time.Now()
+		set := bson.M{
+			"status":     update.Status,
+			"updated_at": now,
+		}
+		if update.Status == constants.TransactionStatusFailed && update.Failure != nil {
+			set["failure"] = update.Failure
+		}
+		change := domain.StatusChange{
+			From:     current.Status,
+			To:       update.Status,
+			At:       now,
+			Instance: update.Instance,
+		}
+
+		result, err := r.collection.UpdateOne(ctx,
+			bson.M{"id": id, "status": current.Status},
+			bson.M{"$set": set, "$push": bson.M{"status_history": change}},
+		)
+		if err != nil {
+			return fmt.Errorf("failed to update transaction status: %v", err)
+		}
+		if result.MatchedCount == 1 {
+			return nil
+		}
+	}
+	return fmt.Errorf("failed to update transaction status: changed concurrently %d times", maxStatusUpdateAttempts)
+}
+
+// Upserts the fee transaction, then stamps the fee on the transaction it was
+// charged for; both writes are idempotent, so a redelivery can repeat them
+func (r *TransactionRepository) RecordFee(fee *domain.Transaction) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	_, err := r.collection.UpdateOne(ctx,
+		bson.M{"id": fee.ID},
+		bson.M{"$setOnInsert": fee},
+		options.Update().SetUpsert(true),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to record fee transaction: %v", err)
+	}
+
+	result, err := r.collection.UpdateOne(ctx, bson.M{"id": fee.FeeOf}, bson.M{
+		"$set": bson.M{
+			"fee":                fee.Amount,
+			"fee_transaction_id": fee.ID,
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to link fee transaction: %v", err)
+	}
+	if result.MatchedCount == 0 {
+		return domain.ErrTransactionNotFound
+	}
 	return nil
 }
@@ -0,0 +1,160 @@
+package mongodb
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"banking-ledger/internal/constants"
+	"banking-ledger/internal/domain"
+	"banking-ledger/internal/money"
+)
+
+func TestCursorRoundTrip(t *testing.T) {
+	transaction := &domain.Transaction{
+		ID:        "tx-1",
+		CreatedAt: time.Date(2024, time.March, 1, 12, 30, 0, 123456789, time.UTC),
+	}
+
+	decoded, err := decodeCursor(encodeCursor(transaction))
+	if err != nil {
+		t.Fatalf("decodeCursor returned an error: %v", err)
+	}
+	if decoded.ID != transaction.ID || !decoded.CreatedAt.Equal(transaction.CreatedAt) {
+		t.Errorf("cursor = %+v, want %s at %s", *decoded, transaction.ID, transaction.CreatedAt)
+	}
+}
+
+func TestDecodeCursorRejects(t *testing.T) {
+	tests := map[string]string{
+		"not base64": "!!!",
+		"not json":   "bm90IGpzb24",
+		"missing id": "eyJjIjoiMjAyNC0wMy0wMVQwMDowMDowMFoifQ",
+		"empty":      "",
+	}
+	for name, value := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := decodeCursor(value); !errors.Is(err, domain.ErrInvalidCursor) {
+				t.Errorf("decodeCursor(%q) = %v, want ErrInvalidCursor", value, err)
+			}
+		})
+	}
+}
+
+func TestAmountCondition(t *testing.T) {
+	lower := money.MustParse("10.00", money.EUR)
+	upper := money.MustParse("20.00", money.EUR)
+
+	tests := []struct {
+		name   string
+		filter domain.TransactionFilter
+		want   bson.M
+	}{
+		{
+			name:   "any account",
+			filter: domain.TransactionFilter{MinAmount: &lower, MaxAmount: &upper},
+			want: bson.M{
+				"amount.amount":   bson.M{"$gte": int64(1000), "$lte": int64(2000)},
+				"amount.currency": money.EUR,
+			},
+		},
+		{
+			name:   "one account matches what it sent or received",
+			filter: domain.TransactionFilter{AccountID: "acc-1", MinAmount: &lower},
+			want: bson.M{"$or": bson.A{
+				bson.M{
+					"account_id":      "acc-1",
+					"amount.amount":   bson.M{"$gte": int64(1000)},
+					"amount.currency": money.EUR,
+				},
+				bson.M{
+					"to_account_id":   "acc-1",
+					"to_amount":       nil,
+					"amount.amount":   bson.M{"$gte": int64(1000)},
+					"amount.currency": money.EUR,
+				},
+				bson.M{
+					"to_account_id":      "acc-1",
+					"to_amount.amount":   bson.M{"$gte": int64(1000)},
+					"to_amount.currency": money.EUR,
+				},
+			}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := amountCondition(tt.filter); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("amountCondition = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestListQuery(t *testing.T) {
+	from := time.Date(2024, time.March, 1, 0, 0, 0, 0, time.UTC)
+	to := from.AddDate(0, 1, 0)
+	limit := money.MustParse("5.00", money.USD)
+	cursor := &domain.Transaction{ID: "tx-9", CreatedAt: from.Add(time.Hour)}
+
+	tests := []struct {
+		name   string
+		filter domain.TransactionFilter
+		want   bson.M
+	}{
+		{
+			name: "no filter",
+			want: bson.M{},
+		},
+		{
+			name: "every condition",
+			filter: domain.TransactionFilter{
+				AccountID:   "acc-1",
+				Types:       []constants.TransactionType{constants.TransactionTypeDeposit},
+				Statuses:    []constants.TransactionStatus{constants.TransactionStatusCompleted},
+				MaxAmount:   &limit,
+				CreatedFrom: &from,
+				CreatedTo:   &to,
+				Description: "rent (march)",
+				Cursor:      encodeCursor(cursor),
+			},
+			want: bson.M{"$and": bson.A{
+				bson.M{"$or": bson.A{
+					bson.M{"account_id": "acc-1"},
+					bson.M{"to_account_id": "acc-1"},
+				}},
+				bson.M{"type": bson.M{"$in": []constants.TransactionType{constants.TransactionTypeDeposit}}},
+				bson.M{"status": bson.M{"$in": []constants.TransactionStatus{constants.TransactionStatusCompleted}}},
+				amountCondition(domain.TransactionFilter{AccountID: "acc-1", MaxAmount: &limit}),
+				bson.M{"created_at": bson.M{"$gte": from}},
+				bson.M{"created_at": bson.M{"$lt": to}},
+				// The search text is matched literally
+				bson.M{"description": primitive.Regex{Pattern: `rent \(march\)`, Options: "i"}},
+				bson.M{"$or": bson.A{
+					bson.M{"created_at": bson.M{"$lt": cursor.CreatedAt}},
+					bson.M{"created_at": cursor.CreatedAt, "id": bson.M{"$lt": cursor.ID}},
+				}},
+			}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := listQuery(tt.filter)
+			if err != nil {
+				t.Fatalf("listQuery returned an error: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("listQuery = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestListQueryRejectsBadCursor(t *testing.T) {
+	if _, err := listQuery(domain.TransactionFilter{Cursor: "!!!"}); !errors.Is(err, domain.ErrInvalidCursor) {
+		t.Errorf("listQuery = %v, want ErrInvalidCursor", err)
+	}
+}
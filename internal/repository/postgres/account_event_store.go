@@ -0,0 +1,230 @@
+package postgres
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"banking-ledger/internal/domain"
+	"banking-ledger/internal/money"
+	"banking-ledger/internal/repository/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Append-only store of account events and the snapshots folded from them.
+// Events are appended by the account and journal repositories in the same
+// database transaction as the account row they change.
+type AccountEventStore struct {
+	db *gorm.DB
+}
+
+func NewAccountEventStore(db *gorm.DB) *AccountEventStore {
+	return &AccountEventStore{db: db}
+}
+
+func mapAccountEventToModel(event *domain.AccountEvent, currency money.Currency) *models.AccountEvent {
+	return &models.AccountEvent{
+		AccountID:      event.AccountID,
+		Version:        event.Version,
+		Type:           string(event.Type),
+		Name:           event.Name,
+		AccountType:    string(event.AccountType),
+		Currency:       string(currency),
+		OverdraftLimit: optionalDecimal(event.OverdraftLimit),
+		Amount:         optionalDecimal(event.Amount),
+		EntryID:        optionalString(event.EntryID),
+		Status:         string(event.Status),
+		OccurredAt:     event.OccurredAt,
+	}
+}
+
+func mapAccountEventModelToDomain(model *models.AccountEvent) (*domain.AccountEvent, error) {
+	currency := money.Currency(model.Currency)
+	limit, err := parseOptionalDecimal(model.OverdraftLimit, currency)
+	if err != nil {
+		return nil, fmt.Errorf("invalid overdraft limit on event %d of account %s: %v", model.Version, model.AccountID, err)
+	}
+	amount, err := parseOptionalDecimal(model.Amount, currency)
+	if err != nil {
+		return nil, fmt.Errorf("invalid amount on event %d of account %s: %v", model.Version, model.AccountID, err)
+	}
+	event := &domain.AccountEvent{
+		AccountID:      model.AccountID,
+		Version:        model.Version,
+		Type:           domain.AccountEventType(model.Type),
+		Name:           model.Name,
+		AccountType:    domain.AccountType(model.AccountType),
+		OverdraftLimit: limit,
+		Amount:         amount,
+		Status:         domain.AccountStatus(model.Status),
+		OccurredAt:     model.OccurredAt,
+	}
+	if event.Type == domain.AccountEventOpened {
+		event.Currency = currency
+	}
+	if model.EntryID != nil {
+		event.EntryID = *model.EntryID
+	}
+	return event, nil
+}
+
+// Appends an event numbered version; the unique (account_id, version) index
+// rejects a second event with the same number
+func appendAccountEvent(tx *gorm.DB, event *domain.AccountEvent, version int64, currency string) error {
+	event.Version = version
+	model := mapAccountEventToModel(event, money.Currency(currency))
+	if err := tx.Create(model).Error; err != nil {
+		return fmt.Errorf("failed to append account event: %v", err)
+	}
+	return nil
+}
+
+// Applies updates to the account row, moves it to its next version and
+// appends event under that version
+func updateAccountWithEvent(tx *gorm.DB, accountID string, updates map[string]interface{}, event *domain.AccountEvent) error {
+	updates["version"] = gorm.Expr("version + 1")
+	var updated []models.Account
+	result := tx.Model(&updated).
+		Clauses(clause.Returning{Columns: []clause.Column{{Name: "version"}, {Name: "currency"}}}).
+		Where("id = ?", accountID).
+		Updates(updates)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return domain.ErrAccountNotFound
+	}
+	return appendAccountEvent(tx, event, updated[0].Version, updated[0].Currency)
+}
+
+// Lists the account's events after afterVersion, oldest first
+func (s *AccountEventStore) ListEvents(accountID string, afterVersion int64) ([]*domain.AccountEvent, error) {
+	var rows []models.AccountEvent
+	err := s.db.Where("account_id = ? AND version > ?", accountID, afterVersion).
+		Order("version").
+		Find(&rows).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list account events: %v", err)
+	}
+
+	events := make([]*domain.AccountEvent, 0, len(rows))
+	for i := range rows {
+		event, err := mapAccountEventModelToDomain(&rows[i])
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, event)
+	}
+	return events, nil
+}
+
+// Lists every account with events, in ID order
+func (s *AccountEventStore) ListAccountIDs() ([]string, error) {
+	var ids []string
+	err := s.db.Model(&models.AccountEvent{}).Distinct("account_id").Order("account_id").Pluck("account_id", &ids).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list accounts with events: %v", err)
+	}
+	return ids, nil
+}
+
+// Returns the account's snapshot with the highest version, or nil if none
+func (s *AccountEventStore) LatestSnapshot(accountID string) (*domain.AccountSnapshot, error) {
+	var model models.AccountSnapshot
+	err := s.db.Where("account_id = ?", accountID).Order("version DESC").First(&model).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve account snapshot: %v", err)
+	}
+
+	currency := money.Currency(model.Currency)
+	balance, err := money.Parse(model.Balance, currency)
+	if err != nil {
+		return nil, fmt.Errorf("invalid balance %q on snapshot %d of account %s: %v", model.Balance, model.Version, accountID, err)
+	}
+	limit, err := money.Parse(model.OverdraftLimit, currency)
+	if err != nil {
+		return nil, fmt.Errorf("invalid overdraft limit %q on snapshot %d of account %s: %v", model.OverdraftLimit, model.Version, accountID, err)
+	}
+	return &domain.AccountSnapshot{
+		Account: domain.Account{
+			ID:             model.AccountID,
+			Name:           model.Name,
+			Type:           domain.AccountType(model.Type),
+			Currency:       currency,
+			Balance:        balance,
+			Held:           money.Zero(currency),
+			OverdraftLimit: limit,
+			Status:         domain.AccountStatus(model.Status),
+			CreatedAt:      model.CreatedAt,
+			UpdatedAt:      model.UpdatedAt,
+			Version:        model.Version,
+		},
+		TakenAt: model.TakenAt,
+	}, nil
+}
+
+// Stores a snapshot unless one exists for the same version
+func (s *AccountEventStore) SaveSnapshot(snapshot *domain.AccountSnapshot) error {
+	account := &snapshot.Account
+	model := models.AccountSnapshot{
+		AccountID:      account.ID,
+		Version:        account.Version,
+		Name:           account.Name,
+		Type:           string(account.Type),
+		Currency:       string(account.Currency),
+		Balance:        account.Balance.String(),
+		OverdraftLimit: decimalOrZero(account.OverdraftLimit),
+		Status:         string(account.Status),
+		CreatedAt:      account.CreatedAt,
+		UpdatedAt:      account.UpdatedAt,
+		TakenAt:        snapshot.TakenAt,
+	}
+	if err := s.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&model).Error; err != nil {
+		return fmt.Errorf("failed to save account snapshot: %v", err)
+	}
+	return nil
+}
+
+// Overwrites the columns the events determine. A missing row is recreated
+// with its held balance summed from its active holds; its fee waiver and
+// interest plan are not events and go back to their defaults.
+func (s *AccountEventStore) ReplaceProjection(account *domain.Account) error {
+	result := s.db.Model(&models.Account{}).
+		Where("id = ?", account.ID).
+		Updates(map[string]interface{}{
+			"name":            account.Name,
+			"type":            string(account.Type),
+			"currency":        string(account.Currency),
+			"balance":         account.Balance.String(),
+			"overdraft_limit": decimalOrZero(account.OverdraftLimit),
+			"status":          string(account.Status),
+			"version":         account.Version,
+			"updated_at":      time.Now(),
+		})
+	if result.Error != nil {
+		return fmt.Errorf("failed to replace account projection: %v", result.Error)
+	}
+	if result.RowsAffected == 1 {
+		return nil
+	}
+
+	var held string
+	err := s.db.Model(&models.Hold{}).
+		Select("COALESCE(SUM(amount), 0)").
+		Where("account_id = ? AND status = ?", account.ID, string(domain.HoldStatusActive)).
+		Scan(&held).Error
+	if err != nil {
+		return fmt.Errorf("failed to sum active holds: %v", err)
+	}
+	model := mapDomainToModel(account)
+	model.Held = held
+	if err := s.db.Create(model).Error; err != nil {
+		return fmt.Errorf("failed to recreate account projection: %v", err)
+	}
+	return nil
+}
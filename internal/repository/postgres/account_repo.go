@@ -1,14 +1,20 @@
 package postgres
 
 import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	"banking-ledger/internal/domain"
+	"banking-ledger/internal/money"
 
 	"banking-ledger/internal/repository/models"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type AccountRepository struct {
@@ -21,33 +27,91 @@ func NewAccountRepository(db *gorm.DB) *AccountRepository {
 
 func mapDomainToModel(account *domain.Account) *models.Account {
 	return &models.Account{
-		ID:        account.ID,
-		Name:      account.Name,
-		Balance:   account.Balance,
-		CreatedAt: account.CreatedAt,
-		UpdatedAt: account.UpdatedAt,
+		ID:             account.ID,
+		Name:           account.Name,
+		Type:           string(account.Type),
+		Currency:       string(account.Currency),
+		Balance:        account.Balance.String(),
+		Held:           decimalOrZero(account.Held),
+		OverdraftLimit: decimalOrZero(account.OverdraftLimit),
+		Status:         string(account.Status),
+		FeesWaived:     account.FeesWaived,
+		InterestPlanID: optionalString(account.InterestPlanID),
+		CreatedAt:      account.CreatedAt,
+		UpdatedAt:      account.UpdatedAt,
+		Version:        account.Version,
 	}
 }
 
-func mapModelToDomain(model *models.Account) *domain.Account {
-	return &domain.Account{
-		ID:        model.ID,
-		Name:      model.Name,
-		Balance:   model.Balance,
-		CreatedAt: model.CreatedAt,
-		UpdatedAt: model.UpdatedAt,
+func optionalString(s string) *string {
+	if s == "" {
+		return nil
 	}
+	return &s
 }
 
-// Inserts a new account into the database
-func (r *AccountRepository) Create(account *domain.Account) error {
-	model := mapDomainToModel(account)
-	result := r.db.Create(model)
-	if result.Error != nil {
-		return fmt.Errorf("failed to create account: %v", result.Error)
+// Formats an optional amount, treating an unset one as zero
+func decimalOrZero(m money.Money) string {
+	if m.Currency == "" {
+		return "0"
 	}
+	return m.String()
+}
 
-	return nil
+// Parses an optional decimal column in the account's currency
+func parseOrZero(value string, currency money.Currency) (money.Money, error) {
+	if value == "" {
+		return money.Zero(currency), nil
+	}
+	return money.Parse(value, currency)
+}
+
+func mapModelToDomain(model *models.Account) (*domain.Account, error) {
+	currency := money.Currency(model.Currency)
+	balance, err := money.Parse(model.Balance, currency)
+	if err != nil {
+		return nil, fmt.Errorf("invalid balance %q on account %s: %v", model.Balance, model.ID, err)
+	}
+	held, err := parseOrZero(model.Held, currency)
+	if err != nil {
+		return nil, fmt.Errorf("invalid held balance %q on account %s: %v", model.Held, model.ID, err)
+	}
+	overdraftLimit, err := parseOrZero(model.OverdraftLimit, currency)
+	if err != nil {
+		return nil, fmt.Errorf("invalid overdraft limit %q on account %s: %v", model.OverdraftLimit, model.ID, err)
+	}
+
+	account := &domain.Account{
+		ID:             model.ID,
+		Name:           model.Name,
+		Type:           domain.AccountType(model.Type),
+		Currency:       currency,
+		Balance:        balance,
+		Held:           held,
+		OverdraftLimit: overdraftLimit,
+		Status:         domain.AccountStatus(model.Status),
+		FeesWaived:     model.FeesWaived,
+		CreatedAt:      model.CreatedAt,
+		UpdatedAt:      model.UpdatedAt,
+		Version:        model.Version,
+	}
+	if model.InterestPlanID != nil {
+		account.InterestPlanID = *model.InterestPlanID
+	}
+	return account, nil
+}
+
+// Inserts a new account, which must have a zero balance, together with the
+// event that opens it
+func (r *AccountRepository) Create(account *domain.Account) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		account.Version = 1
+		model := mapDomainToModel(account)
+		if err := tx.Create(model).Error; err != nil {
+			return fmt.Errorf("failed to create account: %v", err)
+		}
+		return appendAccountEvent(tx, domain.NewOpenedEvent(account), account.Version, model.Currency)
+	})
 }
 
 // retrieves an account by its ID
@@ -56,46 +120,361 @@ func (r *AccountRepository) GetByID(id string) (*domain.Account, error) {
 	result := r.db.First(&model, "id = ?", id)
 	if result.Error != nil {
 		if result.Error == gorm.ErrRecordNotFound {
-			return nil, fmt.Errorf("account not found")
+			return nil, domain.ErrAccountNotFound
 		}
 		return nil, fmt.Errorf("failed to retrieve account: %v", result.Error)
 	}
-	return mapModelToDomain(&model), nil
+	return mapModelToDomain(&model)
+}
+
+// Reads the accounts with SELECT ... FOR UPDATE. Rows are locked in ID order,
+// so two transactions that each lock all their accounts in one call cannot
+// deadlock; locking some first and the rest in a later call gives that up.
+func (r *AccountRepository) LockForUpdate(ids ...string) (map[string]*domain.Account, error) {
+	var rows []models.Account
+	result := r.db.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id IN ?", ids).
+		Order("id").
+		Find(&rows)
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to lock accounts: %v", result.Error)
+	}
+
+	accounts := make(map[string]*domain.Account, len(rows))
+	for i := range rows {
+		account, err := mapModelToDomain(&rows[i])
+		if err != nil {
+			return nil, err
+		}
+		accounts[account.ID] = account
+	}
+	for _, id := range ids {
+		if _, ok := accounts[id]; !ok {
+			return nil, domain.ErrAccountNotFound
+		}
+	}
+	return accounts, nil
+}
+
+// Sets the account's status and records the change in the same transaction
+func (r *AccountRepository) UpdateStatus(change *domain.AccountStatusChange) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		updates := map[string]interface{}{
+			"status":     string(change.ToStatus),
+			"updated_at": change.CreatedAt,
+		}
+		event := domain.NewStatusEvent(change.AccountID, change.ToStatus, change.CreatedAt)
+		err := updateAccountWithEvent(tx, change.AccountID, updates, event)
+		if errors.Is(err, domain.ErrAccountNotFound) {
+			return err
+		}
+		if err != nil {
+			return fmt.Errorf("failed to update account status: %v", err)
+		}
+
+		model := models.AccountStatusChange{
+			ID:         change.ID,
+			AccountID:  change.AccountID,
+			FromStatus: string(change.FromStatus),
+			ToStatus:   string(change.ToStatus),
+			Reason:     change.Reason,
+			Actor:      change.Actor,
+			CreatedAt:  change.CreatedAt,
+		}
+		if err := tx.Create(&model).Error; err != nil {
+			return fmt.Errorf("failed to record account status change: %v", err)
+		}
+		return nil
+	})
+}
+
+// Lists an account's status changes, oldest first
+func (r *AccountRepository) ListStatusChanges(accountID string) ([]*domain.AccountStatusChange, error) {
+	var rows []models.AccountStatusChange
+	result := r.db.Where("account_id = ?", accountID).Order("created_at, id").Find(&rows)
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to list account status changes: %v", result.Error)
+	}
+
+	changes := make([]*domain.AccountStatusChange, 0, len(rows))
+	for _, row := range rows {
+		changes = append(changes, &domain.AccountStatusChange{
+			ID:         row.ID,
+			AccountID:  row.AccountID,
+			FromStatus: domain.AccountStatus(row.FromStatus),
+			ToStatus:   domain.AccountStatus(row.ToStatus),
+			Reason:     row.Reason,
+			Actor:      row.Actor,
+			CreatedAt:  row.CreatedAt,
+		})
+	}
+	return changes, nil
 }
 
-// Updates the balance of an account
-func (r *AccountRepository) UpdateBalance(id string, newBalance float64) error {
+// Sets the account's overdraft limit and records the change in the same transaction
+func (r *AccountRepository) UpdateOverdraftLimit(change *domain.OverdraftLimitChange) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		updates := map[string]interface{}{
+			"overdraft_limit": change.NewLimit.String(),
+			"updated_at":      change.CreatedAt,
+		}
+		event := domain.NewOverdraftLimitEvent(change.AccountID, change.NewLimit, change.CreatedAt)
+		err := updateAccountWithEvent(tx, change.AccountID, updates, event)
+		if errors.Is(err, domain.ErrAccountNotFound) {
+			return err
+		}
+		if err != nil {
+			return fmt.Errorf("failed to update overdraft limit: %v", err)
+		}
+
+		model := models.OverdraftLimitChange{
+			ID:        change.ID,
+			AccountID: change.AccountID,
+			OldLimit:  change.OldLimit.String(),
+			NewLimit:  change.NewLimit.String(),
+			Currency:  string(change.NewLimit.Currency),
+			Reason:    change.Reason,
+			Actor:     change.Actor,
+			CreatedAt: change.CreatedAt,
+		}
+		if err := tx.Create(&model).Error; err != nil {
+			return fmt.Errorf("failed to record overdraft limit change: %v", err)
+		}
+		return nil
+	})
+}
+
+// Turns fee charging off or back on for the account
+func (r *AccountRepository) SetFeesWaived(id string, waived bool) error {
 	result := r.db.Model(&models.Account{}).
 		Where("id = ?", id).
 		Updates(map[string]interface{}{
-			"balance":    newBalance,
-			"updated_at": time.Now(),
+			"fees_waived": waived,
+			"updated_at":  time.Now(),
 		})
+	if result.Error != nil {
+		return fmt.Errorf("failed to update fee waiver: %v", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return domain.ErrAccountNotFound
+	}
+	return nil
+}
 
+// Lists an account's overdraft limit changes, oldest first
+func (r *AccountRepository) ListOverdraftLimitChanges(accountID string) ([]*domain.OverdraftLimitChange, error) {
+	var rows []models.OverdraftLimitChange
+	result := r.db.Where("account_id = ?", accountID).Order("created_at, id").Find(&rows)
 	if result.Error != nil {
-		return fmt.Errorf("failed to update account balance: %v", result.Error)
+		return nil, fmt.Errorf("failed to list overdraft limit changes: %v", result.Error)
 	}
 
-	if result.RowsAffected == 0 {
-		return fmt.Errorf("account not found")
+	changes := make([]*domain.OverdraftLimitChange, 0, len(rows))
+	for _, row := range rows {
+		currency := money.Currency(row.Currency)
+		oldLimit, err := money.Parse(row.OldLimit, currency)
+		if err != nil {
+			return nil, fmt.Errorf("invalid old limit %q on change %s: %v", row.OldLimit, row.ID, err)
+		}
+		newLimit, err := money.Parse(row.NewLimit, currency)
+		if err != nil {
+			return nil, fmt.Errorf("invalid new limit %q on change %s: %v", row.NewLimit, row.ID, err)
+		}
+		changes = append(changes, &domain.OverdraftLimitChange{
+			ID:        row.ID,
+			AccountID: row.AccountID,
+			OldLimit:  oldLimit,
+			NewLimit:  newLimit,
+			Reason:    row.Reason,
+			Actor:     row.Actor,
+			CreatedAt: row.CreatedAt,
+		})
 	}
+	return changes, nil
+}
 
-	return nil
+const (
+	defaultPageSize = 50
+	maxPageSize     = 200
+)
+
+// Position of the last account on a page, tied to the sort it was made for
+type accountCursor struct {
+	SortBy     domain.AccountSortField `json:"s"`
+	Descending bool                    `json:"d"`
+	Value      string                  `json:"v"`
+	ID         string                  `json:"i"`
+}
+
+func sortValue(account *domain.Account, sortBy domain.AccountSortField) string {
+	switch sortBy {
+	case domain.AccountSortName:
+		return account.Name
+	case domain.AccountSortBalance:
+		return account.Balance.String()
+	}
+	return account.CreatedAt.Format(time.RFC3339Nano)
+}
+
+func encodeAccountCursor(c accountCursor) string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeAccountCursor(value string, filter domain.AccountFilter) (*accountCursor, interface{}, error) {
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, nil, domain.ErrInvalidCursor
+	}
+	var c accountCursor
+	if err := json.Unmarshal(data, &c); err != nil || c.ID == "" {
+		return nil, nil, domain.ErrInvalidCursor
+	}
+	// A cursor only makes sense for the ordering that produced it
+	if c.SortBy != filter.SortBy || c.Descending != filter.Descending {
+		return nil, nil, domain.ErrInvalidCursor
+	}
+	if c.SortBy == domain.AccountSortCreatedAt {
+		t, err := time.Parse(time.RFC3339Nano, c.Value)
+		if err != nil {
+			return nil, nil, domain.ErrInvalidCursor
+		}
+		return &c, t, nil
+	}
+	return &c, c.Value, nil
 }
 
-// retrieves all accounts
-func (r *AccountRepository) List() ([]*domain.Account, error) {
-	var models []models.Account
-	result := r.db.Order("created_at DESC").Find(&models)
+// Applies the filter conditions shared by the page query and the total count
+func applyAccountFilter(query *gorm.DB, filter domain.AccountFilter) *gorm.DB {
+	if filter.NamePrefix != "" {
+		escaped := strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(filter.NamePrefix)
+		query = query.Where("name ILIKE ?", escaped+"%")
+	}
+	if filter.Currency != "" {
+		query = query.Where("currency = ?", string(filter.Currency))
+	}
+	if filter.Status != "" {
+		query = query.Where("status = ?", string(filter.Status))
+	}
+	if filter.Type != "" {
+		query = query.Where("type = ?", string(filter.Type))
+	}
+	if filter.Overdrawn {
+		query = query.Where("balance < 0")
+	}
+	// Balances only compare within one currency
+	if filter.MinBalance != nil {
+		query = query.Where("balance >= ? AND currency = ?", filter.MinBalance.String(), string(filter.MinBalance.Currency))
+	}
+	if filter.MaxBalance != nil {
+		query = query.Where("balance <= ? AND currency = ?", filter.MaxBalance.String(), string(filter.MaxBalance.Currency))
+	}
+	if filter.CreatedFrom != nil {
+		query = query.Where("created_at >= ?", *filter.CreatedFrom)
+	}
+	if filter.CreatedTo != nil {
+		query = query.Where("created_at < ?", *filter.CreatedTo)
+	}
+	return query
+}
+
+// Lists one page of accounts using keyset pagination on (sort column, id),
+// so deep pages cost the same as the first one
+func (r *AccountRepository) List(filter domain.AccountFilter) (*domain.AccountPage, error) {
+	if filter.SortBy == "" {
+		filter.SortBy = domain.AccountSortCreatedAt
+		filter.Descending = true
+	}
+	column := string(filter.SortBy)
+	switch filter.SortBy {
+	case domain.AccountSortCreatedAt, domain.AccountSortName, domain.AccountSortBalance:
+	default:
+		return nil, fmt.Errorf("unsupported sort field: %s", filter.SortBy)
+	}
+
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = defaultPageSize
+	}
+	if limit > maxPageSize {
+		limit = maxPageSize
+	}
+
+	direction, comparison := "ASC", ">"
+	if filter.Descending {
+		direction, comparison = "DESC", "<"
+	}
+
+	query := applyAccountFilter(r.db.Model(&models.Account{}), filter)
+	if filter.Cursor != "" {
+		cursor, value, err := decodeAccountCursor(filter.Cursor, filter)
+		if err != nil {
+			return nil, err
+		}
+		placeholder := "?"
+		if filter.SortBy == domain.AccountSortBalance {
+			placeholder = "CAST(? AS numeric)"
+		}
+		query = query.Where(fmt.Sprintf("(%s, id) %s (%s, ?)", column, comparison, placeholder), value, cursor.ID)
+	}
+
+	// One extra row tells us whether another page exists
+	var rows []models.Account
+	result := query.Order(fmt.Sprintf("%s %s, id %s", column, direction, direction)).
+		Limit(limit + 1).
+		Find(&rows)
 	if result.Error != nil {
 		return nil, fmt.Errorf("failed to list accounts: %v", result.Error)
 	}
 
-	accounts := make([]*domain.Account, len(models))
-	for i, model := range models {
-		modelCopy := model
-		accounts[i] = mapModelToDomain(&modelCopy)
+	accounts := make([]*domain.Account, 0, len(rows))
+	for i := range rows {
+		account, err := mapModelToDomain(&rows[i])
+		if err != nil {
+			return nil, err
+		}
+		accounts = append(accounts, account)
 	}
 
-	return accounts, nil
+	page := &domain.AccountPage{Accounts: accounts}
+	if len(accounts) > limit {
+		page.Accounts = accounts[:limit]
+		last := accounts[limit-1]
+		page.NextCursor = encodeAccountCursor(accountCursor{
+			SortBy:     filter.SortBy,
+			Descending: filter.Descending,
+			Value:      sortValue(last, filter.SortBy),
+			ID:         last.ID,
+		})
+	}
+
+	if filter.IncludeTotal {
+		var total int64
+		if err := applyAccountFilter(r.db.Model(&models.Account{}), filter).Count(&total).Error; err != nil {
+			return nil, fmt.Errorf("failed to count accounts: %v", err)
+		}
+		page.Total = &total
+
+		var sums []struct {
+			Currency string
+			Total    string
+		}
+		err := applyAccountFilter(r.db.Model(&models.Account{}), filter).
+			Select("currency, SUM(balance) AS total").
+			Group("currency").
+			Order("currency").
+			Scan(&sums).Error
+		if err != nil {
+			return nil, fmt.Errorf("failed to total account balances: %v", err)
+		}
+		for _, sum := range sums {
+			total, err := money.Parse(sum.Total, money.Currency(sum.Currency))
+			if err != nil {
+				return nil, err
+			}
+			page.Totals = append(page.Totals, total)
+		}
+	}
+
+	return page, nil
 }
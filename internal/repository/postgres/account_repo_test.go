@@ -0,0 +1,166 @@
+package postgres
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+
+	"banking-ledger/internal/domain"
+	"banking-ledger/internal/money"
+	"banking-ledger/internal/repository/models"
+)
+
+// Builds queries without connecting, so the generated SQL can be checked
+func dryRunDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(postgres.New(postgres.Config{DSN: "host=localhost"}), &gorm.Config{
+		DryRun:               true,
+		DisableAutomaticPing: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+func TestAccountCursorRoundTrip(t *testing.T) {
+	createdAt := time.Date(2024, time.March, 1, 12, 30, 0, 123456789, time.UTC)
+
+	tests := []struct {
+		name   string
+		cursor accountCursor
+		value  interface{}
+	}{
+		{
+			name:   "created_at descending",
+			cursor: accountCursor{SortBy: domain.AccountSortCreatedAt, Descending: true, Value: createdAt.Format(time.RFC3339Nano), ID: "acc-1"},
+			value:  createdAt,
+		},
+		{
+			name:   "name ascending",
+			cursor: accountCursor{SortBy: domain.AccountSortName, Value: "O'Brien & Sons", ID: "acc-2"},
+			value:  "O'Brien & Sons",
+		},
+		{
+			name:   "balance ascending",
+			cursor: accountCursor{SortBy: domain.AccountSortBalance, Value: "-12.50", ID: "acc-3"},
+			value:  "-12.50",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filter := domain.AccountFilter{SortBy: tt.cursor.SortBy, Descending: tt.cursor.Descending}
+			decoded, value, err := decodeAccountCursor(encodeAccountCursor(tt.cursor), filter)
+			if err != nil {
+				t.Fatalf("decodeAccountCursor returned an error: %v", err)
+			}
+			if *decoded != tt.cursor {
+				t.Errorf("cursor = %+v, want %+v", *decoded, tt.cursor)
+			}
+			if !reflect.DeepEqual(value, tt.value) {
+				t.Errorf("value = %#v, want %#v", value, tt.value)
+			}
+		})
+	}
+}
+
+func TestDecodeAccountCursorRejects(t *testing.T) {
+	nameAscending := domain.AccountFilter{SortBy: domain.AccountSortName}
+	nameCursor := encodeAccountCursor(accountCursor{SortBy: domain.AccountSortName, Value: "alice", ID: "acc-1"})
+
+	tests := []struct {
+		name   string
+		value  string
+		filter domain.AccountFilter
+	}{
+		{"not base64", "!!!", nameAscending},
+		{"not json", "bm90IGpzb24", nameAscending},
+		{"missing id", encodeAccountCursor(accountCursor{SortBy: domain.AccountSortName, Value: "alice"}), nameAscending},
+		{"different sort", nameCursor, domain.AccountFilter{SortBy: domain.AccountSortBalance}},
+		{"different order", nameCursor, domain.AccountFilter{SortBy: domain.AccountSortName, Descending: true}},
+		{"bad timestamp", encodeAccountCursor(accountCursor{SortBy: domain.AccountSortCreatedAt, Value: "yesterday", ID: "acc-1"}),
+			domain.AccountFilter{SortBy: domain.AccountSortCreatedAt}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, _, err := decodeAccountCursor(tt.value, tt.filter); !errors.Is(err, domain.ErrInvalidCursor) {
+				t.Errorf("decodeAccountCursor = %v, want ErrInvalidCursor", err)
+			}
+		})
+	}
+}
+
+func TestApplyAccountFilter(t *testing.T) {
+	db := dryRunDB(t)
+	eur := func(amount string) *money.Money {
+		m := money.MustParse(amount, money.EUR)
+		return &m
+	}
+	from := time.Date(2024, time.March, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name   string
+		filter domain.AccountFilter
+		sql    string
+		vars   []interface{}
+	}{
+		{
+			name: "no filter",
+			sql:  `SELECT * FROM "accounts"`,
+		},
+		{
+			name:   "name prefix wildcards are literal",
+			filter: domain.AccountFilter{NamePrefix: `50%_off\`},
+			sql:    `SELECT * FROM "accounts" WHERE name ILIKE $1`,
+			vars:   []interface{}{`50\%\_off\\%`},
+		},
+		{
+			name:   "balance range stays in its currency",
+			filter: domain.AccountFilter{MinBalance: eur("10.00"), MaxBalance: eur("20.00")},
+			sql:    `SELECT * FROM "accounts" WHERE (balance >= $1 AND currency = $2) AND (balance <= $3 AND currency = $4)`,
+			vars:   []interface{}{"10.00", "EUR", "20.00", "EUR"},
+		},
+		{
+			name: "every condition",
+			filter: domain.AccountFilter{
+				Currency:    money.EUR,
+				Status:      domain.AccountStatusActive,
+				Type:        domain.AccountTypeChecking,
+				Overdrawn:   true,
+				CreatedFrom: &from,
+			},
+			sql:  `SELECT * FROM "accounts" WHERE currency = $1 AND status = $2 AND type = $3 AND balance < 0 AND created_at >= $4`,
+			vars: []interface{}{"EUR", "active", "checking", from},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var rows []models.Account
+			statement := applyAccountFilter(db.Model(&models.Account{}), tt.filter).Find(&rows).Statement
+			if got := statement.SQL.String(); got != tt.sql {
+				t.Errorf("sql = %s, want %s", got, tt.sql)
+			}
+			if len(statement.Vars) != len(tt.vars) || (len(tt.vars) > 0 && !reflect.DeepEqual(statement.Vars, tt.vars)) {
+				t.Errorf("vars = %#v, want %#v", statement.Vars, tt.vars)
+			}
+		})
+	}
+}
+
+func TestListAccountsValidatesSortAndCursor(t *testing.T) {
+	repo := NewAccountRepository(dryRunDB(t))
+
+	if _, err := repo.List(domain.AccountFilter{SortBy: "owner"}); err == nil {
+		t.Error("List sorted by an unknown field succeeded")
+	}
+
+	// The default sort is newest first, so a name cursor does not fit it
+	cursor := encodeAccountCursor(accountCursor{SortBy: domain.AccountSortName, Value: "alice", ID: "acc-1"})
+	if _, err := repo.List(domain.AccountFilter{Cursor: cursor}); !errors.Is(err, domain.ErrInvalidCursor) {
+		t.Errorf("List with a cursor from another sort = %v, want ErrInvalidCursor", err)
+	}
+}
@@ -3,11 +3,14 @@ package postgres
 import (
 	"fmt"
 	"log"
+	"time"
 
+	"banking-ledger/internal/domain"
 	"banking-ledger/internal/repository/models"
 
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 func NewConnection(url string) (*gorm.DB, error) {
@@ -28,6 +31,65 @@ func runMigrations(db *gorm.DB) error {
 	if err := db.AutoMigrate(&models.Account{}); err != nil {
 		return fmt.Errorf("failed to migrate accounts table: %v", err)
 	}
+	// Keyset pagination indexes, one per sortable column
+	for _, stmt := range []string{
+		"CREATE INDEX IF NOT EXISTS idx_accounts_created_at_id ON accounts (created_at, id)",
+		"CREATE INDEX IF NOT EXISTS idx_accounts_name_id ON accounts (name, id)",
+		"CREATE INDEX IF NOT EXISTS idx_accounts_balance_id ON accounts (balance, id)",
+		"CREATE INDEX IF NOT EXISTS idx_accounts_overdrawn ON accounts (id) WHERE balance < 0",
+	} {
+		if err := db.Exec(stmt).Error; err != nil {
+			return fmt.Errorf("failed to create account indexes: %v", err)
+		}
+	}
+	if err := db.AutoMigrate(&models.OverdraftLimitChange{}); err != nil {
+		return fmt.Errorf("failed to migrate overdraft limit changes table: %v", err)
+	}
+	if err := db.AutoMigrate(&models.AccountStatusChange{}); err != nil {
+		return fmt.Errorf("failed to migrate account status changes table: %v", err)
+	}
+	if err := db.AutoMigrate(&models.JournalEntry{}, &models.Posting{}, &models.BalanceHistory{}); err != nil {
+		return fmt.Errorf("failed to migrate journal tables: %v", err)
+	}
+	if err := db.AutoMigrate(&models.ProcessedTransaction{}); err != nil {
+		return fmt.Errorf("failed to migrate processed transactions table: %v", err)
+	}
+	if err := db.AutoMigrate(&models.IdempotencyKey{}); err != nil {
+		return fmt.Errorf("failed to migrate idempotency keys table: %v", err)
+	}
+	if err := db.AutoMigrate(&models.ReversalTotal{}); err != nil {
+		return fmt.Errorf("failed to migrate reversal totals table: %v", err)
+	}
+	if err := db.AutoMigrate(&models.Hold{}); err != nil {
+		return fmt.Errorf("failed to migrate holds table: %v", err)
+	}
+	if err := db.AutoMigrate(&models.FXRate{}); err != nil {
+		return fmt.Errorf("failed to migrate fx rates table: %v", err)
+	}
+	if err := db.AutoMigrate(&models.LimitRule{}, &models.LimitUsage{}); err != nil {
+		return fmt.Errorf("failed to migrate limit tables: %v", err)
+	}
+	if err := db.AutoMigrate(&models.FeeSchedule{}, &models.FeeCharge{}); err != nil {
+		return fmt.Errorf("failed to migrate fee tables: %v", err)
+	}
+	if err := db.AutoMigrate(&models.InterestPlan{}, &models.InterestAccrual{}, &models.InterestPosting{}); err != nil {
+		return fmt.Errorf("failed to migrate interest tables: %v", err)
+	}
+	if err := db.AutoMigrate(&models.ScheduledTransaction{}, &models.ScheduledExecution{}); err != nil {
+		return fmt.Errorf("failed to migrate scheduled transaction tables: %v", err)
+	}
+	if err := db.AutoMigrate(&models.AccountEvent{}, &models.AccountSnapshot{}); err != nil {
+		return fmt.Errorf("failed to migrate account event tables: %v", err)
+	}
+	if err := backfillOpeningEntries(db); err != nil {
+		return fmt.Errorf("failed to backfill opening journal entries: %v", err)
+	}
+	if err := backfillBalanceHistory(db); err != nil {
+		return fmt.Errorf("failed to backfill balance history: %v", err)
+	}
+	if err := backfillAccountEvents(db); err != nil {
+		return fmt.Errorf("failed to backfill account events: %v", err)
+	}
 
 	return nil
 }
@@ -44,3 +106,135 @@ func Close(db *gorm.DB) {
 		log.Printf("Error closing database connection: %v", err)
 	}
 }
+
+// Accounts created before the journal existed have a balance but no postings.
+// Give each of them an opening entry so the balance can be derived again. The
+// entry is inserted as it stands, since the cached balance already includes
+// it; backfillBalanceHistory and backfillAccountEvents record the rest. Every
+// service runs this at start-up, so the account row is locked and the
+// postings checked again before inserting, or two could both add an entry.
+func backfillOpeningEntries(db *gorm.DB) error {
+	var ids []string
+	err := db.Model(&models.Account{}).
+		Where("balance > 0 AND NOT EXISTS (SELECT 1 FROM postings p WHERE p.account_id = accounts.id)").
+		Order("id").Pluck("id", &ids).Error
+	if err != nil {
+		return err
+	}
+
+	for _, id := range ids {
+		err := db.Transaction(func(tx *gorm.DB) error {
+			var rows []models.Account
+			err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", id).Find(&rows).Error
+			if err != nil || len(rows) == 0 {
+				return err
+			}
+			var posted int64
+			if err := tx.Model(&models.Posting{}).Where("account_id = ?", id).Count(&posted).Error; err != nil {
+				return err
+			}
+			if posted > 0 {
+				return nil
+			}
+
+			account, err := mapModelToDomain(&rows[0])
+			if err != nil {
+				return err
+			}
+			entry := domain.NewOpeningEntry(account)
+			if err := entry.Validate(); err != nil {
+				return err
+			}
+			model := mapJournalEntryToModel(entry, time.Now())
+			if err := tx.Create(&model).Error; err != nil {
+				return err
+			}
+			log.Printf("Backfilled opening journal entry for account %s", account.ID)
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Accounts with postings made before balance history was recorded get their
+// history rebuilt from the journal, one row per entry with a running total.
+// Services starting together compute the same rows, so a row another one
+// has already inserted is skipped.
+func backfillBalanceHistory(db *gorm.DB) error {
+	result := db.Exec(`
+		INSERT INTO balance_history (account_id, entry_id, transaction_id, change, balance, currency, created_at)
+		SELECT account_id, entry_id, transaction_id, change,
+			SUM(change) OVER (PARTITION BY account_id ORDER BY created_at, entry_id),
+			currency, created_at
+		FROM (
+			SELECT p.account_id, p.entry_id, e.transaction_id, p.currency,
+				SUM(CASE WHEN p.direction = ? THEN p.amount ELSE -p.amount END) AS change,
+				MIN(p.created_at) AS created_at
+			FROM postings p
+			JOIN journal_entries e ON e.id = p.entry_id
+			JOIN accounts a ON a.id = p.account_id AND a.currency = p.currency
+			WHERE NOT EXISTS (SELECT 1 FROM balance_history h WHERE h.account_id = p.account_id)
+			GROUP BY p.account_id, p.entry_id, e.transaction_id, p.currency
+		) entries
+		ORDER BY account_id, created_at, entry_id
+		ON CONFLICT (account_id, entry_id) DO NOTHING`, string(domain.Credit))
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected > 0 {
+		log.Printf("Backfilled %d balance history rows", result.RowsAffected)
+	}
+	return nil
+}
+
+// Accounts created before account events were recorded get events that
+// reproduce their current state: opened, one credit or debit for the whole
+// balance, then the status if it is not active. The row lock and version
+// check let several services run this at start-up at once.
+func backfillAccountEvents(db *gorm.DB) error {
+	var ids []string
+	if err := db.Model(&models.Account{}).Where("version = 0").Order("id").Pluck("id", &ids).Error; err != nil {
+		return err
+	}
+
+	for _, id := range ids {
+		err := db.Transaction(func(tx *gorm.DB) error {
+			var rows []models.Account
+			err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+				Where("id = ? AND version = 0", id).Find(&rows).Error
+			if err != nil || len(rows) == 0 {
+				return err
+			}
+			account, err := mapModelToDomain(&rows[0])
+			if err != nil {
+				return err
+			}
+
+			opened := *account
+			opened.Status = domain.AccountStatusActive
+			events := []*domain.AccountEvent{domain.NewOpenedEvent(&opened)}
+			if !account.Balance.IsZero() {
+				events = append(events, domain.NewBalanceEvent(account.ID, "", account.Balance, account.UpdatedAt))
+			}
+			if account.Status != domain.AccountStatusActive {
+				events = append(events, domain.NewStatusEvent(account.ID, account.Status, account.UpdatedAt))
+			}
+			for i, event := range events {
+				if err := appendAccountEvent(tx, event, int64(i+1), rows[0].Currency); err != nil {
+					return err
+				}
+			}
+			return tx.Model(&models.Account{}).Where("id = ?", id).Update("version", len(events)).Error
+		})
+		if err != nil {
+			return err
+		}
+	}
+	if len(ids) > 0 {
+		log.Printf("Backfilled account events for %d accounts", len(ids))
+	}
+	return nil
+}
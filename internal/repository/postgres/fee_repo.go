@@ -0,0 +1,238 @@
+package postgres
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"banking-ledger/internal/constants"
+	"banking-ledger/internal/domain"
+	"banking-ledger/internal/money"
+	"banking-ledger/internal/repository/models"
+
+	"gorm.io/gorm"
+)
+
+// FeeRepository stores fee schedules and the fees charged under them
+type FeeRepository struct {
+	db *gorm.DB
+}
+
+func NewFeeRepository(db *gorm.DB) *FeeRepository {
+	return &FeeRepository{db: db}
+}
+
+func optionalDecimal(m *money.Money) *string {
+	if m == nil {
+		return nil
+	}
+	value := m.String()
+	return &value
+}
+
+func parseOptionalDecimal(value *string, currency money.Currency) (*money.Money, error) {
+	if value == nil {
+		return nil, nil
+	}
+	parsed, err := money.Parse(*value, currency)
+	if err != nil {
+		return nil, err
+	}
+	return &parsed, nil
+}
+
+func mapFeeScheduleDomainToModel(schedule *domain.FeeSchedule) (*models.FeeSchedule, error) {
+	model := &models.FeeSchedule{
+		ID:              schedule.ID,
+		Name:            schedule.Name,
+		TransactionType: string(schedule.TransactionType),
+		AccountType:     string(schedule.AccountType),
+		Currency:        string(schedule.Currency),
+		Kind:            string(schedule.Kind),
+		Flat:            optionalDecimal(schedule.Flat),
+		Min:             optionalDecimal(schedule.Min),
+		Max:             optionalDecimal(schedule.Max),
+		Enabled:         schedule.Enabled,
+		CreatedAt:       schedule.CreatedAt,
+		UpdatedAt:       schedule.UpdatedAt,
+	}
+	if schedule.Rate != "" {
+		model.Rate = &schedule.Rate
+	}
+	if len(schedule.Tiers) > 0 {
+		tiers, err := json.Marshal(schedule.Tiers)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode fee tiers: %v", err)
+		}
+		model.Tiers = tiers
+	}
+	return model, nil
+}
+
+func mapFeeScheduleModelToDomain(model *models.FeeSchedule) (*domain.FeeSchedule, error) {
+	currency := money.Currency(model.Currency)
+	schedule := &domain.FeeSchedule{
+		ID:              model.ID,
+		Name:            model.Name,
+		TransactionType: constants.TransactionType(model.TransactionType),
+		AccountType:     domain.AccountType(model.AccountType),
+		Currency:        currency,
+		Kind:            domain.FeeKind(model.Kind),
+		Enabled:         model.Enabled,
+		CreatedAt:       model.CreatedAt,
+		UpdatedAt:       model.UpdatedAt,
+	}
+	var err error
+	if schedule.Flat, err = parseOptionalDecimal(model.Flat, currency); err != nil {
+		return nil, fmt.Errorf("invalid flat fee on fee schedule %s: %v", model.ID, err)
+	}
+	if schedule.Min, err = parseOptionalDecimal(model.Min, currency); err != nil {
+		return nil, fmt.Errorf("invalid minimum fee on fee schedule %s: %v", model.ID, err)
+	}
+	if schedule.Max, err = parseOptionalDecimal(model.Max, currency); err != nil {
+		return nil, fmt.Errorf("invalid maximum fee on fee schedule %s: %v", model.ID, err)
+	}
+	if model.Rate != nil {
+		schedule.Rate = *model.Rate
+	}
+	if len(model.Tiers) > 0 {
+		if err := json.Unmarshal(model.Tiers, &schedule.Tiers); err != nil {
+			return nil, fmt.Errorf("invalid tiers on fee schedule %s: %v", model.ID, err)
+		}
+	}
+	return schedule, nil
+}
+
+func mapFeeScheduleModelsToDomain(rows []models.FeeSchedule) ([]*domain.FeeSchedule, error) {
+	schedules := make([]*domain.FeeSchedule, 0, len(rows))
+	for i := range rows {
+		schedule, err := mapFeeScheduleModelToDomain(&rows[i])
+		if err != nil {
+			return nil, err
+		}
+		schedules = append(schedules, schedule)
+	}
+	return schedules, nil
+}
+
+// Inserts a new schedule
+func (r *FeeRepository) CreateSchedule(schedule *domain.FeeSchedule) error {
+	model, err := mapFeeScheduleDomainToModel(schedule)
+	if err != nil {
+		return err
+	}
+	if err := r.db.Create(model).Error; err != nil {
+		return fmt.Errorf("failed to create fee schedule: %v", err)
+	}
+	return nil
+}
+
+// retrieves a schedule by ID
+func (r *FeeRepository) GetSchedule(id string) (*domain.FeeSchedule, error) {
+	var model models.FeeSchedule
+	result := r.db.First(&model, "id = ?", id)
+	if result.Error != nil {
+		if result.Error == gorm.ErrRecordNotFound {
+			return nil, domain.ErrFeeScheduleNotFound
+		}
+		return nil, fmt.Errorf("failed to retrieve fee schedule: %v", result.Error)
+	}
+	return mapFeeScheduleModelToDomain(&model)
+}
+
+// Overwrites every field of an existing schedule
+func (r *FeeRepository) UpdateSchedule(schedule *domain.FeeSchedule) error {
+	model, err := mapFeeScheduleDomainToModel(schedule)
+	if err != nil {
+		return err
+	}
+	result := r.db.Model(&models.FeeSchedule{}).
+		Where("id = ?", schedule.ID).
+		Select("*").Omit("id", "created_at").
+		Updates(model)
+	if result.Error != nil {
+		return fmt.Errorf("failed to update fee schedule: %v", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return domain.ErrFeeScheduleNotFound
+	}
+	return nil
+}
+
+// Deletes a schedule; fees already charged under it are kept
+func (r *FeeRepository) DeleteSchedule(id string) error {
+	result := r.db.Delete(&models.FeeSchedule{}, "id = ?", id)
+	if result.Error != nil {
+		return fmt.Errorf("failed to delete fee schedule: %v", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return domain.ErrFeeScheduleNotFound
+	}
+	return nil
+}
+
+// Lists every schedule, oldest first
+func (r *FeeRepository) ListSchedules() ([]*domain.FeeSchedule, error) {
+	var rows []models.FeeSchedule
+	if err := r.db.Order("created_at, id").Find(&rows).Error; err != nil {
+		return nil, fmt.Errorf("failed to list fee schedules: %v", err)
+	}
+	return mapFeeScheduleModelsToDomain(rows)
+}
+
+// Lists the enabled schedules for the transaction type that cover the account
+func (r *FeeRepository) ListApplicableSchedules(account *domain.Account, transactionType constants.TransactionType) ([]*domain.FeeSchedule, error) {
+	var rows []models.FeeSchedule
+	err := r.db.
+		Where("enabled AND transaction_type = ? AND currency = ?", string(transactionType), string(account.Currency)).
+		Where("account_type IN ('', ?)", string(account.Type)).
+		Order("created_at, id").
+		Find(&rows).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list fee schedules: %v", err)
+	}
+	return mapFeeScheduleModelsToDomain(rows)
+}
+
+// Stores a fee charged for a transaction
+func (r *FeeRepository) RecordCharge(charge *domain.FeeCharge) error {
+	model := models.FeeCharge{
+		ID:            charge.ID,
+		TransactionID: charge.TransactionID,
+		AccountID:     charge.AccountID,
+		ScheduleID:    charge.ScheduleID,
+		Amount:        charge.Amount.String(),
+		Currency:      string(charge.Amount.Currency),
+		Description:   charge.Description,
+		CreatedAt:     charge.CreatedAt,
+	}
+	if err := r.db.Create(&model).Error; err != nil {
+		return fmt.Errorf("failed to record fee charge: %v", err)
+	}
+	return nil
+}
+
+// retrieves the fee charged for a transaction, or nil if there was none
+func (r *FeeRepository) GetCharge(transactionID string) (*domain.FeeCharge, error) {
+	var model models.FeeCharge
+	result := r.db.First(&model, "transaction_id = ?", transactionID)
+	if result.Error != nil {
+		if result.Error == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to retrieve fee charge: %v", result.Error)
+	}
+
+	amount, err := money.Parse(model.Amount, money.Currency(model.Currency))
+	if err != nil {
+		return nil, fmt.Errorf("invalid amount %q on fee charge %s: %v", model.Amount, model.ID, err)
+	}
+	return &domain.FeeCharge{
+		ID:            model.ID,
+		TransactionID: model.TransactionID,
+		AccountID:     model.AccountID,
+		ScheduleID:    model.ScheduleID,
+		Amount:        amount,
+		Description:   model.Description,
+		CreatedAt:     model.CreatedAt,
+	}, nil
+}
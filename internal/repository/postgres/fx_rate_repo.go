@@ -0,0 +1,76 @@
+package postgres
+
+import (
+	"fmt"
+	"time"
+
+	"banking-ledger/internal/domain"
+	"banking-ledger/internal/money"
+	"banking-ledger/internal/repository/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// FXRateRepository is the local rate table; it serves as the FX rate provider
+type FXRateRepository struct {
+	db *gorm.DB
+}
+
+func NewFXRateRepository(db *gorm.DB) *FXRateRepository {
+	return &FXRateRepository{db: db}
+}
+
+func mapFXRateModelToDomain(model *models.FXRate) *domain.FXRate {
+	return &domain.FXRate{
+		From: money.Currency(model.FromCurrency),
+		To:   money.Currency(model.ToCurrency),
+		Rate: model.Rate,
+		AsOf: model.AsOf,
+	}
+}
+
+// retrieves the current rate for converting from into to
+func (r *FXRateRepository) GetRate(from, to money.Currency) (*domain.FXRate, error) {
+	var model models.FXRate
+	result := r.db.First(&model, "from_currency = ? AND to_currency = ?", string(from), string(to))
+	if result.Error != nil {
+		if result.Error == gorm.ErrRecordNotFound {
+			return nil, fmt.Errorf("%w: %s/%s", domain.ErrFXRateNotFound, from, to)
+		}
+		return nil, fmt.Errorf("failed to retrieve fx rate: %v", result.Error)
+	}
+	return mapFXRateModelToDomain(&model), nil
+}
+
+// Inserts the pair or replaces its rate
+func (r *FXRateRepository) SetRate(rate *domain.FXRate) error {
+	model := models.FXRate{
+		FromCurrency: string(rate.From),
+		ToCurrency:   string(rate.To),
+		Rate:         rate.Rate,
+		AsOf:         rate.AsOf,
+		UpdatedAt:    time.Now(),
+	}
+	result := r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "from_currency"}, {Name: "to_currency"}},
+		DoUpdates: clause.AssignmentColumns([]string{"rate", "as_of", "updated_at"}),
+	}).Create(&model)
+	if result.Error != nil {
+		return fmt.Errorf("failed to store fx rate: %v", result.Error)
+	}
+	return nil
+}
+
+// Lists every stored rate ordered by currency pair
+func (r *FXRateRepository) ListRates() ([]*domain.FXRate, error) {
+	var rows []models.FXRate
+	if err := r.db.Order("from_currency, to_currency").Find(&rows).Error; err != nil {
+		return nil, fmt.Errorf("failed to list fx rates: %v", err)
+	}
+	rates := make([]*domain.FXRate, 0, len(rows))
+	for i := range rows {
+		rates = append(rates, mapFXRateModelToDomain(&rows[i]))
+	}
+	return rates, nil
+}
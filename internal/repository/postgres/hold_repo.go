@@ -0,0 +1,184 @@
+package postgres
+
+import (
+	"fmt"
+	"time"
+
+	"banking-ledger/internal/domain"
+	"banking-ledger/internal/money"
+	"banking-ledger/internal/repository/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type HoldRepository struct {
+	db *gorm.DB
+}
+
+func NewHoldRepository(db *gorm.DB) *HoldRepository {
+	return &HoldRepository{db: db}
+}
+
+func mapHoldModelToDomain(model *models.Hold) (*domain.Hold, error) {
+	currency := money.Currency(model.Currency)
+	amount, err := money.Parse(model.Amount, currency)
+	if err != nil {
+		return nil, fmt.Errorf("invalid amount %q on hold %s: %v", model.Amount, model.ID, err)
+	}
+
+	hold := &domain.Hold{
+		ID:            model.ID,
+		AccountID:     model.AccountID,
+		Amount:        amount,
+		Status:        domain.HoldStatus(model.Status),
+		Description:   model.Description,
+		TransactionID: model.TransactionID,
+		ExpiresAt:     model.ExpiresAt,
+		CreatedAt:     model.CreatedAt,
+		UpdatedAt:     model.UpdatedAt,
+	}
+	if model.CapturedAmount != nil {
+		captured, err := money.Parse(*model.CapturedAmount, currency)
+		if err != nil {
+			return nil, fmt.Errorf("invalid captured amount %q on hold %s: %v", *model.CapturedAmount, model.ID, err)
+		}
+		hold.CapturedAmount = &captured
+	}
+	return hold, nil
+}
+
+func mapHoldModelsToDomain(rows []models.Hold) ([]*domain.Hold, error) {
+	holds := make([]*domain.Hold, 0, len(rows))
+	for i := range rows {
+		hold, err := mapHoldModelToDomain(&rows[i])
+		if err != nil {
+			return nil, err
+		}
+		holds = append(holds, hold)
+	}
+	return holds, nil
+}
+
+// Inserts an active hold and reserves its amount on the account
+func (r *HoldRepository) Create(hold *domain.Hold) error {
+	model := models.Hold{
+		ID:          hold.ID,
+		AccountID:   hold.AccountID,
+		Amount:      hold.Amount.String(),
+		Currency:    string(hold.Amount.Currency),
+		Status:      string(hold.Status),
+		Description: hold.Description,
+		ExpiresAt:   hold.ExpiresAt,
+		CreatedAt:   hold.CreatedAt,
+		UpdatedAt:   hold.UpdatedAt,
+	}
+
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&model).Error; err != nil {
+			return fmt.Errorf("failed to create hold: %v", err)
+		}
+		return adjustHeld(tx, hold.AccountID, hold.Amount.String(), hold.CreatedAt)
+	})
+}
+
+// retrieves a hold by its ID
+func (r *HoldRepository) GetByID(id string) (*domain.Hold, error) {
+	return r.get(r.db, id)
+}
+
+// Reads the hold with SELECT ... FOR UPDATE
+func (r *HoldRepository) LockForUpdate(id string) (*domain.Hold, error) {
+	return r.get(r.db.Clauses(clause.Locking{Strength: "UPDATE"}), id)
+}
+
+func (r *HoldRepository) get(query *gorm.DB, id string) (*domain.Hold, error) {
+	var model models.Hold
+	result := query.First(&model, "id = ?", id)
+	if result.Error != nil {
+		if result.Error == gorm.ErrRecordNotFound {
+			return nil, domain.ErrHoldNotFound
+		}
+		return nil, fmt.Errorf("failed to retrieve hold: %v", result.Error)
+	}
+	return mapHoldModelToDomain(&model)
+}
+
+// Lists an account's holds, newest first
+func (r *HoldRepository) ListByAccountID(accountID string) ([]*domain.Hold, error) {
+	var rows []models.Hold
+	result := r.db.Where("account_id = ?", accountID).Order("created_at DESC, id DESC").Find(&rows)
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to list holds: %v", result.Error)
+	}
+	return mapHoldModelsToDomain(rows)
+}
+
+// Lists active holds that expired before now, oldest expiry first
+func (r *HoldRepository) ListExpired(now time.Time, limit int) ([]*domain.Hold, error) {
+	var rows []models.Hold
+	result := r.db.Where("status = ? AND expires_at <= ?", string(domain.HoldStatusActive), now).
+		Order("expires_at").
+		Limit(limit).
+		Find(&rows)
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to list expired holds: %v", result.Error)
+	}
+	return mapHoldModelsToDomain(rows)
+}
+
+// Finishes an active hold and gives its whole amount back to the account's
+// available balance. A capture posts the captured part separately.
+func (r *HoldRepository) Release(id string, status domain.HoldStatus, captured *money.Money, transactionID string) error {
+	now := time.Now()
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var model models.Hold
+		if err := tx.First(&model, "id = ?", id).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return domain.ErrHoldNotFound
+			}
+			return fmt.Errorf("failed to retrieve hold: %v", err)
+		}
+
+		updates := map[string]interface{}{
+			"status":         string(status),
+			"transaction_id": transactionID,
+			"updated_at":     now,
+		}
+		if captured != nil {
+			updates["captured_amount"] = captured.String()
+		}
+		result := tx.Model(&models.Hold{}).
+			Where("id = ? AND status = ?", id, string(domain.HoldStatusActive)).
+			Updates(updates)
+		if result.Error != nil {
+			return fmt.Errorf("failed to release hold: %v", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return domain.ErrHoldNotActive
+		}
+
+		hold, err := mapHoldModelToDomain(&model)
+		if err != nil {
+			return err
+		}
+		return adjustHeld(tx, hold.AccountID, hold.Amount.Neg().String(), now)
+	})
+}
+
+// Moves the account's held balance by delta, a signed decimal
+func adjustHeld(tx *gorm.DB, accountID, delta string, now time.Time) error {
+	result := tx.Model(&models.Account{}).
+		Where("id = ?", accountID).
+		Updates(map[string]interface{}{
+			"held":       gorm.Expr("held + CAST(? AS numeric)", delta),
+			"updated_at": now,
+		})
+	if result.Error != nil {
+		return fmt.Errorf("failed to update held balance: %v", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return domain.ErrAccountNotFound
+	}
+	return nil
+}
@@ -0,0 +1,96 @@
+package postgres
+
+import (
+	"fmt"
+	"time"
+
+	"banking-ledger/internal/domain"
+	"banking-ledger/internal/repository/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type IdempotencyRepository struct {
+	db *gorm.DB
+}
+
+func NewIdempotencyRepository(db *gorm.DB) *IdempotencyRepository {
+	return &IdempotencyRepository{db: db}
+}
+
+// Inserts the reservation unless the key exists; the primary key makes two
+// concurrent requests with the same key race safely
+func (r *IdempotencyRepository) Reserve(record *domain.IdempotencyRecord) (*domain.IdempotencyRecord, error) {
+	model := models.IdempotencyKey{
+		Key:         record.Key,
+		Fingerprint: record.Fingerprint,
+		CreatedAt:   record.CreatedAt,
+	}
+	result := r.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&model)
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to reserve idempotency key: %v", result.Error)
+	}
+	if result.RowsAffected == 1 {
+		return nil, nil
+	}
+
+	var existing models.IdempotencyKey
+	if err := r.db.First(&existing, "key = ?", record.Key).Error; err != nil {
+		return nil, fmt.Errorf("failed to retrieve idempotency key: %v", err)
+	}
+	return &domain.IdempotencyRecord{
+		Key:          existing.Key,
+		Fingerprint:  existing.Fingerprint,
+		StatusCode:   existing.StatusCode,
+		ResponseBody: existing.ResponseBody,
+		CreatedAt:    existing.CreatedAt,
+		CompletedAt:  existing.CompletedAt,
+	}, nil
+}
+
+// Stores the response that replays of the key will receive
+func (r *IdempotencyRepository) Complete(key string, statusCode int, body []byte) error {
+	now := time.Now()
+	result := r.db.Model(&models.IdempotencyKey{}).
+		Where("key = ?", key).
+		Updates(map[string]interface{}{
+			"status_code":   statusCode,
+			"response_body": body,
+			"completed_at":  now,
+		})
+	if result.Error != nil {
+		return fmt.Errorf("failed to store idempotent response: %v", result.Error)
+	}
+	return nil
+}
+
+// Deletes a reservation that never completed
+func (r *IdempotencyRepository) Release(key string) error {
+	result := r.db.Where("key = ? AND completed_at IS NULL", key).Delete(&models.IdempotencyKey{})
+	if result.Error != nil {
+		return fmt.Errorf("failed to release idempotency key: %v", result.Error)
+	}
+	return nil
+}
+
+// Claims an unfinished reservation for a new request. Matching on the old
+// created_at means only one of several retries racing for it wins.
+func (r *IdempotencyRepository) TakeOver(record *domain.IdempotencyRecord, claimedAt time.Time) (bool, error) {
+	result := r.db.Model(&models.IdempotencyKey{}).
+		Where("key = ? AND created_at = ? AND completed_at IS NULL", record.Key, claimedAt).
+		Update("created_at", record.CreatedAt)
+	if result.Error != nil {
+		return false, fmt.Errorf("failed to take over idempotency key: %v", result.Error)
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// Removes keys created before the cutoff
+func (r *IdempotencyRepository) DeleteOlderThan(cutoff time.Time) error {
+	result := r.db.Where("created_at < ?", cutoff).Delete(&models.IdempotencyKey{})
+	if result.Error != nil {
+		return fmt.Errorf("failed to delete expired idempotency keys: %v", result.Error)
+	}
+	return nil
+}
@@ -0,0 +1,76 @@
+package postgres
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"banking-ledger/internal/domain"
+	"banking-ledger/internal/repository/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type InboxRepository struct {
+	db *gorm.DB
+}
+
+func NewInboxRepository(db *gorm.DB) *InboxRepository {
+	return &InboxRepository{db: db}
+}
+
+// Inserts the claim, relying on the primary key to detect an earlier one
+func (r *InboxRepository) Claim(transactionID string) (*domain.InboxRecord, error) {
+	result := r.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&models.ProcessedTransaction{
+		TransactionID: transactionID,
+		Outcome:       string(domain.InboxOutcomeApplied),
+		ProcessedAt:   time.Now(),
+	})
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to claim transaction: %v", result.Error)
+	}
+	if result.RowsAffected == 1 {
+		return nil, nil
+	}
+	return r.Get(transactionID)
+}
+
+// Records how the claimed transaction was decided
+func (r *InboxRepository) SetOutcome(transactionID string, outcome domain.InboxOutcome, failure *domain.TransactionFailure) error {
+	updates := map[string]interface{}{
+		"outcome":      string(outcome),
+		"failure_code": "",
+		"reason":       "",
+	}
+	if failure != nil {
+		updates["failure_code"] = string(failure.Code)
+		updates["reason"] = failure.Message
+	}
+	result := r.db.Model(&models.ProcessedTransaction{}).
+		Where("transaction_id = ?", transactionID).
+		Updates(updates)
+	if result.Error != nil {
+		return fmt.Errorf("failed to record transaction outcome: %v", result.Error)
+	}
+	return nil
+}
+
+// Returns the inbox record for a transaction, or nil if it was never processed
+func (r *InboxRepository) Get(transactionID string) (*domain.InboxRecord, error) {
+	var model models.ProcessedTransaction
+	err := r.db.First(&model, "transaction_id = ?", transactionID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve processed transaction: %v", err)
+	}
+	return &domain.InboxRecord{
+		TransactionID: model.TransactionID,
+		Outcome:       domain.InboxOutcome(model.Outcome),
+		FailureCode:   domain.FailureCode(model.FailureCode),
+		Reason:        model.Reason,
+		ProcessedAt:   model.ProcessedAt,
+	}, nil
+}
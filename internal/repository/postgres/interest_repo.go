@@ -0,0 +1,256 @@
+package postgres
+
+import (
+	"fmt"
+	"time"
+
+	"banking-ledger/internal/domain"
+	"banking-ledger/internal/money"
+	"banking-ledger/internal/repository/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// InterestRepository stores interest plans, daily accruals and the monthly
+// postings that pay them out
+type InterestRepository struct {
+	db *gorm.DB
+}
+
+func NewInterestRepository(db *gorm.DB) *InterestRepository {
+	return &InterestRepository{db: db}
+}
+
+func mapInterestPlanDomainToModel(plan *domain.InterestPlan) *models.InterestPlan {
+	return &models.InterestPlan{
+		ID:         plan.ID,
+		Name:       plan.Name,
+		AnnualRate: plan.AnnualRate,
+		Method:     string(plan.Method),
+		DayCount:   string(plan.DayCount),
+		Enabled:    plan.Enabled,
+		CreatedAt:  plan.CreatedAt,
+		UpdatedAt:  plan.UpdatedAt,
+	}
+}
+
+func mapInterestPlanModelToDomain(model *models.InterestPlan) *domain.InterestPlan {
+	return &domain.InterestPlan{
+		ID:         model.ID,
+		Name:       model.Name,
+		AnnualRate: model.AnnualRate,
+		Method:     domain.InterestMethod(model.Method),
+		DayCount:   domain.DayCount(model.DayCount),
+		Enabled:    model.Enabled,
+		CreatedAt:  model.CreatedAt,
+		UpdatedAt:  model.UpdatedAt,
+	}
+}
+
+func mapInterestAccrualModelToDomain(model *models.InterestAccrual) *domain.InterestAccrual {
+	return &domain.InterestAccrual{
+		ID:        model.ID,
+		AccountID: model.AccountID,
+		PlanID:    model.PlanID,
+		Date:      domain.Day(model.Date),
+		Basis:     model.Basis,
+		Rate:      model.Rate,
+		Amount:    model.Amount,
+		Currency:  money.Currency(model.Currency),
+		PostingID: model.PostingID,
+		CreatedAt: model.CreatedAt,
+	}
+}
+
+// Inserts a new plan
+func (r *InterestRepository) CreatePlan(plan *domain.InterestPlan) error {
+	if err := r.db.Create(mapInterestPlanDomainToModel(plan)).Error; err != nil {
+		return fmt.Errorf("failed to create interest plan: %v", err)
+	}
+	return nil
+}
+
+// retrieves a plan by ID
+func (r *InterestRepository) GetPlan(id string) (*domain.InterestPlan, error) {
+	var model models.InterestPlan
+	result := r.db.First(&model, "id = ?", id)
+	if result.Error != nil {
+		if result.Error == gorm.ErrRecordNotFound {
+			return nil, domain.ErrInterestPlanNotFound
+		}
+		return nil, fmt.Errorf("failed to retrieve interest plan: %v", result.Error)
+	}
+	return mapInterestPlanModelToDomain(&model), nil
+}
+
+// Overwrites every field of an existing plan
+func (r *InterestRepository) UpdatePlan(plan *domain.InterestPlan) error {
+	result := r.db.Model(&models.InterestPlan{}).
+		Where("id = ?", plan.ID).
+		Select("*").Omit("id", "created_at").
+		Updates(mapInterestPlanDomainToModel(plan))
+	if result.Error != nil {
+		return fmt.Errorf("failed to update interest plan: %v", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return domain.ErrInterestPlanNotFound
+	}
+	return nil
+}
+
+// Lists every plan, oldest first
+func (r *InterestRepository) ListPlans() ([]*domain.InterestPlan, error) {
+	var rows []models.InterestPlan
+	if err := r.db.Order("created_at, id").Find(&rows).Error; err != nil {
+		return nil, fmt.Errorf("failed to list interest plans: %v", err)
+	}
+	plans := make([]*domain.InterestPlan, 0, len(rows))
+	for i := range rows {
+		plans = append(plans, mapInterestPlanModelToDomain(&rows[i]))
+	}
+	return plans, nil
+}
+
+// Puts the account on a plan; an empty planID takes it off its plan
+func (r *InterestRepository) SetAccountPlan(accountID, planID string) error {
+	var value interface{}
+	if planID != "" {
+		value = planID
+	}
+	result := r.db.Model(&models.Account{}).
+		Where("id = ?", accountID).
+		Updates(map[string]interface{}{
+			"interest_plan_id": value,
+			"updated_at":       time.Now(),
+		})
+	if result.Error != nil {
+		return fmt.Errorf("failed to update interest plan: %v", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return domain.ErrAccountNotFound
+	}
+	return nil
+}
+
+// Lists every account on a plan, in ID order
+func (r *InterestRepository) ListPlanAccounts() ([]*domain.Account, error) {
+	var rows []models.Account
+	if err := r.db.Where("interest_plan_id IS NOT NULL").Order("id").Find(&rows).Error; err != nil {
+		return nil, fmt.Errorf("failed to list accounts on interest plans: %v", err)
+	}
+	accounts := make([]*domain.Account, 0, len(rows))
+	for i := range rows {
+		account, err := mapModelToDomain(&rows[i])
+		if err != nil {
+			return nil, err
+		}
+		accounts = append(accounts, account)
+	}
+	return accounts, nil
+}
+
+// Inserts the accrual, relying on the unique (account, date) index to skip
+// a day that was already accrued
+func (r *InterestRepository) AddAccrual(accrual *domain.InterestAccrual) (bool, error) {
+	model := models.InterestAccrual{
+		ID:        accrual.ID,
+		AccountID: accrual.AccountID,
+		PlanID:    accrual.PlanID,
+		Date:      accrual.Date,
+		Basis:     accrual.Basis,
+		Rate:      accrual.Rate,
+		Amount:    accrual.Amount,
+		Currency:  string(accrual.Currency),
+		CreatedAt: accrual.CreatedAt,
+	}
+	result := r.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&model)
+	if result.Error != nil {
+		return false, fmt.Errorf("failed to record interest accrual: %v", result.Error)
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// Lists the account's accruals in [from, to), oldest first
+func (r *InterestRepository) ListAccruals(accountID string, from, to time.Time) ([]*domain.InterestAccrual, error) {
+	var rows []models.InterestAccrual
+	err := r.db.
+		Where("account_id = ? AND date >= ? AND date < ?", accountID, from, to).
+		Order("date").
+		Find(&rows).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list interest accruals: %v", err)
+	}
+	accruals := make([]*domain.InterestAccrual, 0, len(rows))
+	for i := range rows {
+		accruals = append(accruals, mapInterestAccrualModelToDomain(&rows[i]))
+	}
+	return accruals, nil
+}
+
+// Lists the accounts with unposted accruals in [from, to)
+func (r *InterestRepository) ListAccruingAccounts(from, to time.Time) ([]string, error) {
+	var ids []string
+	err := r.db.Model(&models.InterestAccrual{}).
+		Distinct("account_id").
+		Where("posting_id = '' AND date >= ? AND date < ?", from, to).
+		Order("account_id").
+		Pluck("account_id", &ids).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list accruing accounts: %v", err)
+	}
+	return ids, nil
+}
+
+// retrieves the account's posting for the period, or nil if it has none
+func (r *InterestRepository) GetPosting(accountID, period string) (*domain.InterestPosting, error) {
+	var model models.InterestPosting
+	result := r.db.First(&model, "account_id = ? AND period = ?", accountID, period)
+	if result.Error != nil {
+		if result.Error == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to retrieve interest posting: %v", result.Error)
+	}
+
+	amount, err := money.Parse(model.Amount, money.Currency(model.Currency))
+	if err != nil {
+		return nil, fmt.Errorf("invalid amount %q on interest posting %s: %v", model.Amount, model.ID, err)
+	}
+	return &domain.InterestPosting{
+		ID:        model.ID,
+		AccountID: model.AccountID,
+		Period:    model.Period,
+		Amount:    amount,
+		CreatedAt: model.CreatedAt,
+	}, nil
+}
+
+// Stores the posting and stamps its ID on the accruals it pays out, in one
+// transaction. The unique (account, period) index stops two runs posting the
+// same month twice.
+func (r *InterestRepository) CreatePosting(posting *domain.InterestPosting, accrualIDs []string) error {
+	model := models.InterestPosting{
+		ID:        posting.ID,
+		AccountID: posting.AccountID,
+		Period:    posting.Period,
+		Amount:    posting.Amount.String(),
+		Currency:  string(posting.Amount.Currency),
+		CreatedAt: posting.CreatedAt,
+	}
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&model).Error; err != nil {
+			return fmt.Errorf("failed to record interest posting: %v", err)
+		}
+		if len(accrualIDs) == 0 {
+			return nil
+		}
+		err := tx.Model(&models.InterestAccrual{}).
+			Where("id IN ? AND posting_id = ''", accrualIDs).
+			Update("posting_id", posting.ID).Error
+		if err != nil {
+			return fmt.Errorf("failed to mark interest accruals posted: %v", err)
+		}
+		return nil
+	})
+}
@@ -0,0 +1,400 @@
+package postgres
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"banking-ledger/internal/domain"
+	"banking-ledger/internal/money"
+	"banking-ledger/internal/repository/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type JournalRepository struct {
+	db *gorm.DB
+}
+
+func NewJournalRepository(db *gorm.DB) *JournalRepository {
+	return &JournalRepository{db: db}
+}
+
+// Gives the entry and its postings IDs if they have none, stamps them with
+// now and returns the row to insert
+func mapJournalEntryToModel(entry *domain.JournalEntry, now time.Time) models.JournalEntry {
+	if entry.ID == "" {
+		entry.ID = uuid.New().String()
+	}
+	entry.CreatedAt = now
+
+	model := models.JournalEntry{
+		ID:          entry.ID,
+		Description: entry.Description,
+		CreatedAt:   now,
+	}
+	if entry.TransactionID != "" {
+		model.TransactionID = &entry.TransactionID
+	}
+	for i := range entry.Postings {
+		p := &entry.Postings[i]
+		if p.ID == "" {
+			p.ID = uuid.New().String()
+		}
+		p.EntryID = entry.ID
+		p.CreatedAt = now
+		model.Postings = append(model.Postings, models.Posting{
+			ID:        p.ID,
+			EntryID:   entry.ID,
+			AccountID: p.AccountID,
+			Direction: string(p.Direction),
+			Amount:    p.Amount.String(),
+			Currency:  string(p.Amount.Currency),
+			CreatedAt: now,
+		})
+	}
+	return model
+}
+
+// Inserts the entry with its postings and moves the cached balance of every
+// customer account involved. Wrap in a UnitOfWork when combining with other writes.
+func (r *JournalRepository) Post(entry *domain.JournalEntry) error {
+	if err := entry.Validate(); err != nil {
+		return err
+	}
+	changes, err := entry.BalanceChanges()
+	if err != nil {
+		return err
+	}
+
+	// Accounts are updated in ID order so two entries cannot deadlock
+	accountIDs := make([]string, 0, len(changes))
+	for accountID := range changes {
+		accountIDs = append(accountIDs, accountID)
+	}
+	sort.Strings(accountIDs)
+
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		type update struct {
+			Balance string
+			Version int64
+		}
+		updated := make(map[string]update, len(accountIDs))
+		for _, accountID := range accountIDs {
+			var rows []update
+			err := tx.Raw(`UPDATE accounts SET balance = balance + ?, version = version + 1, updated_at = now() WHERE id = ?
+				RETURNING balance, version`, changes[accountID].String(), accountID).
+				Scan(&rows).Error
+			if err != nil {
+				return fmt.Errorf("failed to update account balance: %v", err)
+			}
+			if len(rows) == 0 {
+				return domain.ErrAccountNotFound
+			}
+			updated[accountID] = rows[0]
+		}
+
+		// Taken while every account row is locked, so any later entry to one
+		// of them gets a later time. Postings, history and events all carry
+		// it, and every point-in-time read agrees on what came first.
+		var postedAt time.Time
+		if err := tx.Raw("SELECT clock_timestamp()").Scan(&postedAt).Error; err != nil {
+			return fmt.Errorf("failed to read the clock: %v", err)
+		}
+		model := mapJournalEntryToModel(entry, postedAt)
+		if err := tx.Create(&model).Error; err != nil {
+			return fmt.Errorf("failed to post journal entry: %v", err)
+		}
+
+		for _, accountID := range accountIDs {
+			delta := changes[accountID]
+			history := models.BalanceHistory{
+				AccountID:     accountID,
+				EntryID:       entry.ID,
+				TransactionID: model.TransactionID,
+				Change:        delta.String(),
+				Balance:       updated[accountID].Balance,
+				Currency:      string(delta.Currency),
+				CreatedAt:     postedAt,
+			}
+			if err := tx.Create(&history).Error; err != nil {
+				return fmt.Errorf("failed to record balance history: %v", err)
+			}
+
+			event := domain.NewBalanceEvent(accountID, entry.ID, delta, postedAt)
+			if err := appendAccountEvent(tx, event, updated[accountID].Version, string(delta.Currency)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Sums credits minus debits over every posting to the account
+func (r *JournalRepository) ComputeBalance(accountID string, currency money.Currency) (money.Money, error) {
+	var balance string
+	err := r.db.Model(&models.Posting{}).
+		Select("COALESCE(SUM(CASE WHEN direction = ? THEN amount ELSE -amount END), 0)", string(domain.Credit)).
+		Where("account_id = ? AND currency = ?", accountID, string(currency)).
+		Scan(&balance).Error
+	if err != nil {
+		return money.Money{}, fmt.Errorf("failed to compute balance: %v", err)
+	}
+	return money.Parse(balance, currency)
+}
+
+// Sums credits minus debits over the postings made before asOf
+func (r *JournalRepository) ComputeBalanceAsOf(accountID string, currency money.Currency, asOf time.Time) (money.Money, error) {
+	var balance string
+	err := r.db.Model(&models.Posting{}).
+		Select("COALESCE(SUM(CASE WHEN direction = ? THEN amount ELSE -amount END), 0)", string(domain.Credit)).
+		Where("account_id = ? AND currency = ? AND created_at < ?", accountID, string(currency), asOf).
+		Scan(&balance).Error
+	if err != nil {
+		return money.Money{}, fmt.Errorf("failed to compute balance: %v", err)
+	}
+	return money.Parse(balance, currency)
+}
+
+// Sums credits minus debits over the postings of entries with no transaction
+func (r *JournalRepository) OpeningBalance(accountID string, currency money.Currency) (money.Money, error) {
+	var balance string
+	err := r.db.Model(&models.Posting{}).
+		Select("COALESCE(SUM(CASE WHEN postings.direction = ? THEN postings.amount ELSE -postings.amount END), 0)", string(domain.Credit)).
+		Joins("JOIN journal_entries ON journal_entries.id = postings.entry_id").
+		Where("postings.account_id = ? AND postings.currency = ? AND journal_entries.transaction_id IS NULL", accountID, string(currency)).
+		Scan(&balance).Error
+	if err != nil {
+		return money.Money{}, fmt.Errorf("failed to compute opening balance: %v", err)
+	}
+	return money.Parse(balance, currency)
+}
+
+// Lists the account's postings in [from, to) with their entries' details,
+// in the order they were posted
+func (r *JournalRepository) ListAccountPostings(accountID string, from, to time.Time) ([]domain.AccountPosting, error) {
+	var rows []struct {
+		models.Posting
+		TransactionID    *string
+		EntryDescription string
+	}
+	err := r.db.Model(&models.Posting{}).
+		Select("postings.*, journal_entries.transaction_id, journal_entries.description AS entry_description").
+		Joins("JOIN journal_entries ON journal_entries.id = postings.entry_id").
+		Where("postings.account_id = ? AND postings.created_at >= ? AND postings.created_at < ?", accountID, from, to).
+		Order("postings.created_at, postings.id").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list postings: %v", err)
+	}
+
+	postings := make([]domain.AccountPosting, 0, len(rows))
+	for _, row := range rows {
+		amount, err := money.Parse(row.Amount, money.Currency(row.Currency))
+		if err != nil {
+			return nil, fmt.Errorf("invalid amount %q on posting %s: %v", row.Amount, row.ID, err)
+		}
+		posting := domain.AccountPosting{
+			Posting: domain.Posting{
+				ID:        row.ID,
+				EntryID:   row.EntryID,
+				AccountID: row.AccountID,
+				Direction: domain.PostingDirection(row.Direction),
+				Amount:    amount,
+				CreatedAt: row.CreatedAt,
+			},
+			EntryDescription: row.EntryDescription,
+		}
+		if row.TransactionID != nil {
+			posting.TransactionID = *row.TransactionID
+		}
+		postings = append(postings, posting)
+	}
+	return postings, nil
+}
+
+func mapBalanceHistoryModelToDomain(model *models.BalanceHistory) (*domain.BalanceHistoryEntry, error) {
+	currency := money.Currency(model.Currency)
+	change, err := money.Parse(model.Change, currency)
+	if err != nil {
+		return nil, fmt.Errorf("invalid change %q in balance history %d: %v", model.Change, model.Sequence, err)
+	}
+	balance, err := money.Parse(model.Balance, currency)
+	if err != nil {
+		return nil, fmt.Errorf("invalid balance %q in balance history %d: %v", model.Balance, model.Sequence, err)
+	}
+	entry := &domain.BalanceHistoryEntry{
+		Sequence:  model.Sequence,
+		AccountID: model.AccountID,
+		EntryID:   model.EntryID,
+		Change:    change,
+		Balance:   balance,
+		CreatedAt: model.CreatedAt,
+	}
+	if model.TransactionID != nil {
+		entry.TransactionID = *model.TransactionID
+	}
+	return entry, nil
+}
+
+// Finds the account's newest balance history row at or before asOf. The
+// (account_id, created_at) index keeps this to a single index probe.
+func (r *JournalRepository) BalanceHistoryAt(accountID string, asOf time.Time) (*domain.BalanceHistoryEntry, error) {
+	var rows []models.BalanceHistory
+	err := r.db.
+		Where("account_id = ? AND created_at <= ?", accountID, asOf).
+		Order("created_at DESC, sequence DESC").
+		Limit(1).
+		Find(&rows).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to read balance history: %v", err)
+	}
+	if len(rows) == 0 {
+		return nil, nil
+	}
+	return mapBalanceHistoryModelToDomain(&rows[0])
+}
+
+// Lists the account's balance history in [from, to) by sequence, which is
+// the order the entries took the account's row lock, with each entry's
+// description
+func (r *JournalRepository) ListStatementEntries(accountID string, from, to time.Time) ([]domain.StatementEntry, error) {
+	var rows []struct {
+		models.BalanceHistory
+		EntryDescription string
+	}
+	err := r.db.Model(&models.BalanceHistory{}).
+		Select("balance_history.*, journal_entries.description AS entry_description").
+		Joins("JOIN journal_entries ON journal_entries.id = balance_history.entry_id").
+		Where("balance_history.account_id = ? AND balance_history.created_at >= ? AND balance_history.created_at < ?", accountID, from, to).
+		Order("balance_history.sequence").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list balance history: %v", err)
+	}
+
+	entries := make([]domain.StatementEntry, 0, len(rows))
+	for i := range rows {
+		entry, err := mapBalanceHistoryModelToDomain(&rows[i].BalanceHistory)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, domain.StatementEntry{BalanceHistoryEntry: *entry, EntryDescription: rows[i].EntryDescription})
+	}
+	return entries, nil
+}
+
+// Position of the last row on a balance history page
+type balanceHistoryCursor struct {
+	CreatedAt time.Time `json:"t"`
+	Sequence  int64     `json:"q"`
+}
+
+// Lists the account's balance history newest first, a page at a time
+func (r *JournalRepository) ListBalanceHistory(accountID string, filter domain.BalanceHistoryFilter) (*domain.BalanceHistoryPage, error) {
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = defaultPageSize
+	}
+	if limit > maxPageSize {
+		limit = maxPageSize
+	}
+
+	query := r.db.Where("account_id = ?", accountID)
+	if filter.From != nil {
+		query = query.Where("created_at >= ?", *filter.From)
+	}
+	if filter.To != nil {
+		query = query.Where("created_at <= ?", *filter.To)
+	}
+	if filter.Cursor != "" {
+		data, err := base64.RawURLEncoding.DecodeString(filter.Cursor)
+		if err != nil {
+			return nil, domain.ErrInvalidCursor
+		}
+		var cursor balanceHistoryCursor
+		if err := json.Unmarshal(data, &cursor); err != nil || cursor.Sequence == 0 {
+			return nil, domain.ErrInvalidCursor
+		}
+		query = query.Where("(created_at, sequence) < (?, ?)", cursor.CreatedAt, cursor.Sequence)
+	}
+
+	// One extra row tells us whether another page exists
+	var rows []models.BalanceHistory
+	err := query.Order("created_at DESC, sequence DESC").Limit(limit + 1).Find(&rows).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list balance history: %v", err)
+	}
+
+	page := &domain.BalanceHistoryPage{Entries: make([]*domain.BalanceHistoryEntry, 0, len(rows))}
+	for i := range rows {
+		if i == limit {
+			last := rows[limit-1]
+			data, _ := json.Marshal(balanceHistoryCursor{CreatedAt: last.CreatedAt, Sequence: last.Sequence})
+			page.NextCursor = base64.RawURLEncoding.EncodeToString(data)
+			break
+		}
+		entry, err := mapBalanceHistoryModelToDomain(&rows[i])
+		if err != nil {
+			return nil, err
+		}
+		page.Entries = append(page.Entries, entry)
+	}
+	return page, nil
+}
+
+// Rewrites every cached account balance from the journal
+func (r *JournalRepository) RecomputeBalances() error {
+	err := r.db.Exec(`
+		UPDATE accounts SET balance = COALESCE((
+			SELECT SUM(CASE WHEN p.direction = ? THEN p.amount ELSE -p.amount END)
+			FROM postings p WHERE p.account_id = accounts.id AND p.currency = accounts.currency
+		), 0), updated_at = ?`, string(domain.Credit), time.Now()).Error
+	if err != nil {
+		return fmt.Errorf("failed to recompute balances: %v", err)
+	}
+	return nil
+}
+
+// Totals debits and credits per currency across the whole journal
+func (r *JournalRepository) TrialBalance() ([]domain.TrialBalanceLine, error) {
+	var rows []struct {
+		Currency string
+		Debits   string
+		Credits  string
+	}
+	err := r.db.Model(&models.Posting{}).
+		Select(`currency,
+			COALESCE(SUM(CASE WHEN direction = ? THEN amount END), 0) AS debits,
+			COALESCE(SUM(CASE WHEN direction = ? THEN amount END), 0) AS credits`,
+			string(domain.Debit), string(domain.Credit)).
+		Group("currency").
+		Order("currency").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to compute trial balance: %v", err)
+	}
+
+	lines := make([]domain.TrialBalanceLine, 0, len(rows))
+	for _, row := range rows {
+		currency := money.Currency(row.Currency)
+		debits, err := money.Parse(row.Debits, currency)
+		if err != nil {
+			return nil, err
+		}
+		credits, err := money.Parse(row.Credits, currency)
+		if err != nil {
+			return nil, err
+		}
+		lines = append(lines, domain.TrialBalanceLine{
+			Currency: currency,
+			Debits:   debits,
+			Credits:  credits,
+			Balanced: debits == credits,
+		})
+	}
+	return lines, nil
+}
@@ -0,0 +1,39 @@
+package postgres
+
+import (
+	"testing"
+
+	"banking-ledger/internal/domain"
+	"banking-ledger/internal/money"
+	"banking-ledger/internal/repository/models"
+)
+
+// Statements read postings and ?as_of reads balance history, so both must
+// be stamped with the same time
+func TestPostStampsPostingsAndHistoryAlike(t *testing.T) {
+	db := openTestDB(t)
+	id := createLegacyAccount(t, db, "0")
+
+	entry := &domain.JournalEntry{
+		Description: "test deposit",
+		Postings: []domain.Posting{
+			{AccountID: domain.SystemAccountOpeningBalance, Direction: domain.Debit, Amount: money.MustParse("10.00", money.USD)},
+			{AccountID: id, Direction: domain.Credit, Amount: money.MustParse("10.00", money.USD)},
+		},
+	}
+	if err := NewJournalRepository(db).Post(entry); err != nil {
+		t.Fatal(err)
+	}
+
+	var posting models.Posting
+	if err := db.Where("account_id = ? AND entry_id = ?", id, entry.ID).First(&posting).Error; err != nil {
+		t.Fatal(err)
+	}
+	var history models.BalanceHistory
+	if err := db.Where("account_id = ? AND entry_id = ?", id, entry.ID).First(&history).Error; err != nil {
+		t.Fatal(err)
+	}
+	if !posting.CreatedAt.Equal(history.CreatedAt) {
+		t.Errorf("posting stamped %s, history %s", posting.CreatedAt, history.CreatedAt)
+	}
+}
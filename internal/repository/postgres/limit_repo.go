@@ -0,0 +1,190 @@
+package postgres
+
+import (
+	"fmt"
+	"time"
+
+	"banking-ledger/internal/constants"
+	"banking-ledger/internal/domain"
+	"banking-ledger/internal/money"
+	"banking-ledger/internal/repository/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// LimitRepository stores limit rules and the usage they are checked against
+type LimitRepository struct {
+	db *gorm.DB
+}
+
+func NewLimitRepository(db *gorm.DB) *LimitRepository {
+	return &LimitRepository{db: db}
+}
+
+func mapLimitRuleModelToDomain(model *models.LimitRule) (*domain.LimitRule, error) {
+	rule := &domain.LimitRule{
+		ID:              model.ID,
+		Name:            model.Name,
+		AccountID:       model.AccountID,
+		AccountType:     domain.AccountType(model.AccountType),
+		TransactionType: constants.TransactionType(model.TransactionType),
+		Kind:            domain.LimitKind(model.Kind),
+		MaxCount:        model.MaxCount,
+		WindowSeconds:   model.WindowSeconds,
+		Enabled:         model.Enabled,
+		CreatedAt:       model.CreatedAt,
+		UpdatedAt:       model.UpdatedAt,
+	}
+	if model.MaxAmount != nil {
+		maxAmount, err := money.Parse(*model.MaxAmount, money.Currency(model.Currency))
+		if err != nil {
+			return nil, fmt.Errorf("invalid max amount %q on limit rule %s: %v", *model.MaxAmount, model.ID, err)
+		}
+		rule.MaxAmount = &maxAmount
+	}
+	return rule, nil
+}
+
+func mapLimitRuleModelsToDomain(rows []models.LimitRule) ([]*domain.LimitRule, error) {
+	rules := make([]*domain.LimitRule, 0, len(rows))
+	for i := range rows {
+		rule, err := mapLimitRuleModelToDomain(&rows[i])
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+func mapLimitRuleDomainToModel(rule *domain.LimitRule) models.LimitRule {
+	model := models.LimitRule{
+		ID:              rule.ID,
+		Name:            rule.Name,
+		AccountID:       rule.AccountID,
+		AccountType:     string(rule.AccountType),
+		TransactionType: string(rule.TransactionType),
+		Kind:            string(rule.Kind),
+		MaxCount:        rule.MaxCount,
+		WindowSeconds:   rule.WindowSeconds,
+		Enabled:         rule.Enabled,
+		CreatedAt:       rule.CreatedAt,
+		UpdatedAt:       rule.UpdatedAt,
+	}
+	if rule.MaxAmount != nil {
+		maxAmount := rule.MaxAmount.String()
+		model.MaxAmount = &maxAmount
+		model.Currency = string(rule.MaxAmount.Currency)
+	}
+	return model
+}
+
+// Inserts a new rule
+func (r *LimitRepository) CreateRule(rule *domain.LimitRule) error {
+	model := mapLimitRuleDomainToModel(rule)
+	if err := r.db.Create(&model).Error; err != nil {
+		return fmt.Errorf("failed to create limit rule: %v", err)
+	}
+	return nil
+}
+
+// retrieves a rule by ID
+func (r *LimitRepository) GetRule(id string) (*domain.LimitRule, error) {
+	var model models.LimitRule
+	result := r.db.First(&model, "id = ?", id)
+	if result.Error != nil {
+		if result.Error == gorm.ErrRecordNotFound {
+			return nil, domain.ErrLimitRuleNotFound
+		}
+		return nil, fmt.Errorf("failed to retrieve limit rule: %v", result.Error)
+	}
+	return mapLimitRuleModelToDomain(&model)
+}
+
+// Overwrites every field of an existing rule
+func (r *LimitRepository) UpdateRule(rule *domain.LimitRule) error {
+	model := mapLimitRuleDomainToModel(rule)
+	result := r.db.Model(&models.LimitRule{}).
+		Where("id = ?", rule.ID).
+		Select("*").Omit("id", "created_at").
+		Updates(&model)
+	if result.Error != nil {
+		return fmt.Errorf("failed to update limit rule: %v", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return domain.ErrLimitRuleNotFound
+	}
+	return nil
+}
+
+// Deletes a rule; usage already recorded is kept
+func (r *LimitRepository) DeleteRule(id string) error {
+	result := r.db.Delete(&models.LimitRule{}, "id = ?", id)
+	if result.Error != nil {
+		return fmt.Errorf("failed to delete limit rule: %v", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return domain.ErrLimitRuleNotFound
+	}
+	return nil
+}
+
+// Lists every rule, oldest first
+func (r *LimitRepository) ListRules() ([]*domain.LimitRule, error) {
+	var rows []models.LimitRule
+	if err := r.db.Order("created_at, id").Find(&rows).Error; err != nil {
+		return nil, fmt.Errorf("failed to list limit rules: %v", err)
+	}
+	return mapLimitRuleModelsToDomain(rows)
+}
+
+// Lists the enabled rules for the transaction type that name the account or its type
+func (r *LimitRepository) ListApplicableRules(account *domain.Account, transactionType constants.TransactionType) ([]*domain.LimitRule, error) {
+	var rows []models.LimitRule
+	err := r.db.
+		Where("enabled AND transaction_type = ?", string(transactionType)).
+		Where("account_id = ? OR account_type = ?", account.ID, string(account.Type)).
+		Order("created_at, id").
+		Find(&rows).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list limit rules: %v", err)
+	}
+	return mapLimitRuleModelsToDomain(rows)
+}
+
+// Counts the account's applied transactions of one type since the given time
+func (r *LimitRepository) Usage(accountID string, transactionType constants.TransactionType, currency money.Currency, since time.Time) (domain.LimitUsage, error) {
+	var row struct {
+		Count int
+		Total string
+	}
+	err := r.db.Model(&models.LimitUsage{}).
+		Select("COUNT(*) AS count, COALESCE(SUM(CASE WHEN currency = ? THEN amount END), 0) AS total", string(currency)).
+		Where("account_id = ? AND transaction_type = ? AND created_at > ?", accountID, string(transactionType), since).
+		Scan(&row).Error
+	if err != nil {
+		return domain.LimitUsage{}, fmt.Errorf("failed to compute limit usage: %v", err)
+	}
+	total, err := money.Parse(row.Total, currency)
+	if err != nil {
+		return domain.LimitUsage{}, err
+	}
+	return domain.LimitUsage{Count: row.Count, Total: total}, nil
+}
+
+// Records an applied transaction; recording the same one twice is a no-op
+func (r *LimitRepository) RecordUsage(transaction *domain.Transaction) error {
+	model := models.LimitUsage{
+		TransactionID:   transaction.ID,
+		AccountID:       transaction.AccountID,
+		TransactionType: string(transaction.Type),
+		Amount:          transaction.Amount.String(),
+		Currency:        string(transaction.Amount.Currency),
+		CreatedAt:       time.Now(),
+	}
+	if err := r.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&model).Error; err != nil {
+		return fmt.Errorf("failed to record limit usage: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,71 @@
+package postgres
+
+import (
+	"fmt"
+	"time"
+
+	"banking-ledger/internal/domain"
+	"banking-ledger/internal/money"
+	"banking-ledger/internal/repository/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type ReversalRepository struct {
+	db *gorm.DB
+}
+
+func NewReversalRepository(db *gorm.DB) *ReversalRepository {
+	return &ReversalRepository{db: db}
+}
+
+// Adds amount to the original's reversed total under a row lock, so two
+// reversals of the same transaction are checked one after the other
+func (r *ReversalRepository) Add(originalID string, amount, limit money.Money) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		row := models.ReversalTotal{
+			OriginalID: originalID,
+			Amount:     money.Zero(amount.Currency).String(),
+			Currency:   string(amount.Currency),
+			UpdatedAt:  time.Now(),
+		}
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&row).Error; err != nil {
+			return fmt.Errorf("failed to create reversal total: %v", err)
+		}
+
+		var current models.ReversalTotal
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			First(&current, "original_id = ?", originalID).Error
+		if err != nil {
+			return fmt.Errorf("failed to lock reversal total: %v", err)
+		}
+
+		reversed, err := money.Parse(current.Amount, money.Currency(current.Currency))
+		if err != nil {
+			return fmt.Errorf("invalid reversal total %q for %s: %v", current.Amount, originalID, err)
+		}
+		total, err := reversed.Add(amount)
+		if err != nil {
+			return err
+		}
+		exceeds, err := limit.LessThan(total)
+		if err != nil {
+			return err
+		}
+		if exceeds {
+			return fmt.Errorf("%w: %s of %s already reversed", domain.ErrReversalExceedsOriginal, reversed, limit)
+		}
+
+		result := tx.Model(&models.ReversalTotal{}).
+			Where("original_id = ?", originalID).
+			Updates(map[string]interface{}{
+				"amount":     total.String(),
+				"updated_at": time.Now(),
+			})
+		if result.Error != nil {
+			return fmt.Errorf("failed to update reversal total: %v", result.Error)
+		}
+		return nil
+	})
+}
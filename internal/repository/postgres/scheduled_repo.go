@@ -0,0 +1,277 @@
+package postgres
+
+import (
+	"fmt"
+	"time"
+
+	"banking-ledger/internal/constants"
+	"banking-ledger/internal/domain"
+	"banking-ledger/internal/money"
+	"banking-ledger/internal/repository/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ScheduledTransactionRepository stores standing orders and the record of
+// each run
+type ScheduledTransactionRepository struct {
+	db *gorm.DB
+}
+
+func NewScheduledTransactionRepository(db *gorm.DB) *ScheduledTransactionRepository {
+	return &ScheduledTransactionRepository{db: db}
+}
+
+func mapScheduledModelToDomain(model *models.ScheduledTransaction) (*domain.ScheduledTransaction, error) {
+	amount, err := money.Parse(model.Amount, money.Currency(model.Currency))
+	if err != nil {
+		return nil, fmt.Errorf("invalid amount %q on scheduled transaction %s: %v", model.Amount, model.ID, err)
+	}
+	return &domain.ScheduledTransaction{
+		ID:          model.ID,
+		AccountID:   model.AccountID,
+		ToAccountID: model.ToAccountID,
+		Type:        constants.TransactionType(model.Type),
+		Amount:      amount,
+		Description: model.Description,
+		RunAt:       model.RunAt,
+		Cron:        model.Cron,
+		Status:      domain.ScheduleStatus(model.Status),
+		NextRunAt:   model.NextRunAt,
+		LastRunAt:   model.LastRunAt,
+		CreatedAt:   model.CreatedAt,
+		UpdatedAt:   model.UpdatedAt,
+	}, nil
+}
+
+func mapScheduledModelsToDomain(rows []models.ScheduledTransaction) ([]*domain.ScheduledTransaction, error) {
+	schedules := make([]*domain.ScheduledTransaction, 0, len(rows))
+	for i := range rows {
+		schedule, err := mapScheduledModelToDomain(&rows[i])
+		if err != nil {
+			return nil, err
+		}
+		schedules = append(schedules, schedule)
+	}
+	return schedules, nil
+}
+
+func mapExecutionModelToDomain(model *models.ScheduledExecution) *domain.ScheduledExecution {
+	return &domain.ScheduledExecution{
+		ID:            model.ID,
+		ScheduleID:    model.ScheduleID,
+		DueAt:         model.DueAt,
+		TransactionID: model.TransactionID,
+		Outcome:       domain.ExecutionOutcome(model.Outcome),
+		Error:         model.Error,
+		CreatedAt:     model.CreatedAt,
+		UpdatedAt:     model.UpdatedAt,
+	}
+}
+
+func mapExecutionModelsToDomain(rows []models.ScheduledExecution) []*domain.ScheduledExecution {
+	executions := make([]*domain.ScheduledExecution, 0, len(rows))
+	for i := range rows {
+		executions = append(executions, mapExecutionModelToDomain(&rows[i]))
+	}
+	return executions
+}
+
+// Inserts a new schedule
+func (r *ScheduledTransactionRepository) Create(schedule *domain.ScheduledTransaction) error {
+	model := models.ScheduledTransaction{
+		ID:          schedule.ID,
+		AccountID:   schedule.AccountID,
+		ToAccountID: schedule.ToAccountID,
+		Type:        string(schedule.Type),
+		Amount:      schedule.Amount.String(),
+		Currency:    string(schedule.Amount.Currency),
+		Description: schedule.Description,
+		RunAt:       schedule.RunAt,
+		Cron:        schedule.Cron,
+		Status:      string(schedule.Status),
+		NextRunAt:   schedule.NextRunAt,
+		CreatedAt:   schedule.CreatedAt,
+		UpdatedAt:   schedule.UpdatedAt,
+	}
+	if err := r.db.Create(&model).Error; err != nil {
+		return fmt.Errorf("failed to create scheduled transaction: %v", err)
+	}
+	return nil
+}
+
+// retrieves a schedule by ID
+func (r *ScheduledTransactionRepository) GetByID(id string) (*domain.ScheduledTransaction, error) {
+	var model models.ScheduledTransaction
+	result := r.db.First(&model, "id = ?", id)
+	if result.Error != nil {
+		if result.Error == gorm.ErrRecordNotFound {
+			return nil, domain.ErrScheduledTransactionNotFound
+		}
+		return nil, fmt.Errorf("failed to retrieve scheduled transaction: %v", result.Error)
+	}
+	return mapScheduledModelToDomain(&model)
+}
+
+// Lists the schedules debiting or crediting the account, oldest first
+func (r *ScheduledTransactionRepository) ListByAccount(accountID string) ([]*domain.ScheduledTransaction, error) {
+	var rows []models.ScheduledTransaction
+	err := r.db.
+		Where("account_id = ? OR to_account_id = ?", accountID, accountID).
+		Order("created_at, id").
+		Find(&rows).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list scheduled transactions: %v", err)
+	}
+	return mapScheduledModelsToDomain(rows)
+}
+
+// Locks the schedule as ClaimExecution does, so a pause or cancel either
+// lands before a run is claimed or sees the schedule it moved on
+func (r *ScheduledTransactionRepository) UpdateState(id string, change func(*domain.ScheduledTransaction) error) (*domain.ScheduledTransaction, error) {
+	var schedule *domain.ScheduledTransaction
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var current models.ScheduledTransaction
+		result := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&current, "id = ?", id)
+		if result.Error != nil {
+			if result.Error == gorm.ErrRecordNotFound {
+				return domain.ErrScheduledTransactionNotFound
+			}
+			return fmt.Errorf("failed to lock scheduled transaction: %v", result.Error)
+		}
+
+		var err error
+		if schedule, err = mapScheduledModelToDomain(&current); err != nil {
+			return err
+		}
+		if err := change(schedule); err != nil {
+			return err
+		}
+
+		err = tx.Model(&models.ScheduledTransaction{}).
+			Where("id = ?", id).
+			Updates(map[string]interface{}{
+				"status":      string(schedule.Status),
+				"next_run_at": schedule.NextRunAt,
+				"updated_at":  schedule.UpdatedAt,
+			}).Error
+		if err != nil {
+			return fmt.Errorf("failed to update scheduled transaction: %v", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return schedule, nil
+}
+
+// Lists active schedules due at or before now, most overdue first
+func (r *ScheduledTransactionRepository) ListDue(now time.Time, limit int) ([]*domain.ScheduledTransaction, error) {
+	var rows []models.ScheduledTransaction
+	err := r.db.
+		Where("status = ? AND next_run_at <= ?", string(domain.ScheduleStatusActive), now).
+		Order("next_run_at, id").
+		Limit(limit).
+		Find(&rows).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list due scheduled transactions: %v", err)
+	}
+	return mapScheduledModelsToDomain(rows)
+}
+
+// Locks the schedule, checks it is still due at the claimed time, then
+// records the run and moves the schedule on in one transaction. The unique
+// (schedule, due_at) index backs up the check.
+func (r *ScheduledTransactionRepository) ClaimExecution(schedule *domain.ScheduledTransaction, execution *domain.ScheduledExecution, next *time.Time) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var current models.ScheduledTransaction
+		result := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&current, "id = ?", schedule.ID)
+		if result.Error != nil {
+			if result.Error == gorm.ErrRecordNotFound {
+				return domain.ErrScheduledTransactionNotFound
+			}
+			return fmt.Errorf("failed to lock scheduled transaction: %v", result.Error)
+		}
+		if current.Status != string(domain.ScheduleStatusActive) ||
+			current.NextRunAt == nil || !current.NextRunAt.Equal(execution.DueAt) {
+			return domain.ErrExecutionClaimed
+		}
+
+		model := models.ScheduledExecution{
+			ID:            execution.ID,
+			ScheduleID:    execution.ScheduleID,
+			DueAt:         execution.DueAt,
+			TransactionID: execution.TransactionID,
+			Outcome:       string(execution.Outcome),
+			CreatedAt:     execution.CreatedAt,
+			UpdatedAt:     execution.UpdatedAt,
+		}
+		if err := tx.Create(&model).Error; err != nil {
+			return fmt.Errorf("failed to record scheduled execution: %v", err)
+		}
+
+		status := domain.ScheduleStatusActive
+		if next == nil {
+			status = domain.ScheduleStatusCompleted
+		}
+		err := tx.Model(&models.ScheduledTransaction{}).
+			Where("id = ?", schedule.ID).
+			Updates(map[string]interface{}{
+				"status":      string(status),
+				"next_run_at": next,
+				"last_run_at": execution.DueAt,
+				"updated_at":  execution.CreatedAt,
+			}).Error
+		if err != nil {
+			return fmt.Errorf("failed to advance scheduled transaction: %v", err)
+		}
+		schedule.Status = status
+		schedule.NextRunAt = next
+		schedule.LastRunAt = &execution.DueAt
+		return nil
+	})
+}
+
+// Stores the outcome of a claimed run
+func (r *ScheduledTransactionRepository) FinishExecution(execution *domain.ScheduledExecution) error {
+	result := r.db.Model(&models.ScheduledExecution{}).
+		Where("id = ?", execution.ID).
+		Updates(map[string]interface{}{
+			"outcome":    string(execution.Outcome),
+			"error":      execution.Error,
+			"updated_at": execution.UpdatedAt,
+		})
+	if result.Error != nil {
+		return fmt.Errorf("failed to update scheduled execution: %v", result.Error)
+	}
+	return nil
+}
+
+// Lists claimed runs still waiting for their transaction, oldest first
+func (r *ScheduledTransactionRepository) ListPendingExecutions(limit int) ([]*domain.ScheduledExecution, error) {
+	var rows []models.ScheduledExecution
+	err := r.db.
+		Where("outcome = ?", string(domain.ExecutionOutcomePending)).
+		Order("due_at, id").
+		Limit(limit).
+		Find(&rows).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pending scheduled executions: %v", err)
+	}
+	return mapExecutionModelsToDomain(rows), nil
+}
+
+// Lists a schedule's runs, newest first
+func (r *ScheduledTransactionRepository) ListExecutions(scheduleID string) ([]*domain.ScheduledExecution, error) {
+	var rows []models.ScheduledExecution
+	err := r.db.
+		Where("schedule_id = ?", scheduleID).
+		Order("due_at DESC, id").
+		Find(&rows).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list scheduled executions: %v", err)
+	}
+	return mapExecutionModelsToDomain(rows), nil
+}
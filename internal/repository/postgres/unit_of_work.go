@@ -0,0 +1,31 @@
+package postgres
+
+import (
+	"banking-ledger/internal/domain"
+
+	"gorm.io/gorm"
+)
+
+type UnitOfWork struct {
+	db *gorm.DB
+}
+
+func NewUnitOfWork(db *gorm.DB) *UnitOfWork {
+	return &UnitOfWork{db: db}
+}
+
+// Runs fn in a database transaction, rolling back if it returns an error
+func (u *UnitOfWork) Do(fn func(tx domain.TxRepositories) error) error {
+	return u.db.Transaction(func(tx *gorm.DB) error {
+		return fn(domain.TxRepositories{
+			Accounts:  NewAccountRepository(tx),
+			Journal:   NewJournalRepository(tx),
+			Inbox:     NewInboxRepository(tx),
+			Holds:     NewHoldRepository(tx),
+			Reversals: NewReversalRepository(tx),
+			Limits:    NewLimitRepository(tx),
+			Fees:      NewFeeRepository(tx),
+			Events:    NewAccountEventStore(tx),
+		})
+	})
+}
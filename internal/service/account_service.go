@@ -2,38 +2,82 @@ package service
 
 import (
 	"errors"
+	"fmt"
 	"time"
 
 	"banking-ledger/internal/domain"
+	"banking-ledger/internal/money"
 
 	"github.com/google/uuid"
 )
 
 type AccountService struct {
 	accountRepo domain.AccountRepository
+	journalRepo domain.JournalRepository
+	unitOfWork  domain.UnitOfWork
 }
 
-func NewAccountService(accountRepo domain.AccountRepository) *AccountService {
+func NewAccountService(
+	accountRepo domain.AccountRepository,
+	journalRepo domain.JournalRepository,
+	unitOfWork domain.UnitOfWork,
+) *AccountService {
 	return &AccountService{
 		accountRepo: accountRepo,
+		journalRepo: journalRepo,
+		unitOfWork:  unitOfWork,
 	}
 }
 
-// Creates a new account with initial balance
-func (s *AccountService) CreateAccount(name string, initialBalance float64) (*domain.Account, error) {
-	if initialBalance < 0 {
+// Creates a new account with initial balance. An empty type means checking;
+// overdraftLimit is in the account's currency and must suit the type.
+func (s *AccountService) CreateAccount(name string, accountType domain.AccountType, initialBalance, overdraftLimit money.Money) (*domain.Account, error) {
+	if initialBalance.IsNegative() {
 		return nil, errors.New("initial balance cannot be negative")
 	}
+	if !initialBalance.Currency.Valid() {
+		return nil, fmt.Errorf("unsupported currency: %q", initialBalance.Currency)
+	}
+	if accountType == "" {
+		accountType = domain.AccountTypeChecking
+	}
+	if !accountType.Valid() {
+		return nil, fmt.Errorf("unsupported account type: %q", accountType)
+	}
+	if overdraftLimit.Currency != initialBalance.Currency {
+		return nil, money.ErrCurrencyMismatch
+	}
+	if err := accountType.ValidateOverdraftLimit(overdraftLimit); err != nil {
+		return nil, err
+	}
 
 	now := time.Now()
 	account := &domain.Account{
-		ID:        uuid.New().String(),
-		Name:      name,
-		Balance:   initialBalance,
-		CreatedAt: now,
-		UpdatedAt: now,
+		ID:             uuid.New().String(),
+		Name:           name,
+		Type:           accountType,
+		Currency:       initialBalance.Currency,
+		Balance:        money.Zero(initialBalance.Currency),
+		Held:           money.Zero(initialBalance.Currency),
+		OverdraftLimit: overdraftLimit,
+		Status:         domain.AccountStatusActive,
+		CreatedAt:      now,
+		UpdatedAt:      now,
 	}
-	if err := s.accountRepo.Create(account); err != nil {
+
+	// The initial balance is posted like any other money movement so the
+	// cached balance always equals the sum of the account's postings
+	err := s.unitOfWork.Do(func(tx domain.TxRepositories) error {
+		if err := tx.Accounts.Create(account); err != nil {
+			return err
+		}
+		if initialBalance.IsZero() {
+			return nil
+		}
+		account.Balance = initialBalance
+		return tx.Journal.Post(domain.NewOpeningEntry(account))
+	})
+	if err != nil {
 		return nil, err
 	}
 	return account, nil
@@ -44,7 +88,169 @@ func (s *AccountService) GetAccount(id string) (*domain.Account, error) {
 	return s.accountRepo.GetByID(id)
 }
 
-// lists all accounts
-func (s *AccountService) ListAccounts() ([]*domain.Account, error) {
-	return s.accountRepo.List()
+// Moves an account to a new status, recording who did it and why. The account
+// row stays locked until the change commits, so a transaction being processed
+// at the same moment sees either the old status or the new one.
+func (s *AccountService) ChangeStatus(id string, status domain.AccountStatus, reason, actor string) (*domain.Account, error) {
+	if !status.Valid() {
+		return nil, fmt.Errorf("%w: unknown status %q", domain.ErrInvalidStatusTransition, status)
+	}
+	if reason == "" || actor == "" {
+		return nil, errors.New("reason and actor are required")
+	}
+
+	var account *domain.Account
+	err := s.unitOfWork.Do(func(tx domain.TxRepositories) error {
+		locked, err := tx.Accounts.LockForUpdate(id)
+		if err != nil {
+			return err
+		}
+		account = locked[id]
+
+		if !account.Status.CanTransitionTo(status) {
+			return fmt.Errorf("%w: %s to %s", domain.ErrInvalidStatusTransition, account.Status, status)
+		}
+		if status == domain.AccountStatusClosed && !account.Balance.IsZero() {
+			return domain.ErrAccountNotEmpty
+		}
+		// A capture of a hold left active would debit the closed account
+		if status == domain.AccountStatusClosed && !account.Held.IsZero() {
+			return domain.ErrAccountHasHolds
+		}
+
+		change := &domain.AccountStatusChange{
+			ID:         uuid.New().String(),
+			AccountID:  id,
+			FromStatus: account.Status,
+			ToStatus:   status,
+			Reason:     reason,
+			Actor:      actor,
+			CreatedAt:  time.Now(),
+		}
+		if err := tx.Accounts.UpdateStatus(change); err != nil {
+			return err
+		}
+		account.Status = status
+		account.UpdatedAt = change.CreatedAt
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return account, nil
+}
+
+// Sets how far below zero the account may go, recording who changed it and
+// why. Lowering the limit under an existing overdraft is allowed; it only
+// blocks further debits until the account is back within the limit.
+func (s *AccountService) ChangeOverdraftLimit(id string, limit money.Money, reason, actor string) (*domain.Account, error) {
+	if reason == "" || actor == "" {
+		return nil, errors.New("reason and actor are required")
+	}
+
+	var account *domain.Account
+	err := s.unitOfWork.Do(func(tx domain.TxRepositories) error {
+		locked, err := tx.Accounts.LockForUpdate(id)
+		if err != nil {
+			return err
+		}
+		account = locked[id]
+
+		if limit.Currency != account.Currency {
+			return money.ErrCurrencyMismatch
+		}
+		if err := account.Type.ValidateOverdraftLimit(limit); err != nil {
+			return err
+		}
+		if limit == account.OverdraftLimit {
+			return fmt.Errorf("%w: limit is already %s", domain.ErrInvalidOverdraftLimit, limit)
+		}
+
+		change := &domain.OverdraftLimitChange{
+			ID:        uuid.New().String(),
+			AccountID: id,
+			OldLimit:  account.OverdraftLimit,
+			NewLimit:  limit,
+			Reason:    reason,
+			Actor:     actor,
+			CreatedAt: time.Now(),
+		}
+		if err := tx.Accounts.UpdateOverdraftLimit(change); err != nil {
+			return err
+		}
+		account.OverdraftLimit = limit
+		account.UpdatedAt = change.CreatedAt
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return account, nil
+}
+
+// Lists an account's overdraft limit changes, oldest first
+func (s *AccountService) GetOverdraftLimitHistory(id string) ([]*domain.OverdraftLimitChange, error) {
+	if _, err := s.accountRepo.GetByID(id); err != nil {
+		return nil, err
+	}
+	return s.accountRepo.ListOverdraftLimitChanges(id)
+}
+
+// Lists an account's status changes, oldest first
+func (s *AccountService) GetStatusHistory(id string) ([]*domain.AccountStatusChange, error) {
+	if _, err := s.accountRepo.GetByID(id); err != nil {
+		return nil, err
+	}
+	return s.accountRepo.ListStatusChanges(id)
+}
+
+// lists one page of accounts matching the filter
+func (s *AccountService) ListAccounts(filter domain.AccountFilter) (*domain.AccountPage, error) {
+	return s.accountRepo.List(filter)
+}
+
+// Recomputes an account's balance from its postings
+func (s *AccountService) GetPostedBalance(id string) (money.Money, error) {
+	account, err := s.accountRepo.GetByID(id)
+	if err != nil {
+		return money.Money{}, err
+	}
+	return s.journalRepo.ComputeBalance(id, account.Balance.Currency)
+}
+
+// Reads the account's ledger balance at asOf from its balance history
+func (s *AccountService) GetBalanceAsOf(id string, asOf time.Time) (*domain.PointInTimeBalance, error) {
+	account, err := s.accountRepo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	last, err := s.journalRepo.BalanceHistoryAt(id, asOf)
+	if err != nil {
+		return nil, err
+	}
+
+	balance := &domain.PointInTimeBalance{
+		AccountID: id,
+		AsOf:      asOf,
+		Balance:   money.Zero(account.Currency),
+	}
+	if last != nil {
+		balance.Balance = last.Balance
+		balance.EntryID = last.EntryID
+		balance.ChangedAt = &last.CreatedAt
+	}
+	return balance, nil
+}
+
+// Lists one page of the account's balance history, newest first
+func (s *AccountService) ListBalanceHistory(id string, filter domain.BalanceHistoryFilter) (*domain.BalanceHistoryPage, error) {
+	if _, err := s.accountRepo.GetByID(id); err != nil {
+		return nil, err
+	}
+	return s.journalRepo.ListBalanceHistory(id, filter)
+}
+
+// Checks that debits equal credits across the whole journal
+func (s *AccountService) TrialBalance() ([]domain.TrialBalanceLine, error) {
+	return s.journalRepo.TrialBalance()
 }
@@ -0,0 +1,79 @@
+package service
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+
+	"banking-ledger/internal/domain"
+)
+
+type FeeService struct {
+	feeRepo     domain.FeeRepository
+	accountRepo domain.AccountRepository
+}
+
+func NewFeeService(feeRepo domain.FeeRepository, accountRepo domain.AccountRepository) *FeeService {
+	return &FeeService{
+		feeRepo:     feeRepo,
+		accountRepo: accountRepo,
+	}
+}
+
+// Stores a new schedule; it prices transactions applied from now on
+func (s *FeeService) CreateSchedule(schedule *domain.FeeSchedule) (*domain.FeeSchedule, error) {
+	if err := schedule.Validate(); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	schedule.ID = uuid.New().String()
+	schedule.CreatedAt = now
+	schedule.UpdatedAt = now
+	if err := s.feeRepo.CreateSchedule(schedule); err != nil {
+		return nil, err
+	}
+	return schedule, nil
+}
+
+// Replaces every setting of an existing schedule
+func (s *FeeService) UpdateSchedule(id string, schedule *domain.FeeSchedule) (*domain.FeeSchedule, error) {
+	existing, err := s.feeRepo.GetSchedule(id)
+	if err != nil {
+		return nil, err
+	}
+	if err := schedule.Validate(); err != nil {
+		return nil, err
+	}
+
+	schedule.ID = existing.ID
+	schedule.CreatedAt = existing.CreatedAt
+	schedule.UpdatedAt = time.Now()
+	if err := s.feeRepo.UpdateSchedule(schedule); err != nil {
+		return nil, err
+	}
+	return schedule, nil
+}
+
+// Retrieves a schedule by ID
+func (s *FeeService) GetSchedule(id string) (*domain.FeeSchedule, error) {
+	return s.feeRepo.GetSchedule(id)
+}
+
+// Lists every schedule, enabled or not
+func (s *FeeService) ListSchedules() ([]*domain.FeeSchedule, error) {
+	return s.feeRepo.ListSchedules()
+}
+
+// Deletes a schedule
+func (s *FeeService) DeleteSchedule(id string) error {
+	return s.feeRepo.DeleteSchedule(id)
+}
+
+// Waives every fee on the account, or charges them again
+func (s *FeeService) SetFeesWaived(accountID string, waived bool) (*domain.Account, error) {
+	if err := s.accountRepo.SetFeesWaived(accountID, waived); err != nil {
+		return nil, err
+	}
+	return s.accountRepo.GetByID(accountID)
+}
@@ -0,0 +1,77 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"banking-ledger/internal/domain"
+	"banking-ledger/internal/money"
+)
+
+type FXService struct {
+	fxRateRepo domain.FXRateRepository
+}
+
+func NewFXService(fxRateRepo domain.FXRateRepository) *FXService {
+	return &FXService{
+		fxRateRepo: fxRateRepo,
+	}
+}
+
+// Stores the rate for converting from into to, effective now
+func (s *FXService) SetRate(from, to, rate string) (*domain.FXRate, error) {
+	fromCurrency, err := money.ParseCurrency(from)
+	if err != nil {
+		return nil, err
+	}
+	toCurrency, err := money.ParseCurrency(to)
+	if err != nil {
+		return nil, err
+	}
+
+	fxRate := &domain.FXRate{
+		From: fromCurrency,
+		To:   toCurrency,
+		Rate: rate,
+		AsOf: time.Now(),
+	}
+	if err := fxRate.Validate(); err != nil {
+		return nil, err
+	}
+	if err := s.fxRateRepo.SetRate(fxRate); err != nil {
+		return nil, err
+	}
+	return fxRate, nil
+}
+
+// Lists the stored rates
+func (s *FXService) ListRates() ([]*domain.FXRate, error) {
+	return s.fxRateRepo.ListRates()
+}
+
+// Loads rates from a JSON file holding a list of {"from", "to", "rate"}
+// objects, replacing any stored rate for the same pair
+func (s *FXService) LoadRatesFile(path string) (int, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read fx rates file: %v", err)
+	}
+
+	var entries []struct {
+		From string `json:"from"`
+		To   string `json:"to"`
+		Rate string `json:"rate"`
+	}
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return 0, fmt.Errorf("failed to parse fx rates file: %v", err)
+	}
+
+	for i, entry := range entries {
+		if _, err := s.SetRate(entry.From, entry.To, entry.Rate); err != nil {
+			return i, fmt.Errorf("fx rates file entry %d: %v", i+1, err)
+		}
+	}
+	return len(entries), nil
+}
@@ -0,0 +1,166 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+
+	"banking-ledger/internal/domain"
+	"banking-ledger/internal/money"
+)
+
+const (
+	// How long a hold reserves funds when the request does not say
+	DefaultHoldTTL = 7 * 24 * time.Hour
+	// Expired holds released per sweep
+	holdExpiryBatchSize = 100
+)
+
+type HoldService struct {
+	accountRepo domain.AccountRepository
+	holdRepo    domain.HoldRepository
+	unitOfWork  domain.UnitOfWork
+}
+
+func NewHoldService(
+	accountRepo domain.AccountRepository,
+	holdRepo domain.HoldRepository,
+	unitOfWork domain.UnitOfWork,
+) *HoldService {
+	return &HoldService{
+		accountRepo: accountRepo,
+		holdRepo:    holdRepo,
+		unitOfWork:  unitOfWork,
+	}
+}
+
+// Reserves amount on the account until expiresAt. Holds are decided straight
+// away rather than queued: the account row is locked while the available
+// balance is checked and the hold recorded.
+func (s *HoldService) CreateHold(accountID string, amount money.Money, description string, expiresAt time.Time) (*domain.Hold, error) {
+	if !amount.IsPositive() {
+		return nil, fmt.Errorf("%w: hold amount must be positive", domain.ErrInvalidTransaction)
+	}
+	now := time.Now()
+	if !expiresAt.After(now) {
+		return nil, fmt.Errorf("%w: hold expiry must be in the future", domain.ErrInvalidTransaction)
+	}
+
+	hold := &domain.Hold{
+		ID:          uuid.New().String(),
+		AccountID:   accountID,
+		Amount:      amount,
+		Status:      domain.HoldStatusActive,
+		Description: description,
+		ExpiresAt:   expiresAt,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+
+	err := s.unitOfWork.Do(func(tx domain.TxRepositories) error {
+		accounts, err := tx.Accounts.LockForUpdate(accountID)
+		if err != nil {
+			return err
+		}
+		account := accounts[accountID]
+		if amount.Currency != account.Currency {
+			return money.ErrCurrencyMismatch
+		}
+		if err := account.CheckDebit(); err != nil {
+			return err
+		}
+		if err := account.CheckFunds(amount); err != nil {
+			return err
+		}
+		return tx.Holds.Create(hold)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return hold, nil
+}
+
+// Retrieves a hold by ID
+func (s *HoldService) GetHold(id string) (*domain.Hold, error) {
+	return s.holdRepo.GetByID(id)
+}
+
+// Lists an account's holds, newest first
+func (s *HoldService) ListHolds(accountID string) ([]*domain.Hold, error) {
+	if _, err := s.accountRepo.GetByID(accountID); err != nil {
+		return nil, err
+	}
+	return s.holdRepo.ListByAccountID(accountID)
+}
+
+// Cancels an active hold and releases its funds
+func (s *HoldService) VoidHold(id string) (*domain.Hold, error) {
+	return s.release(id, domain.HoldStatusVoided)
+}
+
+func (s *HoldService) release(id string, status domain.HoldStatus) (*domain.Hold, error) {
+	err := s.unitOfWork.Do(func(tx domain.TxRepositories) error {
+		hold, err := tx.Holds.LockForUpdate(id)
+		if err != nil {
+			return err
+		}
+		if hold.Status != domain.HoldStatusActive {
+			return domain.ErrHoldNotActive
+		}
+		return tx.Holds.Release(id, status, nil, "")
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.holdRepo.GetByID(id)
+}
+
+// Releases every active hold whose expiry has passed
+func (s *HoldService) ExpireHolds() (int, error) {
+	expired := 0
+	for {
+		holds, err := s.holdRepo.ListExpired(time.Now(), holdExpiryBatchSize)
+		if err != nil {
+			return expired, err
+		}
+		for _, hold := range holds {
+			// A capture or void may have finished the hold since it was listed
+			_, err := s.release(hold.ID, domain.HoldStatusExpired)
+			if errors.Is(err, domain.ErrHoldNotActive) {
+				continue
+			}
+			if err != nil {
+				return expired, err
+			}
+			expired++
+		}
+		if len(holds) < holdExpiryBatchSize {
+			return expired, nil
+		}
+	}
+}
+
+// Runs ExpireHolds every interval until ctx is cancelled
+func (s *HoldService) RunExpiry(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			expired, err := s.ExpireHolds()
+			if err != nil {
+				log.Printf("Failed to expire holds: %v", err)
+			}
+			if expired > 0 {
+				log.Printf("Released %d expired holds", expired)
+			}
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package service
+
+import (
+	"time"
+
+	"banking-ledger/internal/domain"
+)
+
+const (
+	// How long a stored response is replayed for a repeated key
+	idempotencyKeyTTL = 24 * time.Hour
+	// How long a request may hold its key before a retry can take it over,
+	// in case it crashed without completing or releasing it. Well above the
+	// time any request takes, so a slow one is not run twice.
+	idempotencyClaimLease = 2 * time.Minute
+)
+
+type IdempotencyService struct {
+	idempotencyRepo domain.IdempotencyRepository
+}
+
+func NewIdempotencyService(idempotencyRepo domain.IdempotencyRepository) *IdempotencyService {
+	return &IdempotencyService{
+		idempotencyRepo: idempotencyRepo,
+	}
+}
+
+// Claims the key for a request. Returns the stored record when the request
+// is a replay of a completed one, or nil when the caller should go ahead. A
+// claim older than idempotencyClaimLease that never completed is taken over.
+func (s *IdempotencyService) Begin(key, fingerprint string) (*domain.IdempotencyRecord, error) {
+	record := &domain.IdempotencyRecord{
+		Key:         key,
+		Fingerprint: fingerprint,
+		CreatedAt:   time.Now(),
+	}
+
+	existing, err := s.idempotencyRepo.Reserve(record)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil && existing.CreatedAt.Before(time.Now().Add(-idempotencyKeyTTL)) {
+		if err := s.idempotencyRepo.DeleteOlderThan(time.Now().Add(-idempotencyKeyTTL)); err != nil {
+			return nil, err
+		}
+		if existing, err = s.idempotencyRepo.Reserve(record); err != nil {
+			return nil, err
+		}
+	}
+	if existing == nil {
+		return nil, nil
+	}
+
+	if existing.Fingerprint != fingerprint {
+		return nil, domain.ErrIdempotencyKeyReused
+	}
+	if existing.CompletedAt == nil {
+		if existing.CreatedAt.After(time.Now().Add(-idempotencyClaimLease)) {
+			return nil, domain.ErrIdempotencyKeyInFlight
+		}
+		tookOver, err := s.idempotencyRepo.TakeOver(record, existing.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		if !tookOver {
+			return nil, domain.ErrIdempotencyKeyInFlight
+		}
+		return nil, nil
+	}
+	return existing, nil
+}
+
+// Stores the response for future replays
+func (s *IdempotencyService) Complete(key string, statusCode int, body []byte) error {
+	return s.idempotencyRepo.Complete(key, statusCode, body)
+}
+
+// Frees the key after a request that should be retryable
+func (s *IdempotencyService) Release(key string) error {
+	return s.idempotencyRepo.Release(key)
+}
@@ -0,0 +1,336 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+
+	"banking-ledger/internal/constants"
+	"banking-ledger/internal/domain"
+)
+
+type InterestService struct {
+	interestRepo    domain.InterestRepository
+	accountRepo     domain.AccountRepository
+	journalRepo     domain.JournalRepository
+	transactionRepo domain.TransactionRepository
+}
+
+func NewInterestService(
+	interestRepo domain.InterestRepository,
+	accountRepo domain.AccountRepository,
+	journalRepo domain.JournalRepository,
+	transactionRepo domain.TransactionRepository,
+) *InterestService {
+	return &InterestService{
+		interestRepo:    interestRepo,
+		accountRepo:     accountRepo,
+		journalRepo:     journalRepo,
+		transactionRepo: transactionRepo,
+	}
+}
+
+// Stores a new plan; accounts earn on it once they are assigned to it
+func (s *InterestService) CreatePlan(plan *domain.InterestPlan) (*domain.InterestPlan, error) {
+	if err := plan.Validate(); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	plan.ID = uuid.New().String()
+	plan.CreatedAt = now
+	plan.UpdatedAt = now
+	if err := s.interestRepo.CreatePlan(plan); err != nil {
+		return nil, err
+	}
+	return plan, nil
+}
+
+// Replaces every setting of an existing plan; days already accrued keep the
+// rate they were accrued at
+func (s *InterestService) UpdatePlan(id string, plan *domain.InterestPlan) (*domain.InterestPlan, error) {
+	existing, err := s.interestRepo.GetPlan(id)
+	if err != nil {
+		return nil, err
+	}
+	if err := plan.Validate(); err != nil {
+		return nil, err
+	}
+
+	plan.ID = existing.ID
+	plan.CreatedAt = existing.CreatedAt
+	plan.UpdatedAt = time.Now()
+	if err := s.interestRepo.UpdatePlan(plan); err != nil {
+		return nil, err
+	}
+	return plan, nil
+}
+
+// Retrieves a plan by ID
+func (s *InterestService) GetPlan(id string) (*domain.InterestPlan, error) {
+	return s.interestRepo.GetPlan(id)
+}
+
+// Lists every plan, enabled or not
+func (s *InterestService) ListPlans() ([]*domain.InterestPlan, error) {
+	return s.interestRepo.ListPlans()
+}
+
+// Puts the account on a plan, or takes it off when planID is empty
+func (s *InterestService) AssignPlan(accountID, planID string) (*domain.Account, error) {
+	if planID != "" {
+		if _, err := s.interestRepo.GetPlan(planID); err != nil {
+			return nil, err
+		}
+	}
+	if err := s.interestRepo.SetAccountPlan(accountID, planID); err != nil {
+		return nil, err
+	}
+	return s.accountRepo.GetByID(accountID)
+}
+
+// Lists the account's accruals dated in [from, to)
+func (s *InterestService) ListAccruals(accountID string, from, to time.Time) ([]*domain.InterestAccrual, error) {
+	if _, err := s.accountRepo.GetByID(accountID); err != nil {
+		return nil, err
+	}
+	return s.interestRepo.ListAccruals(accountID, from, to)
+}
+
+// RunForDate accrues the day's interest and, on the last day of a month,
+// posts the month. Every step skips work already done, so a date can be run
+// any number of times.
+func (s *InterestService) RunForDate(date time.Time) error {
+	day := domain.Day(date)
+	accrued, err := s.AccrueDay(day)
+	if err != nil {
+		return err
+	}
+	log.Printf("Accrued interest on %d accounts for %s", accrued, day.Format("2006-01-02"))
+
+	if !domain.IsMonthEnd(day) {
+		return nil
+	}
+	posted, err := s.PostMonth(day)
+	if err != nil {
+		return err
+	}
+	log.Printf("Posted interest to %d accounts for %s", posted, domain.InterestPeriod(day))
+	return nil
+}
+
+// AccrueDay records the day's interest for every account on an enabled plan,
+// earned on its balance at the end of the day. It returns how many accruals
+// were stored; accounts already accrued for the day are skipped.
+func (s *InterestService) AccrueDay(date time.Time) (int, error) {
+	day := domain.Day(date)
+	accounts, err := s.interestRepo.ListPlanAccounts()
+	if err != nil {
+		return 0, err
+	}
+
+	plans := make(map[string]*domain.InterestPlan)
+	accrued := 0
+	for _, account := range accounts {
+		if !account.CreatedAt.Before(day.AddDate(0, 0, 1)) {
+			continue
+		}
+		plan, ok := plans[account.InterestPlanID]
+		if !ok {
+			if plan, err = s.interestRepo.GetPlan(account.InterestPlanID); err != nil {
+				return accrued, err
+			}
+			plans[plan.ID] = plan
+		}
+		if !plan.Enabled {
+			continue
+		}
+
+		stored, err := s.accrue(account, plan, day)
+		if err != nil {
+			return accrued, fmt.Errorf("failed to accrue interest for account %s: %v", account.ID, err)
+		}
+		if stored {
+			accrued++
+		}
+	}
+	return accrued, nil
+}
+
+func (s *InterestService) accrue(account *domain.Account, plan *domain.InterestPlan, day time.Time) (bool, error) {
+	balance, err := s.journalRepo.ComputeBalanceAsOf(account.ID, account.Currency, day.AddDate(0, 0, 1))
+	if err != nil {
+		return false, err
+	}
+
+	// Earlier months are posted by now, so only this month's accruals compound
+	var unposted []*domain.InterestAccrual
+	if plan.Method == domain.InterestMethodCompound {
+		monthStart := day.AddDate(0, 0, 1-day.Day())
+		accruals, err := s.interestRepo.ListAccruals(account.ID, monthStart, day)
+		if err != nil {
+			return false, err
+		}
+		for _, accrual := range accruals {
+			if accrual.PostingID == "" {
+				unposted = append(unposted, accrual)
+			}
+		}
+	}
+
+	basis, err := plan.AccrualBasis(balance, unposted)
+	if err != nil {
+		return false, err
+	}
+	interest, err := plan.DailyInterest(basis, day)
+	if err != nil {
+		return false, err
+	}
+	if interest.Sign() == 0 {
+		return false, nil
+	}
+
+	return s.interestRepo.AddAccrual(&domain.InterestAccrual{
+		ID:        uuid.New().String(),
+		AccountID: account.ID,
+		PlanID:    plan.ID,
+		Date:      day,
+		Basis:     basis.FloatString(domain.AccrualPlaces),
+		Rate:      plan.AnnualRate,
+		Amount:    interest.FloatString(domain.AccrualPlaces),
+		Currency:  account.Currency,
+		CreatedAt: time.Now(),
+	})
+}
+
+// PostMonth pays out the unposted accruals of the month date falls in, one
+// interest transaction per account, through the transaction queue. It
+// returns how many accounts were posted; accounts already posted for the
+// month are skipped.
+func (s *InterestService) PostMonth(date time.Time) (int, error) {
+	day := domain.Day(date)
+	from := day.AddDate(0, 0, 1-day.Day())
+	to := from.AddDate(0, 1, 0)
+
+	accountIDs, err := s.interestRepo.ListAccruingAccounts(from, to)
+	if err != nil {
+		return 0, err
+	}
+
+	posted := 0
+	for _, accountID := range accountIDs {
+		stored, err := s.post(accountID, domain.InterestPeriod(from), from, to)
+		if err != nil {
+			return posted, fmt.Errorf("failed to post interest for account %s: %v", accountID, err)
+		}
+		if stored {
+			posted++
+		}
+	}
+	return posted, nil
+}
+
+func (s *InterestService) post(accountID, period string, from, to time.Time) (bool, error) {
+	existing, err := s.interestRepo.GetPosting(accountID, period)
+	if err != nil {
+		return false, err
+	}
+	if existing != nil {
+		return false, nil
+	}
+
+	accruals, err := s.interestRepo.ListAccruals(accountID, from, to)
+	if err != nil {
+		return false, err
+	}
+	var unposted []*domain.InterestAccrual
+	accrualIDs := make([]string, 0, len(accruals))
+	for _, accrual := range accruals {
+		if accrual.PostingID == "" {
+			unposted = append(unposted, accrual)
+			accrualIDs = append(accrualIDs, accrual.ID)
+		}
+	}
+	if len(unposted) == 0 {
+		return false, nil
+	}
+
+	total, err := domain.SumAccruals(unposted)
+	if err != nil {
+		return false, err
+	}
+	amount, err := domain.RoundInterest(total, unposted[0].Currency)
+	if err != nil {
+		return false, err
+	}
+
+	now := time.Now()
+	posting := &domain.InterestPosting{
+		ID:        interestPostingID(accountID, period),
+		AccountID: accountID,
+		Period:    period,
+		Amount:    amount,
+		CreatedAt: now,
+	}
+
+	// The transaction is enqueued before the posting is stored so that a
+	// crash in between is repaired by the next run, which finds the
+	// transaction under the same ID instead of creating a second one
+	if amount.IsPositive() {
+		transaction, err := s.transactionRepo.GetByID(posting.ID)
+		switch {
+		case errors.Is(err, domain.ErrTransactionNotFound):
+			err = enqueueTransaction(s.transactionRepo, &domain.Transaction{
+				ID:          posting.ID,
+				AccountID:   accountID,
+				Type:        constants.TransactionTypeInterest,
+				Amount:      amount,
+				Status:      constants.TransactionStatusPending,
+				Description: fmt.Sprintf("Interest for %s", period),
+				CreatedAt:   now,
+				UpdatedAt:   now,
+			})
+			if err != nil {
+				return false, err
+			}
+		case err != nil:
+			return false, err
+		default:
+			posting.Amount = transaction.Amount
+		}
+	}
+
+	if err := s.interestRepo.CreatePosting(posting, accrualIDs); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// The same account and month always get the same transaction ID
+func interestPostingID(accountID, period string) string {
+	return uuid.NewSHA1(uuid.NameSpaceURL, []byte("interest:"+accountID+":"+period)).String()
+}
+
+// Run accrues, and at month end posts, the previous day on every tick until
+// ctx is cancelled. Days the scheduler was down for are caught up with
+// RunForDate.
+func (s *InterestService) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := s.RunForDate(time.Now().AddDate(0, 0, -1)); err != nil {
+			log.Printf("Failed to run interest: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
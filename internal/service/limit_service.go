@@ -0,0 +1,91 @@
+package service
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+
+	"banking-ledger/internal/domain"
+	"banking-ledger/internal/money"
+)
+
+type LimitService struct {
+	limitRepo   domain.LimitRepository
+	accountRepo domain.AccountRepository
+}
+
+func NewLimitService(limitRepo domain.LimitRepository, accountRepo domain.AccountRepository) *LimitService {
+	return &LimitService{
+		limitRepo:   limitRepo,
+		accountRepo: accountRepo,
+	}
+}
+
+// Stores a new rule; it applies to transactions requested from now on
+func (s *LimitService) CreateRule(rule *domain.LimitRule) (*domain.LimitRule, error) {
+	if err := s.validate(rule); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	rule.ID = uuid.New().String()
+	rule.CreatedAt = now
+	rule.UpdatedAt = now
+	if err := s.limitRepo.CreateRule(rule); err != nil {
+		return nil, err
+	}
+	return rule, nil
+}
+
+// Replaces every setting of an existing rule
+func (s *LimitService) UpdateRule(id string, rule *domain.LimitRule) (*domain.LimitRule, error) {
+	existing, err := s.limitRepo.GetRule(id)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.validate(rule); err != nil {
+		return nil, err
+	}
+
+	rule.ID = existing.ID
+	rule.CreatedAt = existing.CreatedAt
+	rule.UpdatedAt = time.Now()
+	if err := s.limitRepo.UpdateRule(rule); err != nil {
+		return nil, err
+	}
+	return rule, nil
+}
+
+// Retrieves a rule by ID
+func (s *LimitService) GetRule(id string) (*domain.LimitRule, error) {
+	return s.limitRepo.GetRule(id)
+}
+
+// Lists every rule, enabled or not
+func (s *LimitService) ListRules() ([]*domain.LimitRule, error) {
+	return s.limitRepo.ListRules()
+}
+
+// Deletes a rule
+func (s *LimitService) DeleteRule(id string) error {
+	return s.limitRepo.DeleteRule(id)
+}
+
+// An account rule must name an existing account, and its amount must be in
+// that account's currency
+func (s *LimitService) validate(rule *domain.LimitRule) error {
+	if err := rule.Validate(); err != nil {
+		return err
+	}
+	if rule.AccountID == "" {
+		return nil
+	}
+	account, err := s.accountRepo.GetByID(rule.AccountID)
+	if err != nil {
+		return err
+	}
+	if rule.MaxAmount != nil && rule.MaxAmount.Currency != account.Currency {
+		return money.ErrCurrencyMismatch
+	}
+	return nil
+}
@@ -0,0 +1,311 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"banking-ledger/internal/constants"
+	"banking-ledger/internal/domain"
+	"banking-ledger/internal/money"
+)
+
+// Transactions still pending this long after they were queued, with no
+// decision from the processor, are reported as stuck
+const DefaultStuckPendingAge = 15 * time.Minute
+
+// Recorded in the status history of transactions a repair finishes
+const reconcileInstance = "reconcile"
+
+// ReconciliationService cross-checks the balances and decisions held in
+// PostgreSQL against the MongoDB transaction log
+type ReconciliationService struct {
+	accountRepo     domain.AccountRepository
+	transactionRepo domain.TransactionRepository
+	inboxRepo       domain.InboxRepository
+	feeRepo         domain.FeeRepository
+	unitOfWork      domain.UnitOfWork
+}
+
+func NewReconciliationService(
+	accountRepo domain.AccountRepository,
+	transactionRepo domain.TransactionRepository,
+	inboxRepo domain.InboxRepository,
+	feeRepo domain.FeeRepository,
+	unitOfWork domain.UnitOfWork,
+) *ReconciliationService {
+	return &ReconciliationService{
+		accountRepo:     accountRepo,
+		transactionRepo: transactionRepo,
+		inboxRepo:       inboxRepo,
+		feeRepo:         feeRepo,
+		unitOfWork:      unitOfWork,
+	}
+}
+
+// Reconcile compares every account's cached and posted balances with the one
+// its opening amount and completed transactions imply, and every transaction's status
+// with the processor's decision. With repair set, statuses that disagree with
+// a recorded decision are corrected; balances are only ever reported.
+func (s *ReconciliationService) Reconcile(stuckAfter time.Duration, repair bool) (*domain.ReconciliationReport, error) {
+	report := &domain.ReconciliationReport{
+		StartedAt:     time.Now(),
+		Discrepancies: []domain.Discrepancy{},
+	}
+	stuckBefore := report.StartedAt.Add(-stuckAfter)
+
+	accountIDs, err := listAccountIDs(s.accountRepo)
+	if err != nil {
+		return nil, err
+	}
+
+	// A transfer is listed under both its accounts but checked once
+	checked := make(map[string]bool)
+	for _, accountID := range accountIDs {
+		transactions, err := s.transactionRepo.ListByAccountID(accountID)
+		if err != nil {
+			return nil, err
+		}
+
+		discrepancies, err := s.checkBalance(accountID, transactions, stuckBefore)
+		if err != nil {
+			return nil, err
+		}
+		report.Discrepancies = append(report.Discrepancies, discrepancies...)
+		report.AccountsChecked++
+
+		for _, transaction := range transactions {
+			if checked[transaction.ID] {
+				continue
+			}
+			checked[transaction.ID] = true
+
+			discrepancy, err := s.checkStatus(transaction, stuckBefore, repair)
+			if err != nil {
+				return nil, err
+			}
+			if discrepancy != nil {
+				report.Discrepancies = append(report.Discrepancies, *discrepancy)
+			}
+			report.TransactionsChecked++
+		}
+	}
+
+	report.FinishedAt = time.Now()
+	return report, nil
+}
+
+// Compares the balance the log implies with both the cached balance and the
+// sum of the account's postings, read together under the account lock. The
+// log side is the opening amount plus every completed transaction created
+// before cutoff; newer ones may still be in flight, so they are left out of
+// the ledger side too and checked on a later run.
+func (s *ReconciliationService) checkBalance(accountID string, transactions []*domain.Transaction, cutoff time.Time) ([]domain.Discrepancy, error) {
+	recent := make(map[string]bool)
+	var settled []*domain.Transaction
+	for _, transaction := range transactions {
+		if transaction.CreatedAt.Before(cutoff) {
+			settled = append(settled, transaction)
+		} else {
+			recent[transaction.ID] = true
+		}
+	}
+
+	var account *domain.Account
+	var postings []domain.AccountPosting
+	err := s.unitOfWork.Do(func(tx domain.TxRepositories) error {
+		locked, err := tx.Accounts.LockForUpdate(accountID)
+		if err != nil {
+			return err
+		}
+		account = locked[accountID]
+		// Every posting; the lock keeps new ones out until this commits
+		postings, err = tx.Journal.ListAccountPostings(accountID, time.Time{}, time.Now().Add(time.Hour))
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	opening := money.Zero(account.Currency)
+	posted := money.Zero(account.Currency)
+	cached := account.Balance
+	for _, p := range postings {
+		change := p.Amount
+		if p.Direction == domain.Debit {
+			change = change.Neg()
+		}
+		switch {
+		case recent[p.TransactionID]:
+			// Taken off the cached balance so it is as of the cutoff as well
+			if cached, err = cached.Sub(change); err != nil {
+				return nil, err
+			}
+			continue
+		case p.TransactionID == "":
+			if opening, err = opening.Add(change); err != nil {
+				return nil, err
+			}
+		}
+		if posted, err = posted.Add(change); err != nil {
+			return nil, err
+		}
+	}
+
+	byID := make(map[string]*domain.Transaction, len(transactions))
+	for _, transaction := range transactions {
+		byID[transaction.ID] = transaction
+	}
+	originals := make(map[string]*domain.Transaction)
+	for _, transaction := range transactions {
+		if transaction.ReversalOf == "" {
+			continue
+		}
+		if original, ok := byID[transaction.ReversalOf]; ok {
+			originals[original.ID] = original
+			continue
+		}
+		original, err := s.transactionRepo.GetByID(transaction.ReversalOf)
+		if err == nil {
+			originals[original.ID] = original
+		}
+	}
+
+	expected, err := domain.ExpectedBalance(accountID, opening, settled, originals)
+	if err != nil {
+		return []domain.Discrepancy{{
+			Kind:      domain.DiscrepancyBalanceMismatch,
+			AccountID: accountID,
+			Actual:    cached.String(),
+			Detail:    fmt.Sprintf("cannot replay transactions: %v", err),
+		}}, nil
+	}
+
+	var discrepancies []domain.Discrepancy
+	for _, side := range []struct {
+		name   string
+		actual money.Money
+	}{{"cached balance", cached}, {"posted balance", posted}} {
+		if side.actual == expected {
+			continue
+		}
+		discrepancies = append(discrepancies, domain.Discrepancy{
+			Kind:      domain.DiscrepancyBalanceMismatch,
+			AccountID: accountID,
+			Expected:  expected.String(),
+			Actual:    side.actual.String(),
+			Detail: fmt.Sprintf("%s against opening balance %s plus completed transactions created before %s",
+				side.name, opening, cutoff.Format(time.RFC3339)),
+		})
+	}
+	return discrepancies, nil
+}
+
+func (s *ReconciliationService) checkStatus(transaction *domain.Transaction, stuckBefore time.Time, repair bool) (*domain.Discrepancy, error) {
+	// Fees are written to the log already completed and never queued
+	if transaction.FeeOf != "" {
+		return nil, nil
+	}
+
+	decision, err := s.inboxRepo.Get(transaction.ID)
+	if err != nil {
+		return nil, err
+	}
+	if decision == nil {
+		switch {
+		case transaction.Status == constants.TransactionStatusCompleted:
+			return &domain.Discrepancy{
+				Kind:          domain.DiscrepancyNotApplied,
+				AccountID:     transaction.AccountID,
+				TransactionID: transaction.ID,
+				Actual:        string(transaction.Status),
+				Detail:        "completed in the transaction log but never applied to the ledger",
+			}, nil
+		case isUndecided(transaction.Status) && transaction.CreatedAt.Before(stuckBefore):
+			return &domain.Discrepancy{
+				Kind:          domain.DiscrepancyStuckPending,
+				AccountID:     transaction.AccountID,
+				TransactionID: transaction.ID,
+				Actual:        string(transaction.Status),
+				Detail:        fmt.Sprintf("queued at %s", transaction.CreatedAt.Format(time.RFC3339)),
+			}, nil
+		}
+		return nil, nil
+	}
+
+	want := decision.DecidedStatus()
+	if transaction.Status == want {
+		return nil, nil
+	}
+	// The processor may still be finishing a decision it has just made
+	if isUndecided(transaction.Status) && decision.ProcessedAt.After(stuckBefore) {
+		return nil, nil
+	}
+
+	discrepancy := &domain.Discrepancy{
+		Kind:          domain.DiscrepancyStatusMismatch,
+		AccountID:     transaction.AccountID,
+		TransactionID: transaction.ID,
+		Expected:      string(want),
+		Actual:        string(transaction.Status),
+		Detail:        decision.Reason,
+	}
+	if repair {
+		update := domain.StatusUpdate{Status: want, Failure: decision.Failure(), Instance: reconcileInstance}
+		if err := finishStatus(s.transactionRepo, s.feeRepo, transaction, update); err != nil {
+			discrepancy.Detail = fmt.Sprintf("repair failed: %v", err)
+		} else {
+			discrepancy.Repaired = true
+		}
+	}
+	return discrepancy, nil
+}
+
+// Reports whether the processor may not have decided a transaction yet
+func isUndecided(status constants.TransactionStatus) bool {
+	return status == constants.TransactionStatusPending || status == constants.TransactionStatusProcessing
+}
+
+// Finishes what the processor would have after deciding: the fee record,
+// then the status
+func finishStatus(transactionRepo domain.TransactionRepository, feeRepo domain.FeeRepository, transaction *domain.Transaction, update domain.StatusUpdate) error {
+	if update.Status == constants.TransactionStatusCompleted {
+		charge, err := feeRepo.GetCharge(transaction.ID)
+		if err != nil {
+			return err
+		}
+		if charge != nil {
+			if err := transactionRepo.RecordFee(charge.Transaction()); err != nil {
+				return err
+			}
+		}
+	}
+	return transactionRepo.UpdateStatus(transaction.ID, update)
+}
+
+// Run reconciles every interval until ctx is cancelled, logging what it
+// finds; it never repairs
+func (s *ReconciliationService) Run(ctx context.Context, interval, stuckAfter time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			report, err := s.Reconcile(stuckAfter, false)
+			if err != nil {
+				log.Printf("Failed to reconcile: %v", err)
+				continue
+			}
+			for _, d := range report.Discrepancies {
+				log.Printf("Reconciliation: %s account=%s transaction=%s expected=%s actual=%s %s",
+					d.Kind, d.AccountID, d.TransactionID, d.Expected, d.Actual, d.Detail)
+			}
+			log.Printf("Reconciled %d accounts and %d transactions, %d discrepancies",
+				report.AccountsChecked, report.TransactionsChecked, len(report.Discrepancies))
+		}
+	}
+}
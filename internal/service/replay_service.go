@@ -0,0 +1,183 @@
+package service
+
+import (
+	"errors"
+	"sort"
+	"time"
+
+	"banking-ledger/internal/domain"
+)
+
+// A replay saves a snapshot of an account once this many events follow its
+// latest one
+const DefaultSnapshotEvery = 100
+
+// AccountReplayService rebuilds accounts from their events and checks the
+// PostgreSQL account projection against them
+type AccountReplayService struct {
+	accountRepo domain.AccountRepository
+	eventStore  domain.AccountEventStore
+	unitOfWork  domain.UnitOfWork
+}
+
+func NewAccountReplayService(
+	accountRepo domain.AccountRepository,
+	eventStore domain.AccountEventStore,
+	unitOfWork domain.UnitOfWork,
+) *AccountReplayService {
+	return &AccountReplayService{
+		accountRepo: accountRepo,
+		eventStore:  eventStore,
+		unitOfWork:  unitOfWork,
+	}
+}
+
+// Replay folds every account's events, starting from its latest snapshot
+// unless fromScratch is set, in which case the snapshot is checked as well.
+// With rebuild set, projection rows that disagree with the events are
+// overwritten. A snapshot is saved once snapshotEvery events follow the
+// latest one; zero saves none.
+func (s *AccountReplayService) Replay(fromScratch, rebuild bool, snapshotEvery int) (*domain.ReplayReport, error) {
+	report := &domain.ReplayReport{
+		StartedAt:  time.Now(),
+		Mismatched: []domain.ReplayedAccount{},
+	}
+
+	// Accounts missing from the projection only show up in the event store,
+	// and accounts without events only in the projection
+	withEvents, err := s.eventStore.ListAccountIDs()
+	if err != nil {
+		return nil, err
+	}
+	projected, err := listAccountIDs(s.accountRepo)
+	if err != nil {
+		return nil, err
+	}
+	seen := make(map[string]bool)
+	var accountIDs []string
+	for _, id := range append(withEvents, projected...) {
+		if !seen[id] {
+			seen[id] = true
+			accountIDs = append(accountIDs, id)
+		}
+	}
+	sort.Strings(accountIDs)
+
+	for _, accountID := range accountIDs {
+		replayed, snapshotTaken, err := s.replay(accountID, fromScratch, rebuild, snapshotEvery)
+		if err != nil {
+			return nil, err
+		}
+		report.AccountsReplayed++
+		report.EventsReplayed += replayed.Events
+		if snapshotTaken {
+			report.SnapshotsTaken++
+		}
+		if len(replayed.Mismatches) > 0 {
+			report.Mismatched = append(report.Mismatched, *replayed)
+		}
+	}
+
+	report.FinishedAt = time.Now()
+	return report, nil
+}
+
+// Replays one account while its projection row is locked, so no event can
+// be appended between reading the events and comparing the row
+func (s *AccountReplayService) replay(accountID string, fromScratch, rebuild bool, snapshotEvery int) (*domain.ReplayedAccount, bool, error) {
+	replayed := &domain.ReplayedAccount{AccountID: accountID}
+	snapshotTaken := false
+
+	err := s.unitOfWork.Do(func(tx domain.TxRepositories) error {
+		var stored *domain.Account
+		locked, err := tx.Accounts.LockForUpdate(accountID)
+		if err == nil {
+			stored = locked[accountID]
+		} else if !errors.Is(err, domain.ErrAccountNotFound) {
+			return err
+		}
+
+		snapshot, err := tx.Events.LatestSnapshot(accountID)
+		if err != nil {
+			return err
+		}
+		start := snapshot
+		if fromScratch {
+			start = nil
+		}
+		var after int64
+		if start != nil {
+			after = start.Account.Version
+			replayed.FromSnapshot = after
+		}
+		events, err := tx.Events.ListEvents(accountID, after)
+		if err != nil {
+			return err
+		}
+		replayed.Events = len(events)
+		if start == nil && len(events) == 0 {
+			replayed.Mismatches = []string{"no events recorded"}
+			return nil
+		}
+
+		account, snapshotDiffs, err := fold(start, snapshot, events)
+		if err != nil {
+			// Broken events cannot be trusted to rebuild anything
+			replayed.Mismatches = []string{err.Error()}
+			return nil
+		}
+		replayed.Version = account.Version
+		replayed.Mismatches = snapshotDiffs
+
+		var projectionDiffs []string
+		if stored == nil {
+			projectionDiffs = []string{"projection row missing"}
+		} else {
+			projectionDiffs = account.DiffEventState(stored)
+		}
+		replayed.Mismatches = append(replayed.Mismatches, projectionDiffs...)
+
+		if rebuild && len(projectionDiffs) > 0 {
+			if err := tx.Events.ReplaceProjection(account); err != nil {
+				return err
+			}
+			replayed.Rebuilt = true
+		}
+
+		var snapshotVersion int64
+		if snapshot != nil {
+			snapshotVersion = snapshot.Account.Version
+		}
+		if snapshotEvery > 0 && account.Version-snapshotVersion >= int64(snapshotEvery) {
+			if err := tx.Events.SaveSnapshot(&domain.AccountSnapshot{Account: *account, TakenAt: time.Now()}); err != nil {
+				return err
+			}
+			snapshotTaken = true
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, false, err
+	}
+	return replayed, snapshotTaken, nil
+}
+
+// Folds events onto start. When replaying from scratch past the latest
+// snapshot, it also returns where that snapshot disagrees with the events.
+func fold(start, snapshot *domain.AccountSnapshot, events []*domain.AccountEvent) (*domain.Account, []string, error) {
+	if start != nil || snapshot == nil || snapshot.Account.Version > int64(len(events)) {
+		account, err := domain.ReplayAccount(start, events)
+		return account, nil, err
+	}
+
+	atSnapshot, err := domain.ReplayAccount(nil, events[:snapshot.Account.Version])
+	if err != nil {
+		return nil, nil, err
+	}
+	var diffs []string
+	for _, diff := range atSnapshot.DiffEventState(&snapshot.Account) {
+		diffs = append(diffs, "snapshot "+diff)
+	}
+	account, err := domain.ReplayAccount(&domain.AccountSnapshot{Account: *atSnapshot}, events[snapshot.Account.Version:])
+	return account, diffs, err
+}
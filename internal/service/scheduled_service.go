@@ -0,0 +1,272 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+
+	"banking-ledger/internal/domain"
+	"banking-ledger/internal/money"
+)
+
+// How many due schedules, and retried runs, one pass handles
+const scheduledBatchSize = 100
+
+type ScheduledTransactionService struct {
+	scheduleRepo       domain.ScheduledTransactionRepository
+	accountRepo        domain.AccountRepository
+	transactionRepo    domain.TransactionRepository
+	transactionService *TransactionService
+}
+
+func NewScheduledTransactionService(
+	scheduleRepo domain.ScheduledTransactionRepository,
+	accountRepo domain.AccountRepository,
+	transactionRepo domain.TransactionRepository,
+	transactionService *TransactionService,
+) *ScheduledTransactionService {
+	return &ScheduledTransactionService{
+		scheduleRepo:       scheduleRepo,
+		accountRepo:        accountRepo,
+		transactionRepo:    transactionRepo,
+		transactionService: transactionService,
+	}
+}
+
+// Stores a new schedule. Funds and limits are only checked when a run is
+// due, so a schedule can be set up ahead of the money arriving.
+func (s *ScheduledTransactionService) Create(schedule *domain.ScheduledTransaction) (*domain.ScheduledTransaction, error) {
+	now := time.Now()
+	if err := schedule.Validate(now); err != nil {
+		return nil, err
+	}
+
+	account, err := s.accountRepo.GetByID(schedule.AccountID)
+	if err != nil {
+		return nil, err
+	}
+	if schedule.Amount.Currency != account.Currency {
+		return nil, money.ErrCurrencyMismatch
+	}
+	if schedule.ToAccountID != "" {
+		if _, err := s.accountRepo.GetByID(schedule.ToAccountID); err != nil {
+			return nil, err
+		}
+	}
+
+	next, err := schedule.NextAfter(now)
+	if err != nil {
+		return nil, err
+	}
+	if next == nil {
+		return nil, fmt.Errorf("%w: cron %q never runs", domain.ErrInvalidScheduledTransaction, schedule.Cron)
+	}
+
+	schedule.ID = uuid.New().String()
+	schedule.Status = domain.ScheduleStatusActive
+	schedule.NextRunAt = next
+	schedule.CreatedAt = now
+	schedule.UpdatedAt = now
+	if err := s.scheduleRepo.Create(schedule); err != nil {
+		return nil, err
+	}
+	return schedule, nil
+}
+
+// Retrieves a schedule by ID
+func (s *ScheduledTransactionService) Get(id string) (*domain.ScheduledTransaction, error) {
+	return s.scheduleRepo.GetByID(id)
+}
+
+// Lists the schedules paying into or out of the account
+func (s *ScheduledTransactionService) ListByAccount(accountID string) ([]*domain.ScheduledTransaction, error) {
+	if _, err := s.accountRepo.GetByID(accountID); err != nil {
+		return nil, err
+	}
+	return s.scheduleRepo.ListByAccount(accountID)
+}
+
+// Stops a schedule's runs until it is resumed
+func (s *ScheduledTransactionService) Pause(id string) (*domain.ScheduledTransaction, error) {
+	return s.changeState(id, func(schedule *domain.ScheduledTransaction) error {
+		return schedule.Pause()
+	})
+}
+
+// Restarts a paused schedule from its next run after now
+func (s *ScheduledTransactionService) Resume(id string) (*domain.ScheduledTransaction, error) {
+	return s.changeState(id, func(schedule *domain.ScheduledTransaction) error {
+		return schedule.Resume(time.Now())
+	})
+}
+
+// Ends a schedule for good
+func (s *ScheduledTransactionService) Cancel(id string) (*domain.ScheduledTransaction, error) {
+	return s.changeState(id, func(schedule *domain.ScheduledTransaction) error {
+		return schedule.Cancel()
+	})
+}
+
+func (s *ScheduledTransactionService) changeState(id string, change func(*domain.ScheduledTransaction) error) (*domain.ScheduledTransaction, error) {
+	return s.scheduleRepo.UpdateState(id, func(schedule *domain.ScheduledTransaction) error {
+		if err := change(schedule); err != nil {
+			return err
+		}
+		schedule.UpdatedAt = time.Now()
+		return nil
+	})
+}
+
+// Lists a schedule's runs, newest first, with the current status of each
+// queued transaction
+func (s *ScheduledTransactionService) ListExecutions(id string) ([]*domain.ScheduledExecution, error) {
+	if _, err := s.scheduleRepo.GetByID(id); err != nil {
+		return nil, err
+	}
+	executions, err := s.scheduleRepo.ListExecutions(id)
+	if err != nil {
+		return nil, err
+	}
+	for _, execution := range executions {
+		if execution.Outcome != domain.ExecutionOutcomeQueued {
+			continue
+		}
+		transaction, err := s.transactionRepo.GetByID(execution.TransactionID)
+		if err != nil {
+			continue
+		}
+		execution.TransactionStatus = transaction.Status
+	}
+	return executions, nil
+}
+
+// RunDue queues a transaction for every schedule due by now, and retries
+// runs an earlier pass claimed but did not queue. It returns how many
+// transactions it queued. A schedule that fails is logged and left for the
+// next pass so it does not hold up the others.
+func (s *ScheduledTransactionService) RunDue(now time.Time) (int, error) {
+	queued := 0
+
+	pending, err := s.scheduleRepo.ListPendingExecutions(scheduledBatchSize)
+	if err != nil {
+		return 0, err
+	}
+	for _, execution := range pending {
+		schedule, err := s.scheduleRepo.GetByID(execution.ScheduleID)
+		if err != nil {
+			log.Printf("Failed to load scheduled transaction %s: %v", execution.ScheduleID, err)
+			continue
+		}
+		ok, err := s.execute(schedule, execution)
+		if err != nil {
+			log.Printf("Failed to retry run %s of scheduled transaction %s: %v", execution.ID, schedule.ID, err)
+			continue
+		}
+		if ok {
+			queued++
+		}
+	}
+
+	due, err := s.scheduleRepo.ListDue(now, scheduledBatchSize)
+	if err != nil {
+		return queued, err
+	}
+	for _, schedule := range due {
+		execution := &domain.ScheduledExecution{
+			ID:            uuid.New().String(),
+			ScheduleID:    schedule.ID,
+			DueAt:         *schedule.NextRunAt,
+			TransactionID: scheduledTransactionID(schedule.ID, *schedule.NextRunAt),
+			Outcome:       domain.ExecutionOutcomePending,
+			CreatedAt:     now,
+			UpdatedAt:     now,
+		}
+
+		// Runs missed while the scheduler was down are made once, not once each
+		next, err := schedule.NextAfter(now)
+		if err != nil {
+			log.Printf("Failed to compute the next run of scheduled transaction %s: %v", schedule.ID, err)
+			continue
+		}
+		err = s.scheduleRepo.ClaimExecution(schedule, execution, next)
+		if errors.Is(err, domain.ErrExecutionClaimed) {
+			continue
+		}
+		if err != nil {
+			log.Printf("Failed to claim run of scheduled transaction %s: %v", schedule.ID, err)
+			continue
+		}
+
+		ok, err := s.execute(schedule, execution)
+		if err != nil {
+			log.Printf("Failed to run scheduled transaction %s, leaving it pending: %v", schedule.ID, err)
+			continue
+		}
+		if ok {
+			queued++
+		}
+	}
+	return queued, nil
+}
+
+// Queues the run's transaction unless it already exists, recording whether
+// it was queued or refused. Only a rejection of the transaction itself is
+// final; any other failure, such as a database that could not be reached,
+// leaves the run pending for the next pass.
+func (s *ScheduledTransactionService) execute(schedule *domain.ScheduledTransaction, execution *domain.ScheduledExecution) (bool, error) {
+	_, err := s.transactionRepo.GetByID(execution.TransactionID)
+	switch {
+	case err == nil:
+		execution.Outcome = domain.ExecutionOutcomeQueued
+	case errors.Is(err, domain.ErrTransactionNotFound):
+		_, err = s.transactionService.CreateScheduled(execution.TransactionID, schedule)
+		switch {
+		case err == nil:
+			execution.Outcome = domain.ExecutionOutcomeQueued
+		case domain.IsRejection(err):
+			execution.Outcome = domain.ExecutionOutcomeRejected
+			execution.Error = err.Error()
+		default:
+			return false, err
+		}
+	default:
+		return false, err
+	}
+
+	execution.UpdatedAt = time.Now()
+	if err := s.scheduleRepo.FinishExecution(execution); err != nil {
+		return false, err
+	}
+	return execution.Outcome == domain.ExecutionOutcomeQueued, nil
+}
+
+// The same run of a schedule always gets the same transaction ID
+func scheduledTransactionID(scheduleID string, dueAt time.Time) string {
+	key := "scheduled:" + scheduleID + ":" + dueAt.UTC().Format(time.RFC3339)
+	return uuid.NewSHA1(uuid.NameSpaceURL, []byte(key)).String()
+}
+
+// Run queues due scheduled transactions every interval until ctx is cancelled
+func (s *ScheduledTransactionService) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			queued, err := s.RunDue(time.Now())
+			if err != nil {
+				log.Printf("Failed to run scheduled transactions: %v", err)
+			}
+			if queued > 0 {
+				log.Printf("Queued %d scheduled transactions", queued)
+			}
+		}
+	}
+}
@@ -0,0 +1,111 @@
+package service
+
+import (
+	"fmt"
+	"time"
+
+	"banking-ledger/internal/domain"
+	"banking-ledger/internal/money"
+)
+
+type StatementService struct {
+	accountRepo     domain.AccountRepository
+	journalRepo     domain.JournalRepository
+	transactionRepo domain.TransactionRepository
+}
+
+func NewStatementService(
+	accountRepo domain.AccountRepository,
+	journalRepo domain.JournalRepository,
+	transactionRepo domain.TransactionRepository,
+) *StatementService {
+	return &StatementService{
+		accountRepo:     accountRepo,
+		journalRepo:     journalRepo,
+		transactionRepo: transactionRepo,
+	}
+}
+
+// Generate builds the account's statement for [from, to). Order and balances
+// come from the balance history, so only applied transactions appear and the
+// figures match the balance history API; details come from the transaction log.
+func (s *StatementService) Generate(accountID string, from, to time.Time) (*domain.Statement, error) {
+	if !from.Before(to) {
+		return nil, domain.ErrInvalidStatementPeriod
+	}
+
+	account, err := s.accountRepo.GetByID(accountID)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := s.journalRepo.ListStatementEntries(account.ID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	opening, err := s.openingBalance(account, from, entries)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		if entry.TransactionID != "" {
+			ids = append(ids, entry.TransactionID)
+		}
+	}
+	found, err := s.transactionRepo.ListByIDs(ids)
+	if err != nil {
+		return nil, err
+	}
+	transactions := make(map[string]*domain.Transaction, len(found))
+	for _, transaction := range found {
+		transactions[transaction.ID] = transaction
+	}
+
+	statement, err := domain.NewStatement(account, from, to, opening, entries, transactions)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build statement for account %s: %v", account.ID, err)
+	}
+	statement.GeneratedAt = time.Now()
+	return statement, nil
+}
+
+// The balance just before the first entry of the period, or the last one
+// recorded before it if the period has none
+func (s *StatementService) openingBalance(account *domain.Account, from time.Time, entries []domain.StatementEntry) (money.Money, error) {
+	if len(entries) > 0 {
+		return entries[0].Balance.Sub(entries[0].Change)
+	}
+	last, err := s.journalRepo.BalanceHistoryAt(account.ID, from)
+	if err != nil {
+		return money.Money{}, err
+	}
+	if last == nil {
+		return money.Zero(account.Currency), nil
+	}
+	return last.Balance, nil
+}
+
+// ListAccountIDs pages through every account, for generating statements in bulk
+func (s *StatementService) ListAccountIDs() ([]string, error) {
+	return listAccountIDs(s.accountRepo)
+}
+
+// Pages through every account, oldest first
+func listAccountIDs(accountRepo domain.AccountRepository) ([]string, error) {
+	var ids []string
+	filter := domain.AccountFilter{SortBy: domain.AccountSortCreatedAt}
+	for {
+		page, err := accountRepo.List(filter)
+		if err != nil {
+			return nil, err
+		}
+		for _, account := range page.Accounts {
+			ids = append(ids, account.ID)
+		}
+		if page.NextCursor == "" {
+			return ids, nil
+		}
+		filter.Cursor = page.NextCursor
+	}
+}
@@ -0,0 +1,190 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"banking-ledger/internal/constants"
+	"banking-ledger/internal/domain"
+)
+
+// Recorded in the status history of transactions the sweep finishes
+const sweepInstance = "pending-sweep"
+
+// PendingSweepService recovers transactions left pending because their queue
+// message never reached the processor
+type PendingSweepService struct {
+	transactionRepo domain.TransactionRepository
+	outboxRepo      domain.OutboxRepository
+	inboxRepo       domain.InboxRepository
+	feeRepo         domain.FeeRepository
+	unitOfWork      domain.UnitOfWork
+}
+
+func NewPendingSweepService(
+	transactionRepo domain.TransactionRepository,
+	outboxRepo domain.OutboxRepository,
+	inboxRepo domain.InboxRepository,
+	feeRepo domain.FeeRepository,
+	unitOfWork domain.UnitOfWork,
+) *PendingSweepService {
+	return &PendingSweepService{
+		transactionRepo: transactionRepo,
+		outboxRepo:      outboxRepo,
+		inboxRepo:       inboxRepo,
+		feeRepo:         feeRepo,
+		unitOfWork:      unitOfWork,
+	}
+}
+
+// Sweep handles every transaction still undecided olderThan after it was
+// queued. One the processor already decided gets its status finished; one it
+// never saw is requeued, up to maxRequeues times, and then marked failed.
+func (s *PendingSweepService) Sweep(olderThan time.Duration, maxRequeues int) (*domain.SweepReport, error) {
+	report := &domain.SweepReport{
+		StartedAt:    time.Now(),
+		Transactions: []domain.SweptTransaction{},
+	}
+	cutoff := report.StartedAt.Add(-olderThan)
+
+	filter := domain.TransactionFilter{
+		Statuses:  []constants.TransactionStatus{constants.TransactionStatusPending, constants.TransactionStatusProcessing},
+		CreatedTo: &cutoff,
+	}
+	for {
+		page, err := s.transactionRepo.List(filter)
+		if err != nil {
+			return nil, err
+		}
+		for _, transaction := range page.Transactions {
+			swept, err := s.sweep(transaction, cutoff, maxRequeues)
+			if err != nil {
+				return nil, fmt.Errorf("failed to sweep transaction %s: %v", transaction.ID, err)
+			}
+			report.Transactions = append(report.Transactions, *swept)
+		}
+		if page.NextCursor == "" {
+			break
+		}
+		filter.Cursor = page.NextCursor
+	}
+
+	report.FinishedAt = time.Now()
+	return report, nil
+}
+
+func (s *PendingSweepService) sweep(transaction *domain.Transaction, cutoff time.Time, maxRequeues int) (*domain.SweptTransaction, error) {
+	swept := &domain.SweptTransaction{
+		TransactionID: transaction.ID,
+		AccountID:     transaction.AccountID,
+		CreatedAt:     transaction.CreatedAt,
+		Status:        transaction.Status,
+	}
+
+	decision, err := s.inboxRepo.Get(transaction.ID)
+	if err != nil {
+		return nil, err
+	}
+	message, err := s.outboxRepo.Get(transaction.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	swept.Action = domain.NextSweepAction(decision, message, cutoff, maxRequeues)
+	switch swept.Action {
+	case domain.SweepActionRequeued:
+		payload, err := transactionMessage(transaction)
+		if err != nil {
+			return nil, err
+		}
+		if err := s.outboxRepo.Requeue(transaction.ID, payload); err != nil {
+			return nil, err
+		}
+		swept.Reason = "never reached the processor"
+		if message != nil {
+			swept.Reason = fmt.Sprintf("never reached the processor, requeue %d of %d", message.Requeues+1, maxRequeues)
+		}
+		return swept, nil
+	case domain.SweepActionWaiting:
+		return swept, nil
+	case domain.SweepActionFailed:
+		// Rejecting through the inbox means a copy of the message still in
+		// the queue finds the decision made and cannot apply it as well
+		var rejected bool
+		failure := &domain.TransactionFailure{
+			Code:    domain.FailureNotProcessed,
+			Message: fmt.Sprintf("not processed after %d requeues", maxRequeues),
+		}
+		decision, rejected, err = s.reject(transaction.ID, failure)
+		if err != nil {
+			return nil, err
+		}
+		if !rejected {
+			swept.Action = domain.SweepActionFinished
+		}
+	}
+
+	swept.Status = decision.DecidedStatus()
+	swept.Reason = decision.Reason
+	update := domain.StatusUpdate{Status: swept.Status, Failure: decision.Failure(), Instance: sweepInstance}
+	if err := finishStatus(s.transactionRepo, s.feeRepo, transaction, update); err != nil {
+		return nil, err
+	}
+	return swept, nil
+}
+
+// Records a rejection unless the processor decided first, returning
+// whichever decision stands and whether it was this one
+func (s *PendingSweepService) reject(transactionID string, failure *domain.TransactionFailure) (*domain.InboxRecord, bool, error) {
+	var decision *domain.InboxRecord
+	rejected := false
+	err := s.unitOfWork.Do(func(tx domain.TxRepositories) error {
+		prior, err := tx.Inbox.Claim(transactionID)
+		if err != nil {
+			return err
+		}
+		if prior != nil {
+			decision = prior
+			return nil
+		}
+		decision = &domain.InboxRecord{
+			TransactionID: transactionID,
+			Outcome:       domain.InboxOutcomeRejected,
+			FailureCode:   failure.Code,
+			Reason:        failure.Message,
+			ProcessedAt:   time.Now(),
+		}
+		rejected = true
+		return tx.Inbox.SetOutcome(transactionID, decision.Outcome, failure)
+	})
+	if err != nil {
+		return nil, false, err
+	}
+	return decision, rejected, nil
+}
+
+// Run sweeps every interval until ctx is cancelled, logging what it did
+func (s *PendingSweepService) Run(ctx context.Context, interval, olderThan time.Duration, maxRequeues int) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			report, err := s.Sweep(olderThan, maxRequeues)
+			if err != nil {
+				log.Printf("Failed to sweep pending transactions: %v", err)
+				continue
+			}
+			for _, swept := range report.Transactions {
+				if swept.Action != domain.SweepActionWaiting {
+					log.Printf("Pending sweep: %s transaction %s (%s) %s", swept.Action, swept.TransactionID, swept.Status, swept.Reason)
+				}
+			}
+		}
+	}
+}
@@ -1,7 +1,8 @@
 package service
 
 import (
-	"errors"
+	"encoding/json"
+	"fmt"
 	"time"
 
 	"github.com/google/uuid"
@@ -9,41 +10,57 @@ import (
 	"banking-ledger/internal/constants"
 	"banking-ledger/internal/domain"
 	"banking-ledger/internal/models"
-	"banking-ledger/internal/queue"
+	"banking-ledger/internal/money"
 )
 
 type TransactionService struct {
 	transactionRepo domain.TransactionRepository
 	accountRepo     domain.AccountRepository
-	producer        queue.Producer
+	fxRates         domain.FXRateProvider
+	holdRepo        domain.HoldRepository
+	limitRepo       domain.LimitRepository
 }
 
 func NewTransactionService(
 	transactionRepo domain.TransactionRepository,
 	accountRepo domain.AccountRepository,
-	producer queue.Producer,
+	fxRates domain.FXRateProvider,
+	holdRepo domain.HoldRepository,
+	limitRepo domain.LimitRepository,
 ) *TransactionService {
 	return &TransactionService{
 		transactionRepo: transactionRepo,
 		accountRepo:     accountRepo,
-		producer:        producer,
+		fxRates:         fxRates,
+		holdRepo:        holdRepo,
+		limitRepo:       limitRepo,
 	}
 }
 
 // creates a new deposit transaction
-func (s *TransactionService) CreateDeposit(accountID string, amount float64, description string) (*domain.Transaction, error) {
-	if amount <= 0 {
-		return nil, errors.New("deposit amount must be positive")
+func (s *TransactionService) CreateDeposit(accountID string, amount money.Money, description string) (*domain.Transaction, error) {
+	return s.createDeposit(uuid.New().String(), accountID, amount, description)
+}
+
+func (s *TransactionService) createDeposit(id, accountID string, amount money.Money, description string) (*domain.Transaction, error) {
+	if !amount.IsPositive() {
+		return nil, fmt.Errorf("%w: deposit amount must be positive", domain.ErrInvalidTransaction)
 	}
 
-	_, err := s.accountRepo.GetByID(accountID)
+	account, err := s.accountRepo.GetByID(accountID)
 	if err != nil {
 		return nil, err
 	}
+	if amount.Currency != account.Currency {
+		return nil, money.ErrCurrencyMismatch
+	}
+	if err := account.CheckCredit(); err != nil {
+		return nil, err
+	}
 
 	now := time.Now()
 	transaction := &domain.Transaction{
-		ID:          uuid.New().String(),
+		ID:          id,
 		AccountID:   accountID,
 		Type:        constants.TransactionTypeDeposit,
 		Amount:      amount,
@@ -53,20 +70,11 @@ func (s *TransactionService) CreateDeposit(accountID string, amount float64, des
 		UpdatedAt:   now,
 	}
 
-	if err := s.transactionRepo.Create(transaction); err != nil {
+	if err := s.checkLimits(account, transaction); err != nil {
 		return nil, err
 	}
 
-	message := models.TransactionMessage{
-		TransactionID: transaction.ID,
-		AccountID:     accountID,
-		Type:          constants.TransactionTypeDeposit,
-		Amount:        amount,
-		Description:   description,
-	}
-
-	if err := s.producer.PublishTransaction(message); err != nil {
-		_ = s.transactionRepo.UpdateStatus(transaction.ID, constants.TransactionStatusFailed)
+	if err := s.enqueue(transaction); err != nil {
 		return nil, err
 	}
 
@@ -74,23 +82,33 @@ func (s *TransactionService) CreateDeposit(accountID string, amount float64, des
 }
 
 // Creates a new withdrawal transaction
-func (s *TransactionService) CreateWithdrawal(accountID string, amount float64, description string) (*domain.Transaction, error) {
-	if amount <= 0 {
-		return nil, errors.New("withdrawal amount must be positive")
+func (s *TransactionService) CreateWithdrawal(accountID string, amount money.Money, description string) (*domain.Transaction, error) {
+	return s.createWithdrawal(uuid.New().String(), accountID, amount, description)
+}
+
+func (s *TransactionService) createWithdrawal(id, accountID string, amount money.Money, description string) (*domain.Transaction, error) {
+	if !amount.IsPositive() {
+		return nil, fmt.Errorf("%w: withdrawal amount must be positive", domain.ErrInvalidTransaction)
 	}
 
 	account, err := s.accountRepo.GetByID(accountID)
 	if err != nil {
 		return nil, err
 	}
+	if amount.Currency != account.Currency {
+		return nil, money.ErrCurrencyMismatch
+	}
+	if err := account.CheckDebit(); err != nil {
+		return nil, err
+	}
 
-	if account.Balance < amount {
-		return nil, errors.New("insufficient funds")
+	if err := account.CheckFunds(amount); err != nil {
+		return nil, err
 	}
 
 	now := time.Now()
 	transaction := &domain.Transaction{
-		ID:          uuid.New().String(),
+		ID:          id,
 		AccountID:   accountID,
 		Type:        constants.TransactionTypeWithdrawal,
 		Amount:      amount,
@@ -100,32 +118,310 @@ func (s *TransactionService) CreateWithdrawal(accountID string, amount float64,
 		UpdatedAt:   now,
 	}
 
-	if err := s.transactionRepo.Create(transaction); err != nil {
+	if err := s.checkLimits(account, transaction); err != nil {
 		return nil, err
 	}
 
-	message := models.TransactionMessage{
-		TransactionID: transaction.ID,
-		AccountID:     accountID,
-		Type:          constants.TransactionTypeWithdrawal,
-		Amount:        amount,
-		Description:   description,
+	if err := s.enqueue(transaction); err != nil {
+		return nil, err
+	}
+
+	return transaction, nil
+}
+
+// Creates a transfer between two accounts; both legs are applied atomically by the processor.
+// When the accounts hold different currencies the amount is converted at the
+// current rate, and that rate is stored with the transaction.
+func (s *TransactionService) CreateTransfer(fromAccountID, toAccountID string, amount money.Money, description string) (*domain.Transaction, error) {
+	return s.createTransfer(uuid.New().String(), fromAccountID, toAccountID, amount, description)
+}
+
+func (s *TransactionService) createTransfer(id, fromAccountID, toAccountID string, amount money.Money, description string) (*domain.Transaction, error) {
+	if !amount.IsPositive() {
+		return nil, fmt.Errorf("%w: transfer amount must be positive", domain.ErrInvalidTransaction)
+	}
+	if fromAccountID == toAccountID {
+		return nil, fmt.Errorf("%w: cannot transfer to the same account", domain.ErrInvalidTransaction)
+	}
+
+	from, err := s.accountRepo.GetByID(fromAccountID)
+	if err != nil {
+		return nil, err
+	}
+	to, err := s.accountRepo.GetByID(toAccountID)
+	if err != nil {
+		return nil, err
+	}
+	if amount.Currency != from.Currency {
+		return nil, money.ErrCurrencyMismatch
+	}
+	if err := from.CheckDebit(); err != nil {
+		return nil, err
+	}
+	if err := to.CheckCredit(); err != nil {
+		return nil, err
+	}
+
+	if err := from.CheckFunds(amount); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	transaction := &domain.Transaction{
+		ID:          id,
+		AccountID:   fromAccountID,
+		ToAccountID: toAccountID,
+		Type:        constants.TransactionTypeTransfer,
+		Amount:      amount,
+		Status:      constants.TransactionStatusPending,
+		Description: description,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+
+	if to.Currency != from.Currency {
+		rate, err := s.fxRates.GetRate(from.Currency, to.Currency)
+		if err != nil {
+			return nil, err
+		}
+		converted, err := rate.Convert(amount)
+		if err != nil {
+			return nil, err
+		}
+		if !converted.IsPositive() {
+			return nil, fmt.Errorf("%w: transfer amount is too small to convert", domain.ErrInvalidTransaction)
+		}
+		transaction.ToAmount = &converted
+		transaction.FXRate = rate
+	}
+
+	// Catch an entry the processor could not post before accepting the transfer
+	if _, err := domain.NewTransactionEntry(transaction); err != nil {
+		return nil, err
+	}
+
+	if err := s.checkLimits(from, transaction); err != nil {
+		return nil, err
+	}
+
+	if err := s.enqueue(transaction); err != nil {
+		return nil, err
+	}
+
+	return transaction, nil
+}
+
+// Settles a hold by debiting amount, or the whole hold when amount is nil.
+// Anything not captured is released when the processor applies the capture.
+func (s *TransactionService) CreateCapture(holdID string, amount *money.Money, description string) (*domain.Transaction, error) {
+	hold, err := s.holdRepo.GetByID(holdID)
+	if err != nil {
+		return nil, err
+	}
+
+	captured := hold.Amount
+	if amount != nil {
+		captured = *amount
+	}
+	if !captured.IsPositive() {
+		return nil, fmt.Errorf("%w: capture amount must be positive", domain.ErrInvalidTransaction)
+	}
+	if err := hold.CheckCapture(captured, time.Now()); err != nil {
+		return nil, err
+	}
+	if description == "" {
+		description = hold.Description
+	}
+
+	account, err := s.accountRepo.GetByID(hold.AccountID)
+	if err != nil {
+		return nil, err
 	}
 
-	if err := s.producer.PublishTransaction(message); err != nil {
-		_ = s.transactionRepo.UpdateStatus(transaction.ID, constants.TransactionStatusFailed)
+	now := time.Now()
+	transaction := &domain.Transaction{
+		ID:          uuid.New().String(),
+		AccountID:   hold.AccountID,
+		Type:        constants.TransactionTypeCapture,
+		Amount:      captured,
+		HoldID:      hold.ID,
+		Status:      constants.TransactionStatusPending,
+		Description: description,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+
+	if err := s.checkLimits(account, transaction); err != nil {
+		return nil, err
+	}
+
+	if err := s.enqueue(transaction); err != nil {
 		return nil, err
 	}
 
 	return transaction, nil
 }
 
+// Undoes a completed transaction in full. Fails if any part of it has
+// already been reversed or refunded.
+func (s *TransactionService) ReverseTransaction(id, description string) (*domain.Transaction, error) {
+	original, err := s.transactionRepo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	return s.createReversal(original, constants.TransactionTypeReversal, original.Amount, description)
+}
+
+// Gives back part of a completed transaction. Refunds add up, and together
+// can return at most the original amount.
+func (s *TransactionService) RefundTransaction(id string, amount money.Money, description string) (*domain.Transaction, error) {
+	original, err := s.transactionRepo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	return s.createReversal(original, constants.TransactionTypeRefund, amount, description)
+}
+
+// Queues a transaction that undoes amount of the original. The remaining
+// amount is checked here against earlier reversals that have not failed; the
+// processor checks it again under a lock when it applies the reversal.
+func (s *TransactionService) createReversal(original *domain.Transaction, transactionType constants.TransactionType, amount money.Money, description string) (*domain.Transaction, error) {
+	if err := original.CheckReversible(); err != nil {
+		return nil, err
+	}
+	if !amount.IsPositive() {
+		return nil, fmt.Errorf("%w: refund amount must be positive", domain.ErrInvalidTransaction)
+	}
+	if amount.Currency != original.Amount.Currency {
+		return nil, money.ErrCurrencyMismatch
+	}
+
+	earlier, err := s.transactionRepo.ListReversals(original.ID)
+	if err != nil {
+		return nil, err
+	}
+	remaining := original.Amount
+	for _, reversal := range earlier {
+		if reversal.Status == constants.TransactionStatusFailed {
+			continue
+		}
+		if remaining, err = remaining.Sub(reversal.Amount); err != nil {
+			return nil, err
+		}
+	}
+	if transactionType == constants.TransactionTypeReversal && remaining != original.Amount {
+		return nil, fmt.Errorf("%w: transaction %s has already been reversed or refunded", domain.ErrReversalExceedsOriginal, original.ID)
+	}
+	exceeds, err := remaining.LessThan(amount)
+	if err != nil {
+		return nil, err
+	}
+	if exceeds {
+		return nil, fmt.Errorf("%w: only %s is left to refund", domain.ErrReversalExceedsOriginal, remaining)
+	}
+
+	if description == "" {
+		description = "Reversal of " + original.ID
+		if transactionType == constants.TransactionTypeRefund {
+			description = "Refund of " + original.ID
+		}
+	}
+
+	now := time.Now()
+	transaction := &domain.Transaction{
+		ID:          uuid.New().String(),
+		AccountID:   original.AccountID,
+		ToAccountID: original.ToAccountID,
+		Type:        transactionType,
+		Amount:      amount,
+		ReversalOf:  original.ID,
+		Status:      constants.TransactionStatusPending,
+		Description: description,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+
+	// Catch an entry the processor could not post before accepting the reversal
+	if _, err := domain.NewReversalEntry(original, transaction); err != nil {
+		return nil, err
+	}
+
+	if err := s.enqueue(transaction); err != nil {
+		return nil, err
+	}
+
+	return transaction, nil
+}
+
+// Rejects a transaction that would break one of the account's limit rules.
+// Usage only counts applied transactions, so the processor checks again.
+func (s *TransactionService) checkLimits(account *domain.Account, transaction *domain.Transaction) error {
+	rules, err := s.limitRepo.ListApplicableRules(account, transaction.Type)
+	if err != nil {
+		return err
+	}
+	return domain.CheckLimits(rules, s.limitRepo, account, transaction, time.Now())
+}
+
+func (s *TransactionService) enqueue(transaction *domain.Transaction) error {
+	return enqueueTransaction(s.transactionRepo, transaction)
+}
+
+// Stores a pending transaction together with its queue message; the outbox
+// relay publishes the message, so a crash here cannot strand the transaction
+func enqueueTransaction(transactionRepo domain.TransactionRepository, transaction *domain.Transaction) error {
+	payload, err := transactionMessage(transaction)
+	if err != nil {
+		return err
+	}
+
+	if err := transactionRepo.CreateWithOutbox(transaction, payload); err != nil {
+		return fmt.Errorf("failed to queue transaction: %v", err)
+	}
+	return nil
+}
+
+// Encodes the queue message that announces a transaction to the processor
+func transactionMessage(transaction *domain.Transaction) ([]byte, error) {
+	message := models.TransactionMessage{
+		TransactionID: transaction.ID,
+		AccountID:     transaction.AccountID,
+		ToAccountID:   transaction.ToAccountID,
+		Type:          transaction.Type,
+		Amount:        transaction.Amount,
+		ToAmount:      transaction.ToAmount,
+		HoldID:        transaction.HoldID,
+		ReversalOf:    transaction.ReversalOf,
+		Description:   transaction.Description,
+	}
+
+	return json.Marshal(message)
+}
+
+// CreateScheduled queues the transaction a schedule describes under the
+// given ID, applying the same checks as a request made through the API
+func (s *TransactionService) CreateScheduled(id string, scheduled *domain.ScheduledTransaction) (*domain.Transaction, error) {
+	switch scheduled.Type {
+	case constants.TransactionTypeDeposit:
+		return s.createDeposit(id, scheduled.AccountID, scheduled.Amount, scheduled.Description)
+	case constants.TransactionTypeWithdrawal:
+		return s.createWithdrawal(id, scheduled.AccountID, scheduled.Amount, scheduled.Description)
+	case constants.TransactionTypeTransfer:
+		return s.createTransfer(id, scheduled.AccountID, scheduled.ToAccountID, scheduled.Amount, scheduled.Description)
+	}
+	return nil, fmt.Errorf("%w: %q transactions cannot be scheduled", domain.ErrInvalidTransaction, scheduled.Type)
+}
+
 // Retrieves a transaction by ID
 func (s *TransactionService) GetTransaction(id string) (*domain.Transaction, error) {
 	return s.transactionRepo.GetByID(id)
 }
 
-// lists all transactions for an account
-func (s *TransactionService) ListTransactionsByAccount(accountID string) ([]*domain.Transaction, error) {
-	return s.transactionRepo.ListByAccountID(accountID)
+// lists one page of an account's transactions matching the filter
+func (s *TransactionService) ListTransactionsByAccount(accountID string, filter domain.TransactionFilter) (*domain.TransactionPage, error) {
+	if _, err := s.accountRepo.GetByID(accountID); err != nil {
+		return nil, err
+	}
+	filter.AccountID = accountID
+	return s.transactionRepo.List(filter)
 }
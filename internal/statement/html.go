@@ -0,0 +1,59 @@
+// Package statement renders account statements as documents for customers
+package statement
+
+import (
+	"html/template"
+	"io"
+	"time"
+
+	"banking-ledger/internal/domain"
+)
+
+var page = template.Must(template.New("statement").Funcs(template.FuncMap{
+	"date": func(t time.Time) string { return t.UTC().Format("2006-01-02") },
+	// The period's To is exclusive; customers expect the last day covered
+	"lastDay": func(t time.Time) string { return t.UTC().Add(-time.Nanosecond).Format("2006-01-02") },
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>Statement {{.AccountID}} {{date .From}} to {{lastDay .To}}</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+table { border-collapse: collapse; width: 100%; }
+th, td { padding: 0.4em 0.6em; border-bottom: 1px solid #ddd; text-align: left; }
+td.amount, th.amount { text-align: right; font-variant-numeric: tabular-nums; }
+tfoot td { font-weight: bold; }
+</style>
+</head>
+<body>
+<h1>Account statement</h1>
+<p>
+{{.AccountName}}<br>
+Account {{.AccountID}} ({{.Currency}})<br>
+{{date .From}} to {{lastDay .To}}
+</p>
+<table>
+<thead>
+<tr><th>Date</th><th>Description</th><th>Type</th><th class="amount">Amount</th><th class="amount">Balance</th></tr>
+</thead>
+<tbody>
+<tr><td>{{date .From}}</td><td>Opening balance</td><td></td><td class="amount"></td><td class="amount">{{.OpeningBalance}}</td></tr>
+{{- range .Lines}}
+<tr><td>{{date .Date}}</td><td>{{.Description}}</td><td>{{.Type}}</td><td class="amount">{{.Amount}}</td><td class="amount">{{.Balance}}</td></tr>
+{{- end}}
+</tbody>
+<tfoot>
+<tr><td>{{lastDay .To}}</td><td>Closing balance</td><td></td><td class="amount"></td><td class="amount">{{.ClosingBalance}}</td></tr>
+</tfoot>
+</table>
+<p>Total credits {{.TotalCredits}} &middot; Total debits {{.TotalDebits}}</p>
+<p><small>Generated {{.GeneratedAt.UTC.Format "2006-01-02 15:04 UTC"}}</small></p>
+</body>
+</html>
+`))
+
+// RenderHTML writes the statement as a standalone HTML page
+func RenderHTML(w io.Writer, statement *domain.Statement) error {
+	return page.Execute(w, statement)
+}